@@ -16,6 +16,7 @@ package analyze
 import (
 	"fmt"
 	"os"
+	"sort"
 
 	"github.com/fatih/color"
 	"github.com/k8sgpt-ai/k8sgpt/pkg/analysis"
@@ -34,6 +35,11 @@ var (
 	maxConcurrency int
 	withDoc        bool
 	shortText      bool
+	enrich         bool
+	enrichTokens   int
+	enrichLogLines int64
+	dryRun         bool
+	maxTokens      int
 )
 
 // AnalyzeCmd represents the problems command
@@ -45,10 +51,15 @@ var AnalyzeCmd = &cobra.Command{
 	provide you with a list of issues that need to be resolved`,
 	Run: func(cmd *cobra.Command, args []string) {
 
+		// a dry run prints the prompts of the explanation without calling the AI backend
+		if dryRun {
+			explain = true
+		}
+
 		// AnalysisResult configuration
 
 		config, err := analysis.NewAnalysis(backend,
-			language, filters, namespace, nocache, explain, maxConcurrency, withDoc, "", shortText)
+			language, filters, namespace, nocache, explain, maxConcurrency, withDoc, "", shortText, dryRun)
 		if err != nil {
 			color.Red("Error: %v", err)
 			os.Exit(1)
@@ -56,7 +67,15 @@ var AnalyzeCmd = &cobra.Command{
 
 		config.RunAnalysis()
 
+		if explain && enrich {
+			enrichOptions := analysis.DefaultEnrichOptions()
+			enrichOptions.MaxTokens = enrichTokens
+			enrichOptions.LogLines = enrichLogLines
+			config.EnrichResults(enrichOptions)
+		}
+
 		if explain {
+			config.MaxTokens = maxTokens
 			err := config.GetAIResults(output, anonymize)
 			if err != nil {
 				color.Red("Error: %v", err)
@@ -64,6 +83,11 @@ var AnalyzeCmd = &cobra.Command{
 			}
 		}
 
+		if dryRun {
+			printPrompts(config)
+			return
+		}
+
 		// print results
 		output, err := config.PrintOutput(output)
 		if err != nil {
@@ -78,6 +102,25 @@ var AnalyzeCmd = &cobra.Command{
 	},
 }
 
+func printPrompts(config *analysis.Analysis) {
+	for _, prompt := range config.Prompts {
+		fmt.Printf("%s %s\n%s\n\n", color.CyanString("Prompt for"), color.YellowString(prompt.Name), prompt.Text)
+	}
+	if config.Anonymizer == nil {
+		return
+	}
+	mapping := config.Anonymizer.Mapping()
+	pseudonyms := make([]string, 0, len(mapping))
+	for pseudonym := range mapping {
+		pseudonyms = append(pseudonyms, pseudonym)
+	}
+	sort.Strings(pseudonyms)
+	fmt.Println(color.CyanString("Pseudonyms (kept locally):"))
+	for _, pseudonym := range pseudonyms {
+		fmt.Printf("  %s => %s\n", pseudonym, mapping[pseudonym])
+	}
+}
+
 func init() {
 
 	// namespace flag
@@ -85,7 +128,9 @@ func init() {
 	// no cache flag
 	AnalyzeCmd.Flags().BoolVarP(&nocache, "no-cache", "c", false, "Do not use cached data")
 	// anonymize flag
-	AnalyzeCmd.Flags().BoolVarP(&anonymize, "anonymize", "a", false, "Anonymize data before sending it to the AI backend. This flag masks sensitive data, such as Kubernetes object names and labels, by replacing it with a key. However, please note that this flag does not currently apply to events.")
+	AnalyzeCmd.Flags().BoolVarP(&anonymize, "anonymize", "a", false, "Anonymize data before sending it to the AI backend. This flag replaces object names, namespaces, IPs, hostnames, image registries, emails and tokens found in failures, events and logs with pseudonyms that are mapped back in the answer.")
+	// dry-run flag
+	AnalyzeCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Print the prompts that would be sent to the AI backend instead of sending them, no AI backend needs to be configured (implies --explain)")
 	// array of strings flag
 	AnalyzeCmd.Flags().StringSliceVarP(&filters, "filter", "f", []string{}, "Filter for these analyzers (e.g. Pod, PersistentVolumeClaim, Service, ReplicaSet)")
 	// explain flag
@@ -101,4 +146,10 @@ func init() {
 	AnalyzeCmd.Flags().IntVarP(&maxConcurrency, "max-concurrency", "m", 10, "Maximum number of concurrent requests to the Kubernetes API server")
 	// kubernetes doc flag
 	AnalyzeCmd.Flags().BoolVarP(&withDoc, "with-doc", "d", false, "Give me the official documentation of the involved field")
+	// enrichment flags
+	AnalyzeCmd.Flags().BoolVar(&enrich, "enrich", false, "Attach events, container logs, the owner spec and field docs to the AI prompt (requires --explain)")
+	AnalyzeCmd.Flags().IntVar(&enrichTokens, "enrich-max-tokens", 1000, "Estimated token budget for the context attached to each result")
+	AnalyzeCmd.Flags().Int64Var(&enrichLogLines, "enrich-log-lines", 20, "Number of log lines attached for each failing container")
+	// token budget flag
+	AnalyzeCmd.Flags().IntVar(&maxTokens, "max-tokens", 0, "Stop calling the AI backend once the run has used this many tokens (0 means no limit)")
 }
@@ -17,6 +17,7 @@ package cache
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/fatih/color"
 	"github.com/k8sgpt-ai/k8sgpt/pkg/cache"
@@ -24,7 +25,17 @@ import (
 )
 
 var (
-	region string
+	region         string
+	cacheType      string
+	endpoint       string
+	pathStyle      bool
+	projectId      string
+	storageAccount string
+	containerName  string
+	redisAddress   string
+	redisPassword  string
+	redisDB        int
+	memoryEntries  int
 )
 
 // addCmd represents the add command
@@ -33,10 +44,27 @@ var addCmd = &cobra.Command{
 	Short: "Add a remote cache",
 	Long: `This command allows you to add a remote cache to store the results of an analysis.
 	The supported cache types are:
-	- S3`,
+	- s3: AWS S3, or any S3-compatible store (e.g. MinIO) with --endpoint and --path-style
+	- redis: a Redis server shared by several replicas
+	- azure: Azure Blob Storage
+	- gcs: Google Cloud Storage
+	Any remote cache can be fronted by an in-memory LRU tier with --memory-entries.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		fmt.Println(color.YellowString("Adding remote S3 based cache"))
-		err := cache.AddRemoteCache(bucketname, region)
+		fmt.Println(color.YellowString("Adding remote %s based cache", cacheType))
+		err := cache.AddRemoteCache(cache.CacheProvider{
+			Type:           cacheType,
+			BucketName:     bucketname,
+			Region:         region,
+			Endpoint:       endpoint,
+			PathStyle:      pathStyle,
+			ProjectId:      projectId,
+			StorageAccount: storageAccount,
+			ContainerName:  containerName,
+			RedisAddress:   redisAddress,
+			RedisPassword:  redisPassword,
+			RedisDB:        redisDB,
+			MemoryEntries:  memoryEntries,
+		})
 		if err != nil {
 			color.Red("Error: %v", err)
 			os.Exit(1)
@@ -46,9 +74,16 @@ var addCmd = &cobra.Command{
 
 func init() {
 	CacheCmd.AddCommand(addCmd)
-	addCmd.Flags().StringVarP(&region, "region", "r", "", "The region to use for the cache")
-	addCmd.Flags().StringVarP(&bucketname, "bucket", "b", "", "The name of the bucket to use for the cache")
-	addCmd.MarkFlagRequired("bucket")
-	addCmd.MarkFlagRequired("region")
-
+	addCmd.Flags().StringVarP(&cacheType, "type", "t", cache.TypeS3, fmt.Sprintf("The type of the cache (%s)", strings.Join(cache.RegisteredTypes(), ", ")))
+	addCmd.Flags().StringVarP(&region, "region", "r", "", "The region to use for the s3 or gcs cache")
+	addCmd.Flags().StringVarP(&bucketname, "bucket", "b", "", "The name of the bucket to use for the s3 or gcs cache")
+	addCmd.Flags().StringVar(&endpoint, "endpoint", "", "The URL of an S3-compatible store, e.g. http://minio:9000")
+	addCmd.Flags().BoolVar(&pathStyle, "path-style", false, "Use path-style addressing for the s3 cache, required by MinIO")
+	addCmd.Flags().StringVar(&projectId, "project-id", "", "The GCP project of the gcs cache")
+	addCmd.Flags().StringVar(&storageAccount, "storage-account", "", "The storage account of the azure cache")
+	addCmd.Flags().StringVar(&containerName, "container", "", "The blob container of the azure cache")
+	addCmd.Flags().StringVar(&redisAddress, "redis-address", "", "The address of the redis cache, e.g. redis:6379")
+	addCmd.Flags().StringVar(&redisPassword, "redis-password", "", "The password of the redis cache")
+	addCmd.Flags().IntVar(&redisDB, "redis-db", 0, "The database number of the redis cache")
+	addCmd.Flags().IntVar(&memoryEntries, "memory-entries", 0, "Size of the in-memory LRU tier in front of the remote cache, 0 disables it")
 }
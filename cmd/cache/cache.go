@@ -26,7 +26,7 @@ var (
 var CacheCmd = &cobra.Command{
 	Use:   "cache",
 	Short: "For working with the cache the results of an analysis",
-	Long:  `Cache commands allow you to add a remote cache, list the contents of the cache, and remove items from the cache.`,
+	Long:  `Cache commands allow you to add a remote cache, list, inspect and purge the contents of the cache, and configure its expiry and size.`,
 	Run: func(cmd *cobra.Command, args []string) {
 		cmd.Help()
 	},
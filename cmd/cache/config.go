@@ -0,0 +1,63 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cache
+
+import (
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/cache"
+	"github.com/spf13/cobra"
+)
+
+var (
+	ttl     string
+	maxSize string
+)
+
+// configCmd represents the config command
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Configure the expiry and size of the cache",
+	Long: `This command sets the limits of the cache.
+	Entries older than --ttl (e.g. 72h) are deleted when they are read or purged, and after each analysis the entries written first are deleted until the cache fits in --max-size (e.g. 100Mi).
+	A limit that is not passed is left unchanged, an empty value (e.g. --ttl "") removes it.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if !cmd.Flags().Changed("ttl") && !cmd.Flags().Changed("max-size") {
+			color.Red("Error: set --ttl or --max-size")
+			os.Exit(1)
+		}
+		// only the limits passed on the command line are changed
+		var ttlLimit, maxSizeLimit *string
+		if cmd.Flags().Changed("ttl") {
+			ttlLimit = &ttl
+		}
+		if cmd.Flags().Changed("max-size") {
+			maxSizeLimit = &maxSize
+		}
+		err := cache.SetCacheLimits(ttlLimit, maxSizeLimit)
+		if err != nil {
+			color.Red("Error: %v", err)
+			os.Exit(1)
+		}
+		color.Green("Cache limits updated")
+	},
+}
+
+func init() {
+	CacheCmd.AddCommand(configCmd)
+	configCmd.Flags().StringVar(&ttl, "ttl", "", "How long an answer is reused, e.g. 72h")
+	configCmd.Flags().StringVar(&maxSize, "max-size", "", "Maximum total size of the cache, e.g. 100Mi")
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cache
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/cache"
+	"github.com/spf13/cobra"
+)
+
+// getCmd represents the get command
+var getCmd = &cobra.Command{
+	Use:   "get <key>",
+	Short: "Show a cache entry",
+	Long:  `This command shows the metadata and the cached answer stored under a key.`,
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		c := getCache()
+		if !c.Exists(args[0]) {
+			color.Red("Error: key %s not found in the cache", args[0])
+			os.Exit(1)
+		}
+		raw, err := c.Load(args[0])
+		if err != nil {
+			color.Red("Error: %v", err)
+			os.Exit(1)
+		}
+		entry := cache.DecodeEntry(raw)
+		data, err := base64.StdEncoding.DecodeString(entry.Data)
+		if err != nil {
+			data = []byte(entry.Data)
+		}
+
+		fmt.Printf("Provider:    %s\n", valueOrNone(entry.Provider))
+		fmt.Printf("Model:       %s\n", valueOrNone(entry.Model))
+		fmt.Printf("Prompt hash: %s\n", valueOrNone(entry.PromptHash))
+		if !entry.CreatedAt.IsZero() {
+			fmt.Printf("Created:     %s\n", entry.CreatedAt.Format(time.RFC3339))
+		}
+		fmt.Printf("\n%s\n", string(data))
+	},
+}
+
+func init() {
+	CacheCmd.AddCommand(getCmd)
+}
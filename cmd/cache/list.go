@@ -15,10 +15,11 @@ limitations under the License.
 package cache
 
 import (
+	"fmt"
 	"os"
+	"time"
 
 	"github.com/fatih/color"
-	"github.com/k8sgpt-ai/k8sgpt/pkg/cache"
 	"github.com/spf13/cobra"
 )
 
@@ -29,22 +30,16 @@ var listCmd = &cobra.Command{
 	Long:  `This command allows you to list the contents of the cache.`,
 	Run: func(cmd *cobra.Command, args []string) {
 
-		// load remote cache if it is configured
-		remoteCacheEnabled, err := cache.RemoteCacheEnabled()
-		if err != nil {
-			color.Red("Error: %v", err)
-			os.Exit(1)
-		}
-		c := cache.New(false, remoteCacheEnabled)
+		c := getCache()
 		// list the contents of the cache
-		names, err := c.List()
+		objects, err := c.List()
 		if err != nil {
 			color.Red("Error: %v", err)
 			os.Exit(1)
 		}
-		
-		for _, name := range names {
-			println(name)
+
+		for _, object := range objects {
+			fmt.Printf("%s  %s  %d bytes\n", object.Name, object.UpdatedAt.Format(time.RFC3339), object.Size)
 		}
 	},
 }
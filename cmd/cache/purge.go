@@ -0,0 +1,47 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cache
+
+import (
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/cache"
+	"github.com/spf13/cobra"
+)
+
+var (
+	purgeAll bool
+)
+
+// purgeCmd represents the purge command
+var purgeCmd = &cobra.Command{
+	Use:   "purge",
+	Short: "Delete expired entries from the cache",
+	Long:  `This command deletes the entries older than the configured TTL, or every entry with --all.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		deleted, err := cache.Purge(getCache(), purgeAll)
+		if err != nil {
+			color.Red("Error: %v", err)
+			os.Exit(1)
+		}
+		color.Green("Deleted %d entries", deleted)
+	},
+}
+
+func init() {
+	CacheCmd.AddCommand(purgeCmd)
+	purgeCmd.Flags().BoolVar(&purgeAll, "all", false, "Delete every entry, not only the expired ones")
+}
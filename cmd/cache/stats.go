@@ -0,0 +1,76 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cache
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/cache"
+	"github.com/spf13/cobra"
+)
+
+// statsCmd represents the stats command
+var statsCmd = &cobra.Command{
+	Use:   "stats",
+	Short: "Show statistics about the cache",
+	Long:  `This command shows the number of entries, the size and the age of the cache, and the configured limits.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		c := getCache()
+		stats, err := cache.GetStats(c)
+		if err != nil {
+			color.Red("Error: %v", err)
+			os.Exit(1)
+		}
+		config, err := cache.GetCacheConfig()
+		if err != nil {
+			color.Red("Error: %v", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Entries:    %d\n", stats.Entries)
+		fmt.Printf("Total size: %d bytes\n", stats.TotalSize)
+		fmt.Printf("Expired:    %d\n", stats.Expired)
+		if stats.Entries > 0 {
+			fmt.Printf("Oldest:     %s\n", stats.Oldest.Format(time.RFC3339))
+			fmt.Printf("Newest:     %s\n", stats.Newest.Format(time.RFC3339))
+		}
+		fmt.Printf("TTL:        %s\n", valueOrNone(config.TTL))
+		fmt.Printf("Max size:   %s\n", valueOrNone(config.MaxSize))
+	},
+}
+
+func valueOrNone(value string) string {
+	if value == "" {
+		return "none"
+	}
+	return value
+}
+
+// getCache returns the configured cache, the remote one when it is set
+func getCache() cache.ICache {
+	c, err := cache.New(false)
+	if err != nil {
+		color.Red("Error: %v", err)
+		os.Exit(1)
+	}
+	return c
+}
+
+func init() {
+	CacheCmd.AddCommand(statsCmd)
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package draincheck
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/analyzer"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// DrainCheckCmd represents the drain-check command
+var DrainCheckCmd = &cobra.Command{
+	Use:   "drain-check <node>",
+	Short: "List the PodDisruptionBudgets and pods preventing a node drain",
+	Long: `This command lists the pods of a node that a drain cannot evict and why: the PodDisruptionBudgets allowing no
+disruption or overlapping, and the pods kubectl drain refuses without --force or --delete-emptydir-data.
+The DaemonSet and static pods are ignored like kubectl drain --ignore-daemonsets does. The command exits with 1 when the drain is blocked.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		client, err := kubernetes.NewClient(viper.GetString("kubecontext"), viper.GetString("kubeconfig"))
+		if err != nil {
+			color.Red("Error: %v", err)
+			os.Exit(1)
+		}
+		blockers, err := analyzer.DrainBlockers(context.Background(), client.GetClient(), args[0])
+		if err != nil {
+			color.Red("Error: %v", err)
+			os.Exit(1)
+		}
+		if len(blockers) == 0 {
+			color.Green("Node %s can be drained", args[0])
+			return
+		}
+		color.Red("Node %s cannot be drained:", args[0])
+		for _, blocker := range blockers {
+			line := fmt.Sprintf("- Pod %s: %s", blocker.Pod, blocker.Reason)
+			if len(blocker.PodDisruptionBudgets) > 0 {
+				line += fmt.Sprintf(" (PodDisruptionBudget %s)", strings.Join(blocker.PodDisruptionBudgets, ", "))
+			}
+			fmt.Println(line)
+		}
+		os.Exit(1)
+	},
+}
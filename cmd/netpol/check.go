@@ -0,0 +1,137 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package netpol
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/netpol"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+var (
+	from string
+	to   string
+)
+
+// checkCmd represents the check command
+var checkCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Check whether a pod can reach a Service",
+	Long: `This command tells whether a pod can reach the pods of a Service on one of its ports, by name or number.
+Every pod of the Service is checked, the NetworkPolicies denying the traffic are named. The command exits with 1 when no pod can be reached.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		fromNamespace, fromPod, ok := strings.Cut(from, "/")
+		if !ok {
+			color.Red("Error: --from must be namespace/pod")
+			os.Exit(1)
+		}
+		target, portName, ok := strings.Cut(to, ":")
+		toNamespace, toService, ok2 := strings.Cut(target, "/")
+		if !ok || !ok2 {
+			color.Red("Error: --to must be namespace/service:port")
+			os.Exit(1)
+		}
+
+		client, err := kubernetes.NewClient(viper.GetString("kubecontext"), viper.GetString("kubeconfig"))
+		if err != nil {
+			color.Red("Error: %v", err)
+			os.Exit(1)
+		}
+		ctx := context.Background()
+
+		source, err := client.GetClient().CoreV1().Pods(fromNamespace).Get(ctx, fromPod, metav1.GetOptions{})
+		if err != nil {
+			color.Red("Error: %v", err)
+			os.Exit(1)
+		}
+		svc, err := client.GetClient().CoreV1().Services(toNamespace).Get(ctx, toService, metav1.GetOptions{})
+		if err != nil {
+			color.Red("Error: %v", err)
+			os.Exit(1)
+		}
+		port, err := servicePort(svc, portName)
+		if err != nil {
+			color.Red("Error: %v", err)
+			os.Exit(1)
+		}
+		if len(svc.Spec.Selector) == 0 {
+			color.Red("Error: Service %s has no selector, its pods cannot be found", target)
+			os.Exit(1)
+		}
+		backends, err := client.GetClient().CoreV1().Pods(toNamespace).List(ctx, metav1.ListOptions{
+			LabelSelector: labels.SelectorFromSet(svc.Spec.Selector).String(),
+		})
+		if err != nil {
+			color.Red("Error: %v", err)
+			os.Exit(1)
+		}
+		if len(backends.Items) == 0 {
+			color.Red("Error: Service %s selects no pod", target)
+			os.Exit(1)
+		}
+
+		engine, err := netpol.NewEngine(ctx, client.GetClient())
+		if err != nil {
+			color.Red("Error: %v", err)
+			os.Exit(1)
+		}
+		reachable := false
+		for _, backend := range backends.Items {
+			number, ok := netpol.TargetPort(backend, port)
+			if !ok {
+				color.Yellow("%s/%s does not declare the port %s", backend.Namespace, backend.Name, port.TargetPort.String())
+				continue
+			}
+			verdict := engine.Check(*source, backend, number, port.Protocol)
+			if verdict.Allowed {
+				reachable = true
+				color.Green("Allowed: %s", verdict.Reason)
+			} else {
+				color.Red("Denied: %s", verdict.Reason)
+			}
+		}
+		if !reachable {
+			os.Exit(1)
+		}
+	},
+}
+
+// servicePort finds a port of a Service by name or number
+func servicePort(svc *v1.Service, name string) (v1.ServicePort, error) {
+	number, err := strconv.Atoi(name)
+	for _, port := range svc.Spec.Ports {
+		if port.Name == name || (err == nil && int(port.Port) == number) {
+			return port, nil
+		}
+	}
+	return v1.ServicePort{}, fmt.Errorf("the Service %s/%s has no port %s", svc.Namespace, svc.Name, name)
+}
+
+func init() {
+	checkCmd.Flags().StringVar(&from, "from", "", "The source pod, as namespace/pod")
+	checkCmd.Flags().StringVar(&to, "to", "", "The destination Service and port, as namespace/service:port")
+	_ = checkCmd.MarkFlagRequired("from")
+	_ = checkCmd.MarkFlagRequired("to")
+}
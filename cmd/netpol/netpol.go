@@ -0,0 +1,35 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package netpol
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// NetpolCmd represents the netpol command
+var NetpolCmd = &cobra.Command{
+	Use:     "netpol",
+	Aliases: []string{"networkpolicy"},
+	Short:   "Evaluate the NetworkPolicies of the cluster",
+	Long: `The netpol command evaluates all the NetworkPolicies of the cluster to tell whether the traffic between pods is allowed. For example:
+
+	k8sgpt netpol check --from shop/frontend-7d4b9 --to shop/api:http`,
+	Run: func(cmd *cobra.Command, args []string) {
+		_ = cmd.Help()
+	},
+}
+
+func init() {
+	NetpolCmd.AddCommand(checkCmd)
+}
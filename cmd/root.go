@@ -21,9 +21,11 @@ import (
 	"github.com/k8sgpt-ai/k8sgpt/cmd/analyze"
 	"github.com/k8sgpt-ai/k8sgpt/cmd/auth"
 	"github.com/k8sgpt-ai/k8sgpt/cmd/cache"
+	"github.com/k8sgpt-ai/k8sgpt/cmd/draincheck"
 	"github.com/k8sgpt-ai/k8sgpt/cmd/filters"
 	"github.com/k8sgpt-ai/k8sgpt/cmd/generate"
 	"github.com/k8sgpt-ai/k8sgpt/cmd/integration"
+	"github.com/k8sgpt-ai/k8sgpt/cmd/netpol"
 	"github.com/k8sgpt-ai/k8sgpt/cmd/serve"
 	"github.com/k8sgpt-ai/k8sgpt/cmd/test"
 	"github.com/k8sgpt-ai/k8sgpt/pkg/util"
@@ -72,10 +74,12 @@ func init() {
 	rootCmd.AddCommand(filters.FiltersCmd)
 	rootCmd.AddCommand(generate.GenerateCmd)
 	rootCmd.AddCommand(integration.IntegrationCmd)
+	rootCmd.AddCommand(netpol.NetpolCmd)
 	rootCmd.AddCommand(serve.ServeCmd)
 	rootCmd.AddCommand(serve.HttpCmd)
 	rootCmd.AddCommand(test.TestCmd)
 	rootCmd.AddCommand(cache.CacheCmd)
+	rootCmd.AddCommand(draincheck.DrainCheckCmd)
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.k8sgpt.yaml)")
 	rootCmd.PersistentFlags().StringVar(&kubecontext, "kubecontext", "", "Kubernetes context to use. Only required if out-of-cluster.")
 	rootCmd.PersistentFlags().StringVar(&kubeconfig, "kubeconfig", "", "Path to a kubeconfig. Only required if out-of-cluster.")
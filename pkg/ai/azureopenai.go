@@ -37,7 +37,7 @@ func (c *AzureAIClient) Configure(config IAIConfig, lang string) error {
 	return nil
 }
 
-func (c *AzureAIClient) GetCompletion(ctx context.Context, prompt string, promptTmpl string) (string, error) {
+func (c *AzureAIClient) GetCompletion(ctx context.Context, prompt string, promptTmpl string) (string, Usage, error) {
 	// Create a completion request
 	resp, err := c.client.CreateChatCompletion(ctx, openai.ChatCompletionRequest{
 		Model: c.model,
@@ -49,47 +49,54 @@ func (c *AzureAIClient) GetCompletion(ctx context.Context, prompt string, prompt
 		},
 	})
 	if err != nil {
-		return "", err
+		return "", Usage{}, err
 	}
-	return resp.Choices[0].Message.Content, nil
+	return resp.Choices[0].Message.Content, Usage{
+		PromptTokens:     resp.Usage.PromptTokens,
+		CompletionTokens: resp.Usage.CompletionTokens,
+	}, nil
 }
 
-func (a *AzureAIClient) Parse(ctx context.Context, prompt []string, cache cache.ICache, promptTmpl string) (string, error) {
+func (a *AzureAIClient) Parse(ctx context.Context, prompt []string, c cache.ICache, promptTmpl string) (string, Usage, error) {
 	inputKey := strings.Join(prompt, " ")
 	// Check for cached data
-	cacheKey := util.GetCacheKey(a.GetName(), a.language, inputKey)
+	cacheKey := util.GetCacheKey(a.GetName(), a.language, a.model, promptTmpl, inputKey)
 
-	if !cache.IsCacheDisabled() && cache.Exists(cacheKey) {
-		response, err := cache.Load(cacheKey)
+	if !c.IsCacheDisabled() {
+		entry, err := cache.LoadEntry(c, cacheKey)
 		if err != nil {
-			return "", err
+			return "", Usage{}, err
 		}
 
-		if response != "" {
-			output, err := base64.StdEncoding.DecodeString(response)
+		if entry != nil && entry.Data != "" {
+			output, err := base64.StdEncoding.DecodeString(entry.Data)
 			if err != nil {
 				color.Red("error decoding cached data: %v", err)
-				return "", nil
+				return "", Usage{}, nil
 			}
-			return string(output), nil
+			return string(output), Usage{CacheHit: true}, nil
 		}
 	}
 
-	response, err := a.GetCompletion(ctx, inputKey, promptTmpl)
+	response, usage, err := a.GetCompletion(ctx, inputKey, promptTmpl)
 	if err != nil {
-		return "", err
+		return "", Usage{}, err
 	}
 
-	err = cache.Store(cacheKey, base64.StdEncoding.EncodeToString([]byte(response)))
+	err = cache.StoreEntry(c, cacheKey, cache.NewEntry(a.GetName(), a.model, promptTmpl, base64.StdEncoding.EncodeToString([]byte(response))))
 
 	if err != nil {
 		color.Red("error storing value to cache: %v", err)
-		return "", nil
+		return "", usage, nil
 	}
 
-	return response, nil
+	return response, usage, nil
 }
 
 func (a *AzureAIClient) GetName() string {
 	return "azureopenai"
 }
+
+func (a *AzureAIClient) GetModel() string {
+	return a.model
+}
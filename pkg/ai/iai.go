@@ -36,9 +36,10 @@ var (
 
 type IAI interface {
 	Configure(config IAIConfig, language string) error
-	GetCompletion(ctx context.Context, prompt string, promptTmpl string) (string, error)
-	Parse(ctx context.Context, prompt []string, cache cache.ICache, promptTmpl string) (string, error)
+	GetCompletion(ctx context.Context, prompt string, promptTmpl string) (string, Usage, error)
+	Parse(ctx context.Context, prompt []string, cache cache.ICache, promptTmpl string) (string, Usage, error)
 	GetName() string
+	GetModel() string
 }
 
 type IAIConfig interface {
@@ -61,6 +62,8 @@ func NewClient(provider string) IAI {
 type AIConfiguration struct {
 	Providers       []AIProvider `mapstructure:"providers"`
 	DefaultProvider string       `mapstructure:"defaultprovider"`
+	// Prices overrides the price per 1000 tokens of a model, used to estimate the cost of a run
+	Prices map[string]ModelPrice `mapstructure:"prices" yaml:"prices,omitempty"`
 }
 
 type AIProvider struct {
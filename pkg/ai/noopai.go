@@ -38,35 +38,39 @@ func (c *NoOpAIClient) Configure(config IAIConfig, language string) error {
 	return nil
 }
 
-func (c *NoOpAIClient) GetCompletion(ctx context.Context, prompt string, promptTmpl string) (string, error) {
+func (c *NoOpAIClient) GetCompletion(ctx context.Context, prompt string, promptTmpl string) (string, Usage, error) {
 	// Create a completion request
 	response := "I am a noop response to the prompt " + prompt
-	return response, nil
+	return response, EstimateUsage(prompt, response), nil
 }
 
-func (a *NoOpAIClient) Parse(ctx context.Context, prompt []string, cache cache.ICache, promptTmpl string) (string, error) {
+func (a *NoOpAIClient) Parse(ctx context.Context, prompt []string, c cache.ICache, promptTmpl string) (string, Usage, error) {
 	// parse the text with the AI backend
 	inputKey := strings.Join(prompt, " ")
 	// Check for cached data
 	sEnc := base64.StdEncoding.EncodeToString([]byte(inputKey))
-	cacheKey := util.GetCacheKey(a.GetName(), a.language, sEnc)
+	cacheKey := util.GetCacheKey(a.GetName(), a.language, a.model, promptTmpl, sEnc)
 
-	response, err := a.GetCompletion(ctx, inputKey, promptTmpl)
+	response, usage, err := a.GetCompletion(ctx, inputKey, promptTmpl)
 	if err != nil {
 		color.Red("error getting completion: %v", err)
-		return "", err
+		return "", Usage{}, err
 	}
 
-	err = cache.Store(cacheKey, base64.StdEncoding.EncodeToString([]byte(response)))
+	err = cache.StoreEntry(c, cacheKey, cache.NewEntry(a.GetName(), a.model, promptTmpl, base64.StdEncoding.EncodeToString([]byte(response))))
 
 	if err != nil {
 		color.Red("error storing value to cache: %v", err)
-		return "", nil
+		return "", usage, nil
 	}
 
-	return response, nil
+	return response, usage, nil
 }
 
 func (a *NoOpAIClient) GetName() string {
 	return "noopai"
 }
+
+func (a *NoOpAIClient) GetModel() string {
+	return a.model
+}
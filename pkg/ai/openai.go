@@ -74,7 +74,7 @@ func (c *OpenAIClient) Configure(config IAIConfig, language string) error {
 	return nil
 }
 
-func (c *OpenAIClient) GetCompletion(ctx context.Context, prompt string, promptTmpl string) (string, error) {
+func (c *OpenAIClient) GetCompletion(ctx context.Context, prompt string, promptTmpl string) (string, Usage, error) {
 	// Create a completion request
 	if len(promptTmpl) == 0 {
 		promptTmpl = PromptMap["default"]
@@ -90,47 +90,59 @@ func (c *OpenAIClient) GetCompletion(ctx context.Context, prompt string, promptT
 		},
 	})
 	if err != nil {
-		return "", err
+		return "", Usage{}, err
 	}
-	return resp.Choices[0].Message.Content, nil
+	content := resp.Choices[0].Message.Content
+	if resp.Usage.TotalTokens == 0 {
+		// some OpenAI compatible backends such as LocalAI do not report the usage
+		return content, EstimateUsage(fmt.Sprintf(promptTmpl, c.language, prompt), content), nil
+	}
+	return content, Usage{
+		PromptTokens:     resp.Usage.PromptTokens,
+		CompletionTokens: resp.Usage.CompletionTokens,
+	}, nil
 }
 
-func (a *OpenAIClient) Parse(ctx context.Context, prompt []string, cache cache.ICache, promptTmpl string) (string, error) {
+func (a *OpenAIClient) Parse(ctx context.Context, prompt []string, c cache.ICache, promptTmpl string) (string, Usage, error) {
 	inputKey := strings.Join(prompt, " ")
 	// Check for cached data
-	cacheKey := util.GetCacheKey(a.GetName(), a.language, inputKey)
+	cacheKey := util.GetCacheKey(a.GetName(), a.language, a.model, promptTmpl, inputKey)
 
-	if !cache.IsCacheDisabled() && cache.Exists(cacheKey) {
-		response, err := cache.Load(cacheKey)
+	if !c.IsCacheDisabled() {
+		entry, err := cache.LoadEntry(c, cacheKey)
 		if err != nil {
-			return "", err
+			return "", Usage{}, err
 		}
 
-		if response != "" {
-			output, err := base64.StdEncoding.DecodeString(response)
+		if entry != nil && entry.Data != "" {
+			output, err := base64.StdEncoding.DecodeString(entry.Data)
 			if err != nil {
 				color.Red("error decoding cached data: %v", err)
-				return "", nil
+				return "", Usage{}, nil
 			}
-			return string(output), nil
+			return string(output), Usage{CacheHit: true}, nil
 		}
 	}
 
-	response, err := a.GetCompletion(ctx, inputKey, promptTmpl)
+	response, usage, err := a.GetCompletion(ctx, inputKey, promptTmpl)
 	if err != nil {
-		return "", err
+		return "", Usage{}, err
 	}
 
-	err = cache.Store(cacheKey, base64.StdEncoding.EncodeToString([]byte(response)))
+	err = cache.StoreEntry(c, cacheKey, cache.NewEntry(a.GetName(), a.model, promptTmpl, base64.StdEncoding.EncodeToString([]byte(response))))
 
 	if err != nil {
 		color.Red("error storing value to cache: %v", err)
-		return "", nil
+		return "", usage, nil
 	}
 
-	return response, nil
+	return response, usage, nil
 }
 
 func (a *OpenAIClient) GetName() string {
 	return "openai"
 }
+
+func (a *OpenAIClient) GetModel() string {
+	return a.model
+}
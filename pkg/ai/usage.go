@@ -0,0 +1,84 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ai
+
+import (
+	"strings"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/util"
+)
+
+// Usage is the token consumption of a single completion
+type Usage struct {
+	PromptTokens     int
+	CompletionTokens int
+	// Estimated is set when the provider did not report the usage and it was derived from the text length
+	Estimated bool
+	// CacheHit is set when the answer came from the cache, no tokens were spent
+	CacheHit bool
+}
+
+func (u Usage) TotalTokens() int {
+	return u.PromptTokens + u.CompletionTokens
+}
+
+// EstimateUsage is used for providers that do not report the token usage
+func EstimateUsage(prompt string, completion string) Usage {
+	return Usage{
+		PromptTokens:     util.EstimateTokens(prompt),
+		CompletionTokens: util.EstimateTokens(completion),
+		Estimated:        true,
+	}
+}
+
+// ModelPrice is the price of 1000 tokens of a model
+type ModelPrice struct {
+	Prompt     float64 `mapstructure:"prompt"`
+	Completion float64 `mapstructure:"completion"`
+}
+
+// defaultPrices are the public list prices in USD, they can be overridden with ai.prices in the config
+var defaultPrices = map[string]ModelPrice{
+	"gpt-3.5-turbo": {Prompt: 0.0005, Completion: 0.0015},
+	"gpt-4":         {Prompt: 0.03, Completion: 0.06},
+	"gpt-4-turbo":   {Prompt: 0.01, Completion: 0.03},
+	"gpt-4o":        {Prompt: 0.005, Completion: 0.015},
+}
+
+// GetModelPrice looks up the price of a model, the configured table first. Models are matched
+// by the longest known prefix, so that gpt-4-0613 uses the gpt-4 price.
+func GetModelPrice(prices map[string]ModelPrice, model string) (ModelPrice, bool) {
+	if price, ok := prices[model]; ok {
+		return price, true
+	}
+	if price, ok := defaultPrices[model]; ok {
+		return price, true
+	}
+	var best string
+	for _, table := range []map[string]ModelPrice{prices, defaultPrices} {
+		for name := range table {
+			if strings.HasPrefix(model, name) && len(name) > len(best) {
+				best = name
+			}
+		}
+		if best != "" {
+			return table[best], true
+		}
+	}
+	return ModelPrice{}, false
+}
+
+func (p ModelPrice) Cost(u Usage) float64 {
+	return float64(u.PromptTokens)/1000*p.Prompt + float64(u.CompletionTokens)/1000*p.Completion
+}
@@ -26,10 +26,10 @@ import (
 	openapi_v2 "github.com/google/gnostic/openapiv2"
 	"github.com/k8sgpt-ai/k8sgpt/pkg/ai"
 	"github.com/k8sgpt-ai/k8sgpt/pkg/analyzer"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/anonymizer"
 	"github.com/k8sgpt-ai/k8sgpt/pkg/cache"
 	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
 	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
-	"github.com/k8sgpt-ai/k8sgpt/pkg/util"
 	"github.com/spf13/viper"
 )
 
@@ -47,6 +47,23 @@ type Analysis struct {
 	AnalysisAIProvider string // The name of the AI Provider used for this analysis
 	WithDoc            bool
 	ShortText          bool
+	Language           string
+	// DryRun collects the prompts in Prompts instead of sending them to the AI provider
+	DryRun     bool
+	Prompts    []Prompt
+	Anonymizer *anonymizer.Anonymizer
+	// MaxTokens stops the AI calls once the run has used that many tokens, 0 means no limit
+	MaxTokens int
+	Usage     *Usage
+	Prices    map[string]ai.ModelPrice
+	// Events is shared by the analyzers and the enrichment of a run, so the events are listed only once
+	Events *kubernetes.EventIndex
+}
+
+// Prompt is the exact text that is sent to the AI provider for a result
+type Prompt struct {
+	Name string `json:"name"`
+	Text string `json:"text"`
 }
 
 type AnalysisStatus string
@@ -63,6 +80,7 @@ type JsonOutput struct {
 	Status   AnalysisStatus  `json:"status"`
 	Problems int             `json:"problems"`
 	Results  []common.Result `json:"results"`
+	Usage    *Usage          `json:"usage,omitempty"`
 }
 
 func TestAnalysis() (*Analysis, error) {
@@ -80,8 +98,9 @@ func TestAnalysis() (*Analysis, error) {
 	}, nil
 }
 
+// NewAnalysis configures an analysis, a dry run collects the prompts and needs no AI provider
 func NewAnalysis(backend string, language string, filters []string, namespace string, noCache bool, explain bool, maxConcurrency int, withDoc bool,
-	kubecontext0 string, shortText bool) (*Analysis, error) {
+	kubecontext0 string, shortText bool, dryRun bool) (*Analysis, error) {
 	var configAI ai.AIConfiguration
 	err := viper.UnmarshalKey("ai", &configAI)
 	if err != nil {
@@ -89,34 +108,37 @@ func NewAnalysis(backend string, language string, filters []string, namespace st
 		os.Exit(1)
 	}
 
-	if len(configAI.Providers) == 0 && explain {
-		color.Red("Error: AI provider not specified in configuration. Please run k8sgpt auth")
-		os.Exit(1)
-	}
+	var aiClient ai.IAI = &ai.NoOpAIClient{}
+	if !dryRun {
+		if len(configAI.Providers) == 0 && explain {
+			color.Red("Error: AI provider not specified in configuration. Please run k8sgpt auth")
+			os.Exit(1)
+		}
 
-	// Backend string will have high priority than a default provider
-	// Backend as "openai" represents the default CLI argument passed through
-	if configAI.DefaultProvider != "" && backend == "openai" {
-		backend = configAI.DefaultProvider
-	}
+		// Backend string will have high priority than a default provider
+		// Backend as "openai" represents the default CLI argument passed through
+		if configAI.DefaultProvider != "" && backend == "openai" {
+			backend = configAI.DefaultProvider
+		}
 
-	var aiProvider ai.AIProvider
-	for _, provider := range configAI.Providers {
-		if backend == provider.Name {
-			aiProvider = provider
-			break
+		var aiProvider ai.AIProvider
+		for _, provider := range configAI.Providers {
+			if backend == provider.Name {
+				aiProvider = provider
+				break
+			}
 		}
-	}
 
-	if aiProvider.Name == "" {
-		color.Red("Error: AI provider %s not specified in configuration. Please run k8sgpt auth", backend)
-		return nil, errors.New("AI provider not specified in configuration")
-	}
+		if aiProvider.Name == "" {
+			color.Red("Error: AI provider %s not specified in configuration. Please run k8sgpt auth", backend)
+			return nil, errors.New("AI provider not specified in configuration")
+		}
 
-	aiClient := ai.NewClient(aiProvider.Name)
-	if err := aiClient.Configure(&aiProvider, language); err != nil {
-		color.Red("Error: %v", err)
-		return nil, err
+		aiClient = ai.NewClient(aiProvider.Name)
+		if err := aiClient.Configure(&aiProvider, language); err != nil {
+			color.Red("Error: %v", err)
+			return nil, err
+		}
 	}
 
 	ctx := context.Background()
@@ -135,7 +157,7 @@ func NewAnalysis(backend string, language string, filters []string, namespace st
 	}
 
 	// load remote cache if it is configured
-	remoteCacheEnabled, err := cache.RemoteCacheEnabled()
+	aiCache, err := cache.New(noCache)
 	if err != nil {
 		return nil, err
 	}
@@ -146,12 +168,15 @@ func NewAnalysis(backend string, language string, filters []string, namespace st
 		Client:             client,
 		AIClient:           aiClient,
 		Namespace:          namespace,
-		Cache:              cache.New(noCache, remoteCacheEnabled),
+		Cache:              aiCache,
 		Explain:            explain,
 		MaxConcurrency:     maxConcurrency,
 		AnalysisAIProvider: backend,
 		WithDoc:            withDoc,
 		ShortText:          shortText,
+		Language:           language,
+		Prices:             configAI.Prices,
+		DryRun:             dryRun,
 	}, nil
 }
 
@@ -177,6 +202,7 @@ func (a *Analysis) RunAnalysis() {
 		Namespace:     a.Namespace,
 		AIClient:      a.AIClient,
 		OpenapiSchema: openapiSchema,
+		Events:        a.eventIndex(),
 	}
 
 	semaphore := make(chan struct{}, a.MaxConcurrency)
@@ -269,17 +295,23 @@ func (a *Analysis) GetAIResults(output string, anonymize bool) error {
 	if len(a.Results) == 0 {
 		return nil
 	}
+	if anonymize {
+		a.Anonymizer = a.newAnonymizer()
+	}
 	for index, analysis := range a.Results {
 		var texts []string
 
 		for _, failure := range analysis.Error {
-			if anonymize {
-				for _, s := range failure.Sensitive {
-					failure.Text = util.ReplaceIfMatch(failure.Text, s.Unmasked, s.Masked)
-				}
-			}
 			texts = append(texts, failure.Text)
 		}
+		for _, enrichment := range analysis.Enrichments {
+			texts = append(texts, fmt.Sprintf("Context (%s): %s", enrichment.Source, enrichment.Text))
+		}
+		if a.Anonymizer != nil {
+			for i := range texts {
+				texts[i] = a.Anonymizer.Anonymize(texts[i])
+			}
+		}
 		// If the resource `Kind` comes from a "integration plugin", maybe a customized prompt template will be involved.
 		var promptTemplate string
 		if prompt, ok := ai.PromptMap[analysis.Kind]; ok {
@@ -287,7 +319,22 @@ func (a *Analysis) GetAIResults(output string, anonymize bool) error {
 		} else {
 			promptTemplate = ai.PromptMap["default"]
 		}
-		parsedText, err := a.AIClient.Parse(a.Context, texts, a.Cache, promptTemplate)
+
+		if a.DryRun {
+			a.Prompts = append(a.Prompts, Prompt{
+				Name: analysis.Name,
+				Text: fmt.Sprintf(promptTemplate, a.Language, strings.Join(texts, " ")),
+			})
+			continue
+		}
+
+		if a.budgetExhausted() {
+			a.Usage.BudgetExhausted = true
+			a.Errors = append(a.Errors, fmt.Sprintf("[AI] token budget of %d exhausted, %d results were not explained", a.MaxTokens, len(a.Results)-index))
+			break
+		}
+
+		parsedText, usage, err := a.AIClient.Parse(a.Context, texts, a.Cache, promptTemplate)
 		if err != nil {
 			// FIXME: can we avoid checking if output is json multiple times?
 			//   maybe implement the progress bar better?
@@ -300,17 +347,40 @@ func (a *Analysis) GetAIResults(output string, anonymize bool) error {
 			}
 		}
 
-		if anonymize {
-			for _, failure := range analysis.Error {
-				for _, s := range failure.Sensitive {
-					parsedText = strings.ReplaceAll(parsedText, s.Masked, s.Unmasked)
-				}
-			}
+		a.recordUsage(usage)
+
+		if a.Anonymizer != nil {
+			parsedText = a.Anonymizer.Deanonymize(parsedText)
 		}
 
 		analysis.Details = parsedText
 
 		a.Results[index] = analysis
 	}
+	if a.Cache != nil && !a.DryRun {
+		if err := cache.Evict(a.Cache); err != nil {
+			a.Errors = append(a.Errors, fmt.Sprintf("[Cache] eviction failed: %s", err))
+		}
+	}
 	return nil
 }
+
+// newAnonymizer registers the names reported by the analyzers, the rest is detected in the text itself
+func (a *Analysis) newAnonymizer() *anonymizer.Anonymizer {
+	an := anonymizer.New()
+	for _, result := range a.Results {
+		an.AddKnown(anonymizer.CategoryNamespace, result.Namespace)
+		an.AddKnown(anonymizer.CategoryName, result.ResourceName)
+		for _, failure := range result.Error {
+			for _, s := range failure.Sensitive {
+				an.AddKnown(anonymizer.CategoryName, s.Unmasked)
+			}
+		}
+		for _, enrichment := range result.Enrichments {
+			for _, s := range enrichment.Sensitive {
+				an.AddKnown(anonymizer.CategoryName, s.Unmasked)
+			}
+		}
+	}
+	return an
+}
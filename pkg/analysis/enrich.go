@@ -0,0 +1,277 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/util"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	EnrichSourceEvents = "events"
+	EnrichSourceLogs   = "logs"
+	EnrichSourceOwner  = "owner"
+	EnrichSourceDoc    = "doc"
+)
+
+// EnrichOptions controls which context is attached to each result before calling the AI backend
+type EnrichOptions struct {
+	Events    bool
+	Logs      bool
+	Owner     bool
+	Docs      bool
+	LogLines  int64
+	MaxEvents int
+	// MaxTokens is the estimated token budget spent on enrichment for a single result
+	MaxTokens int
+}
+
+func DefaultEnrichOptions() EnrichOptions {
+	return EnrichOptions{
+		Events:    true,
+		Logs:      true,
+		Owner:     true,
+		Docs:      true,
+		LogLines:  20,
+		MaxEvents: 5,
+		MaxTokens: 1000,
+	}
+}
+
+// EnrichResults attaches events, logs, the owner spec and field docs to every result,
+// in that order of priority, until the token budget of the result is spent
+func (a *Analysis) EnrichResults(opts EnrichOptions) {
+	for index, result := range a.Results {
+		budget := opts.MaxTokens
+		var enrichments []common.Enrichment
+
+		add := func(e common.Enrichment) bool {
+			if e.Text == "" {
+				return true
+			}
+			cost := util.EstimateTokens(e.Text)
+			if cost > budget {
+				if budget <= 0 {
+					return false
+				}
+				e.Text = util.TruncateToTokens(e.Text, budget)
+				cost = budget
+			}
+			budget -= cost
+			enrichments = append(enrichments, e)
+			return budget > 0
+		}
+
+		var items []common.Enrichment
+		if opts.Events {
+			items = append(items, a.eventsEnrichment(result, opts.MaxEvents))
+		}
+		if opts.Logs && result.Kind == "Pod" {
+			items = append(items, a.logsEnrichment(result, opts.LogLines)...)
+		}
+		if opts.Owner {
+			items = append(items, a.ownerEnrichment(result))
+		}
+		if opts.Docs && a.WithDoc {
+			items = append(items, docEnrichment(result))
+		}
+		for _, item := range items {
+			if !add(item) {
+				break
+			}
+		}
+
+		result.Enrichments = enrichments
+		a.Results[index] = result
+	}
+}
+
+// eventIndex returns the index shared by the run, it is created by the first lookup
+func (a *Analysis) eventIndex() *kubernetes.EventIndex {
+	if a.Events == nil {
+		a.Events = kubernetes.NewEventIndex(a.Client, a.Namespace)
+	}
+	return a.Events
+}
+
+func (a *Analysis) eventsEnrichment(result common.Result, maxEvents int) common.Enrichment {
+	enrichment := common.Enrichment{Source: EnrichSourceEvents}
+	if result.ResourceName == "" {
+		return enrichment
+	}
+	events, err := a.eventIndex().ForObject(a.Context, result.Kind, metav1.ObjectMeta{
+		Namespace: result.Namespace,
+		Name:      result.ResourceName,
+	})
+	if err != nil {
+		a.Errors = append(a.Errors, fmt.Sprintf("[Enrich] events for %s: %s", result.Name, err))
+		return enrichment
+	}
+
+	// the index is shared, the events are sorted on a copy
+	items := append([]kubernetes.Event{}, events...)
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].LastTimestamp.After(items[j].LastTimestamp)
+	})
+	if maxEvents > 0 && len(items) > maxEvents {
+		items = items[:maxEvents]
+	}
+
+	var lines []string
+	for _, evt := range items {
+		lines = append(lines, fmt.Sprintf("%s %s: %s (x%d)", evt.Type, evt.Reason, evt.Message, max(evt.Count, 1)))
+	}
+	enrichment.Text = strings.Join(lines, "\n")
+	enrichment.Sensitive = resultSensitive(result)
+	return enrichment
+}
+
+func (a *Analysis) logsEnrichment(result common.Result, logLines int64) []common.Enrichment {
+	pod, err := a.Client.GetClient().CoreV1().Pods(result.Namespace).Get(a.Context, result.ResourceName, metav1.GetOptions{})
+	if err != nil {
+		return nil
+	}
+
+	var enrichments []common.Enrichment
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Ready && status.RestartCount == 0 {
+			continue
+		}
+		// a container waiting to restart has no current log, the previous one explains why it died
+		previous := status.RestartCount > 0 && status.State.Running == nil
+		logs, err := a.Client.GetClient().CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &v1.PodLogOptions{
+			Container: status.Name,
+			TailLines: &logLines,
+			Previous:  previous,
+		}).DoRaw(a.Context)
+		if err != nil || len(logs) == 0 {
+			continue
+		}
+		enrichments = append(enrichments, common.Enrichment{
+			Source:    EnrichSourceLogs,
+			Text:      fmt.Sprintf("container %s:\n%s", status.Name, strings.TrimSpace(string(logs))),
+			Sensitive: resultSensitive(result),
+		})
+	}
+	return enrichments
+}
+
+// ownerEnrichment renders the spec of the owning workload, without the fields that only add noise
+func (a *Analysis) ownerEnrichment(result common.Result) common.Enrichment {
+	enrichment := common.Enrichment{Source: EnrichSourceOwner}
+	kind, name, found := strings.Cut(result.ParentObject, "/")
+	if !found {
+		return enrichment
+	}
+
+	var spec interface{}
+	var err error
+	switch kind {
+	case "Deployment":
+		dep, e := a.Client.GetClient().AppsV1().Deployments(result.Namespace).Get(a.Context, name, metav1.GetOptions{})
+		err = e
+		if err == nil {
+			spec = dep.Spec
+		}
+	case "StatefulSet":
+		sts, e := a.Client.GetClient().AppsV1().StatefulSets(result.Namespace).Get(a.Context, name, metav1.GetOptions{})
+		err = e
+		if err == nil {
+			spec = sts.Spec
+		}
+	case "DaemonSet":
+		ds, e := a.Client.GetClient().AppsV1().DaemonSets(result.Namespace).Get(a.Context, name, metav1.GetOptions{})
+		err = e
+		if err == nil {
+			spec = ds.Spec
+		}
+	default:
+		return enrichment
+	}
+	if err != nil {
+		return enrichment
+	}
+
+	out, err := yaml.Marshal(spec)
+	if err != nil {
+		return enrichment
+	}
+	enrichment.Text = fmt.Sprintf("%s spec:\n%s", kind, stripNoise(string(out)))
+	enrichment.Sensitive = append(resultSensitive(result), common.Sensitive{
+		Unmasked: name,
+		Masked:   util.MaskString(name),
+	})
+	return enrichment
+}
+
+func docEnrichment(result common.Result) common.Enrichment {
+	var docs []string
+	for _, failure := range result.Error {
+		if failure.KubernetesDoc != "" && !util.SliceContainsString(docs, failure.KubernetesDoc) {
+			docs = append(docs, failure.KubernetesDoc)
+		}
+	}
+	return common.Enrichment{
+		Source: EnrichSourceDoc,
+		Text:   strings.Join(docs, "\n"),
+	}
+}
+
+// stripNoise drops empty values and defaults that do not help to explain a failure
+func stripNoise(spec string) string {
+	var lines []string
+	for _, line := range strings.Split(spec, "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "",
+			strings.HasSuffix(trimmed, ": {}"),
+			strings.HasSuffix(trimmed, ": null"),
+			strings.HasPrefix(trimmed, "creationTimestamp:"),
+			strings.HasPrefix(trimmed, "terminationMessagePath:"),
+			strings.HasPrefix(trimmed, "terminationMessagePolicy:"),
+			strings.HasPrefix(trimmed, "revisionHistoryLimit:"),
+			strings.HasPrefix(trimmed, "progressDeadlineSeconds:"),
+			strings.HasPrefix(trimmed, "dnsPolicy:"),
+			strings.HasPrefix(trimmed, "schedulerName:"),
+			strings.HasPrefix(trimmed, "terminationGracePeriodSeconds:"):
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// resultSensitive collects the sensitive values of the failures of a result, so they are
+// masked in the enrichment text as well
+func resultSensitive(result common.Result) []common.Sensitive {
+	var sensitive []common.Sensitive
+	for _, failure := range result.Error {
+		sensitive = append(sensitive, failure.Sensitive...)
+	}
+	if result.ResourceName != "" {
+		sensitive = append(sensitive, common.Sensitive{
+			Unmasked: result.ResourceName,
+			Masked:   util.MaskString(result.ResourceName),
+		})
+	}
+	return sensitive
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analysis
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func enrichTestAnalysis() *Analysis {
+	replicas := int32(1)
+	clientset := fake.NewSimpleClientset(
+		&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "example-pod",
+				Namespace: "test",
+			},
+			Status: v1.PodStatus{
+				Phase: v1.PodRunning,
+				ContainerStatuses: []v1.ContainerStatus{
+					{
+						Name:         "app",
+						RestartCount: 3,
+						State: v1.ContainerState{
+							Waiting: &v1.ContainerStateWaiting{Reason: "CrashLoopBackOff"},
+						},
+					},
+				},
+			},
+		},
+		&v1.Event{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "example-pod.1",
+				Namespace: "test",
+			},
+			InvolvedObject: v1.ObjectReference{Kind: "Pod", Name: "example-pod", Namespace: "test"},
+			Type:           "Warning",
+			Reason:         "BackOff",
+			Message:        "Back-off restarting failed container",
+			Count:          4,
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "example",
+				Namespace: "test",
+			},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: &replicas,
+			},
+		},
+	)
+
+	return &Analysis{
+		Context: context.Background(),
+		Client: &kubernetes.Client{
+			Client: clientset,
+		},
+		WithDoc: true,
+		Results: []common.Result{
+			{
+				Kind:         "Pod",
+				Name:         "test/example-pod",
+				Namespace:    "test",
+				ResourceName: "example-pod",
+				ParentObject: "Deployment/example",
+				Error: []common.Failure{
+					{
+						Text:          "back-off 5m0s restarting failed container",
+						KubernetesDoc: "Number of desired pods.",
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestEnrichResults(t *testing.T) {
+	a := enrichTestAnalysis()
+	a.EnrichResults(DefaultEnrichOptions())
+
+	enrichments := a.Results[0].Enrichments
+	sources := map[string]string{}
+	for _, e := range enrichments {
+		sources[e.Source] = e.Text
+	}
+	require.Contains(t, sources[EnrichSourceEvents], "BackOff: Back-off restarting failed container (x4)")
+	require.Contains(t, sources[EnrichSourceLogs], "container app:")
+	require.Contains(t, sources[EnrichSourceOwner], "Deployment spec:")
+	require.Contains(t, sources[EnrichSourceOwner], "replicas: 1")
+	require.Equal(t, "Number of desired pods.", sources[EnrichSourceDoc])
+}
+
+func TestEnrichResultsTokenBudget(t *testing.T) {
+	a := enrichTestAnalysis()
+	opts := DefaultEnrichOptions()
+	opts.MaxTokens = 5
+	a.EnrichResults(opts)
+
+	enrichments := a.Results[0].Enrichments
+	require.Len(t, enrichments, 1)
+	require.Equal(t, EnrichSourceEvents, enrichments[0].Source)
+	require.LessOrEqual(t, len(enrichments[0].Text), 20)
+	require.True(t, strings.HasPrefix(enrichments[0].Text, "Warning"))
+}
+
+func TestEnrichResultsSharesEvents(t *testing.T) {
+	a := enrichTestAnalysis()
+	a.Results = append(a.Results, a.Results[0], a.Results[0])
+	a.EnrichResults(DefaultEnrichOptions())
+
+	lists := 0
+	for _, action := range a.Client.GetClient().(*fake.Clientset).Actions() {
+		if action.GetVerb() == "list" && action.GetResource().Resource == "events" {
+			lists++
+		}
+	}
+	// the events.k8s.io list is empty, the core list follows, whatever the number of results
+	require.Equal(t, 2, lists)
+	for _, result := range a.Results {
+		require.Equal(t, EnrichSourceEvents, result.Enrichments[0].Source)
+	}
+}
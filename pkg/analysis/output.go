@@ -48,6 +48,7 @@ func (a *Analysis) jsonOutput() ([]byte, error) {
 		Results:  a.Results,
 		Errors:   a.Errors,
 		Status:   status,
+		Usage:    a.Usage,
 	}
 	output, err := json.MarshalIndent(result, "", "  ")
 	if err != nil {
@@ -115,5 +116,8 @@ func (a *Analysis) textOutput() ([]byte, error) {
 		}
 
 	}
+	if a.Usage != nil && !a.ShortText {
+		output.WriteString("\n" + a.Usage.String() + "\n")
+	}
 	return []byte(output.String()), nil
 }
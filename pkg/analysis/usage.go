@@ -0,0 +1,84 @@
+package analysis
+
+import (
+	"fmt"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/ai"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	AIPromptTokensMetric = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "ai_prompt_tokens_total",
+		Help: "Number of prompt tokens sent to the AI provider",
+	}, []string{"provider", "model"})
+	AICompletionTokensMetric = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "ai_completion_tokens_total",
+		Help: "Number of completion tokens returned by the AI provider",
+	}, []string{"provider", "model"})
+	AICacheRequestsMetric = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "ai_cache_requests_total",
+		Help: "Number of AI requests answered from the cache (hit) or the provider (miss)",
+	}, []string{"provider", "model", "result"})
+	AICostMetric = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "ai_estimated_cost_total",
+		Help: "Estimated cost of the AI requests according to the price table",
+	}, []string{"provider", "model"})
+)
+
+// Usage is the token consumption of all the AI calls of a run
+type Usage struct {
+	PromptTokens     int `json:"promptTokens"`
+	CompletionTokens int `json:"completionTokens"`
+	TotalTokens      int `json:"totalTokens"`
+	CacheHits        int `json:"cacheHits"`
+	CacheMisses      int `json:"cacheMisses"`
+	// Estimated is set when at least one provider did not report its usage
+	Estimated     bool    `json:"estimated"`
+	EstimatedCost float64 `json:"estimatedCost"`
+	// BudgetExhausted is set when MaxTokens was reached and the remaining results were not explained
+	BudgetExhausted bool `json:"budgetExhausted"`
+}
+
+// recordUsage adds the usage of a single call to the run and to the metrics
+func (a *Analysis) recordUsage(usage ai.Usage) {
+	if a.Usage == nil {
+		a.Usage = &Usage{}
+	}
+	provider, model := a.AIClient.GetName(), a.AIClient.GetModel()
+	if usage.CacheHit {
+		a.Usage.CacheHits++
+		AICacheRequestsMetric.WithLabelValues(provider, model, "hit").Inc()
+		return
+	}
+	a.Usage.CacheMisses++
+	AICacheRequestsMetric.WithLabelValues(provider, model, "miss").Inc()
+
+	a.Usage.PromptTokens += usage.PromptTokens
+	a.Usage.CompletionTokens += usage.CompletionTokens
+	a.Usage.TotalTokens += usage.TotalTokens()
+	a.Usage.Estimated = a.Usage.Estimated || usage.Estimated
+	AIPromptTokensMetric.WithLabelValues(provider, model).Add(float64(usage.PromptTokens))
+	AICompletionTokensMetric.WithLabelValues(provider, model).Add(float64(usage.CompletionTokens))
+
+	if price, ok := ai.GetModelPrice(a.Prices, model); ok {
+		cost := price.Cost(usage)
+		a.Usage.EstimatedCost += cost
+		AICostMetric.WithLabelValues(provider, model).Add(cost)
+	}
+}
+
+// budgetExhausted reports whether the token budget of the run is spent, a zero budget is unlimited
+func (a *Analysis) budgetExhausted() bool {
+	return a.MaxTokens > 0 && a.Usage != nil && a.Usage.TotalTokens >= a.MaxTokens
+}
+
+func (u *Usage) String() string {
+	estimated := ""
+	if u.Estimated {
+		estimated = " (estimated)"
+	}
+	return fmt.Sprintf("AI usage: %d prompt + %d completion = %d tokens%s, cache %d hits / %d misses, cost ~$%.4f",
+		u.PromptTokens, u.CompletionTokens, u.TotalTokens, estimated, u.CacheHits, u.CacheMisses, u.EstimatedCost)
+}
@@ -0,0 +1,72 @@
+package analysis
+
+import (
+	"context"
+	"testing"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/ai"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/cache"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/stretchr/testify/require"
+)
+
+func newUsageTestAnalysis(t *testing.T, maxTokens int) *Analysis {
+	aiClient := &ai.NoOpAIClient{}
+	require.NoError(t, aiClient.Configure(&ai.AIProvider{Name: "noopai", Model: "gpt-4"}, "english"))
+
+	var results []common.Result
+	for _, name := range []string{"test/a", "test/b", "test/c"} {
+		results = append(results, common.Result{
+			Kind:  "Pod",
+			Name:  name,
+			Error: []common.Failure{{Text: "Back-off pulling image \"nginx:latest\" for the container"}},
+		})
+	}
+	return &Analysis{
+		Context:   context.Background(),
+		AIClient:  aiClient,
+		Cache:     cache.NewMemoryCache(10),
+		Results:   results,
+		Language:  "english",
+		MaxTokens: maxTokens,
+	}
+}
+
+func TestGetAIResults_Usage(t *testing.T) {
+	a := newUsageTestAnalysis(t, 0)
+	require.NoError(t, a.GetAIResults("json", false))
+
+	require.NotNil(t, a.Usage)
+	require.Equal(t, 3, a.Usage.CacheMisses)
+	require.True(t, a.Usage.Estimated)
+	require.Equal(t, a.Usage.PromptTokens+a.Usage.CompletionTokens, a.Usage.TotalTokens)
+	require.Greater(t, a.Usage.EstimatedCost, 0.0)
+	require.False(t, a.Usage.BudgetExhausted)
+	for _, result := range a.Results {
+		require.NotEmpty(t, result.Details)
+	}
+}
+
+func TestGetAIResults_TokenBudget(t *testing.T) {
+	a := newUsageTestAnalysis(t, 1)
+	require.NoError(t, a.GetAIResults("json", false))
+
+	require.True(t, a.Usage.BudgetExhausted)
+	require.Equal(t, 1, a.Usage.CacheMisses)
+	require.NotEmpty(t, a.Results[0].Details)
+	require.Empty(t, a.Results[1].Details)
+	require.Len(t, a.Errors, 1)
+}
+
+func TestGetModelPrice(t *testing.T) {
+	price, ok := ai.GetModelPrice(nil, "gpt-4-0613")
+	require.True(t, ok)
+	require.Equal(t, 0.03, price.Prompt)
+
+	price, ok = ai.GetModelPrice(map[string]ai.ModelPrice{"gpt-4": {Prompt: 1}}, "gpt-4-0613")
+	require.True(t, ok)
+	require.Equal(t, 1.0, price.Prompt)
+
+	_, ok = ai.GetModelPrice(nil, "llama2")
+	require.False(t, ok)
+}
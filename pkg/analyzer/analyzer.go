@@ -16,6 +16,8 @@ package analyzer
 import (
 	"fmt"
 	"os"
+	"sync"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
@@ -47,8 +49,24 @@ var coreAnalyzerMap = map[string]common.IAnalyzer{
 var additionalAnalyzerMap = map[string]common.IAnalyzer{
 	"HorizontalPodAutoScaler": HpaAnalyzer{},
 	"PodDisruptionBudget":     PdbAnalyzer{},
-
-	"Log": LogAnalyzer{},
+	"GatewayClass":            GatewayClassAnalyzer{},
+	"Gateway":                 GatewayAnalyzer{},
+	"HTTPRoute":               HTTPRouteAnalyzer{},
+	"DaemonSet":               DaemonSetAnalyzer{},
+	"Job":                     JobAnalyzer{},
+	"References":              ReferencesAnalyzer{},
+	"Hygiene":                 HygieneAnalyzer{},
+	"Quota":                   QuotaAnalyzer{},
+	"Storage":                 StorageAnalyzer{},
+	"RBAC":                    RBACAnalyzer{},
+	"Webhook":                 WebhookAnalyzer{},
+	"Certificate":             CertificateAnalyzer{},
+	"Rollout":                 RolloutAnalyzer{},
+	"Reachability":            ReachabilityAnalyzer{},
+	"Namespace":               NamespaceAnalyzer{},
+
+	"Event": EventAnalyzer{},
+	"Log":   LogAnalyzer{},
 }
 
 func ListFilters() ([]string, []string, []string) {
@@ -116,3 +134,22 @@ func GetAnalyzerMap() (map[string]common.IAnalyzer, map[string]common.IAnalyzer)
 
 	return coreAnalyzer, mergedAnalyzerMap
 }
+
+// invalidDurations remembers the invalid durations already reported, the configs are read on every analysis
+var invalidDurations sync.Map
+
+// configDuration parses the duration set under key, e.g. analyzers.pod.inittimeout. An invalid duration is
+// reported once and read as unset, which disables the check using it rather than the whole analyzer.
+func configDuration(key string, value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		if _, reported := invalidDurations.LoadOrStore(key+"="+value, true); !reported {
+			_, _ = color.New(color.FgYellow).Fprintf(os.Stderr, "Warning: ignoring %s, %q is not a duration (e.g. 15m, 1h)\n", key, value)
+		}
+		return 0
+	}
+	return duration
+}
@@ -0,0 +1,28 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyzer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func TestConfigDuration(t *testing.T) {
+	assert.Equal(t, configDuration("analyzers.test.timeout", "15m"), 15*time.Minute)
+	assert.Equal(t, configDuration("analyzers.test.timeout", ""), time.Duration(0))
+	// a typo disables the check instead of failing the analyzer
+	assert.Equal(t, configDuration("analyzers.test.timeout", "15mins"), time.Duration(0))
+}
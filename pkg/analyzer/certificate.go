@@ -0,0 +1,228 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyzer
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/spf13/viper"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime/pkg/client"
+	gtwapi "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+// CertificateAnalyzer reports the TLS certificates served by the Ingresses and Gateways that are expired,
+// about to expire or issued for other hosts
+type CertificateAnalyzer struct{}
+
+// CertificateConfig is read from the analyzers.certificate key of the config file
+type CertificateConfig struct {
+	// ExpiryWindow is how long before its expiry a certificate is reported
+	ExpiryWindow string `mapstructure:"expirywindow"`
+
+	expiryWindow time.Duration
+}
+
+func getCertificateConfig() CertificateConfig {
+	config := CertificateConfig{
+		ExpiryWindow: "720h",
+	}
+	_ = viper.UnmarshalKey("analyzers.certificate", &config)
+	config.expiryWindow = configDuration("analyzers.certificate.expirywindow", config.ExpiryWindow)
+	return config
+}
+
+// tlsUser is an Ingress or a Gateway listener serving the certificate of a Secret
+type tlsUser struct {
+	Kind  string
+	Name  string
+	Hosts []string
+	// Parent is the Kind/name of the Ingress or Gateway
+	Parent string
+}
+
+func (CertificateAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
+
+	kind := "Certificate"
+
+	AnalyzerErrorsMetric.DeletePartialMatch(map[string]string{
+		"analyzer_name": kind,
+	})
+
+	config := getCertificateConfig()
+
+	users, err := listTLSUsers(a)
+	if err != nil {
+		return nil, err
+	}
+	secrets := make([]string, 0, len(users))
+	for key := range users {
+		secrets = append(secrets, key)
+	}
+	sort.Strings(secrets)
+
+	for _, key := range secrets {
+		namespace, name, _ := strings.Cut(key, "/")
+		secret, err := a.Client.GetClient().CoreV1().Secrets(namespace).Get(a.Context, name, metav1.GetOptions{})
+		// a missing Secret is reported by the Ingress and Gateway analyzers, and the Secrets may not be readable
+		// by k8sgpt, the certificates of the others are still checked
+		if errors.IsNotFound(err) || errors.IsForbidden(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var failures []common.Failure
+		report := func(format string, args ...interface{}) {
+			failures = append(failures, common.Failure{
+				Text:      fmt.Sprintf("Secret %s/%s ", namespace, name) + fmt.Sprintf(format, args...),
+				Sensitive: sensitiveValues(namespace, name),
+			})
+		}
+		cert, err := leafCertificate(secret.Data[v1.TLSCertKey])
+		if err != nil {
+			report("used by %s %s does not hold a valid certificate: %s.", users[key][0].Kind, users[key][0].Name, err)
+		} else {
+			now := time.Now()
+			switch {
+			case now.After(cert.NotAfter):
+				report("holds a certificate for %s which expired on %s.", cert.Subject.CommonName, cert.NotAfter.Format(time.RFC3339))
+			case config.expiryWindow > 0 && cert.NotAfter.Sub(now) < config.expiryWindow:
+				report("holds a certificate for %s which expires on %s, in %d days.",
+					cert.Subject.CommonName, cert.NotAfter.Format(time.RFC3339), int(cert.NotAfter.Sub(now).Hours()/24))
+			}
+			for _, user := range users[key] {
+				for _, host := range user.Hosts {
+					if cert.VerifyHostname(host) != nil {
+						report("holds a certificate for %s which does not cover the host %s of %s %s.",
+							strings.Join(cert.DNSNames, ", "), host, user.Kind, user.Name)
+					}
+				}
+			}
+		}
+
+		if len(failures) == 0 {
+			continue
+		}
+		AnalyzerErrorsMetric.WithLabelValues(kind, name, namespace).Set(float64(len(failures)))
+
+		a.Results = append(a.Results, common.Result{
+			Namespace:    namespace,
+			ResourceName: name,
+			Kind:         "Secret",
+			Name:         key,
+			Error:        failures,
+			ParentObject: users[key][0].Parent,
+		})
+	}
+
+	return a.Results, nil
+}
+
+// listTLSUsers returns the Ingresses and Gateway listeners serving a certificate, by namespace/name of its Secret
+func listTLSUsers(a common.Analyzer) (map[string][]tlsUser, error) {
+	users := map[string][]tlsUser{}
+
+	ingresses, err := a.Client.GetClient().NetworkingV1().Ingresses(a.Namespace).List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, ing := range ingresses.Items {
+		if SkipNamespace(ing.Namespace) {
+			continue
+		}
+		for _, tls := range ing.Spec.TLS {
+			if tls.SecretName == "" {
+				continue
+			}
+			key := ing.Namespace + "/" + tls.SecretName
+			users[key] = append(users[key], tlsUser{
+				Kind:   "Ingress",
+				Name:   ing.Namespace + "/" + ing.Name,
+				Hosts:  tls.Hosts,
+				Parent: "Ingress/" + ing.Name,
+			})
+		}
+	}
+
+	if a.Client.GetCtrlClient() == nil {
+		return users, nil
+	}
+	gateways := &gtwapi.GatewayList{}
+	if err := a.Client.GetCtrlClient().List(a.Context, gateways, ctrl.InNamespace(a.Namespace)); err != nil {
+		// the Gateway API CRDs are not installed, only the Ingresses serve certificates
+		if meta.IsNoMatchError(err) {
+			return users, nil
+		}
+		return nil, err
+	}
+	for _, gtw := range gateways.Items {
+		if SkipNamespace(gtw.Namespace) {
+			continue
+		}
+		for _, listener := range gtw.Spec.Listeners {
+			if listener.TLS == nil {
+				continue
+			}
+			var hosts []string
+			if listener.Hostname != nil {
+				hosts = append(hosts, string(*listener.Hostname))
+			}
+			for _, ref := range listener.TLS.CertificateRefs {
+				if (ref.Group != nil && *ref.Group != "") || (ref.Kind != nil && *ref.Kind != "Secret") {
+					continue
+				}
+				namespace := gtw.Namespace
+				if ref.Namespace != nil {
+					namespace = string(*ref.Namespace)
+				}
+				key := namespace + "/" + string(ref.Name)
+				users[key] = append(users[key], tlsUser{
+					Kind:   "Gateway",
+					Name:   fmt.Sprintf("%s/%s listener %s", gtw.Namespace, gtw.Name, listener.Name),
+					Hosts:  hosts,
+					Parent: "Gateway/" + gtw.Name,
+				})
+			}
+		}
+	}
+	return users, nil
+}
+
+// leafCertificate parses the first certificate of a PEM chain, the one presented to the clients
+func leafCertificate(data []byte) (*x509.Certificate, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("the %s key is empty", v1.TLSCertKey)
+	}
+	for rest := data; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return nil, fmt.Errorf("the %s key has no PEM certificate", v1.TLSCertKey)
+		}
+		if block.Type == "CERTIFICATE" {
+			return x509.ParseCertificate(block.Bytes)
+		}
+	}
+}
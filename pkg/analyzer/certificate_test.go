@@ -0,0 +1,121 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyzer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/magiconair/properties/assert"
+	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+	ctrl "sigs.k8s.io/controller-runtime/pkg/client"
+	gtwapi "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+func TestCertificateAnalyzer(t *testing.T) {
+	tlsSecret := func(name string, cert []byte) *v1.Secret {
+		return &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test"},
+			Type:       v1.SecretTypeTLS,
+			Data:       map[string][]byte{v1.TLSCertKey: cert},
+		}
+	}
+	hostname := gtwapi.Hostname("api.example.com")
+	client := gatewayTestClient(t, []ctrl.Object{
+		&gtwapi.Gateway{
+			ObjectMeta: metav1.ObjectMeta{Name: "public", Namespace: "test"},
+			Spec: gtwapi.GatewaySpec{
+				GatewayClassName: "example",
+				Listeners: []gtwapi.Listener{
+					{
+						Name:     "https",
+						Hostname: &hostname,
+						Protocol: gtwapi.HTTPSProtocolType,
+						Port:     443,
+						TLS:      &gtwapi.GatewayTLSConfig{CertificateRefs: []gtwapi.SecretObjectReference{{Name: "api-tls"}}},
+					},
+				},
+			},
+		},
+	},
+		tlsSecret("valid-tls", testCertificate(t, "www.example.com", time.Now().Add(90*24*time.Hour), "www.example.com")),
+		tlsSecret("expired-tls", testCertificate(t, "shop.example.com", time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), "shop.example.com")),
+		tlsSecret("api-tls", testCertificate(t, "*.example.org", time.Now().Add(90*24*time.Hour), "*.example.org")),
+		tlsSecret("empty-tls", nil),
+		&networkingv1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "test"},
+			Spec: networkingv1.IngressSpec{
+				TLS: []networkingv1.IngressTLS{
+					{Hosts: []string{"www.example.com"}, SecretName: "valid-tls"},
+					{Hosts: []string{"shop.example.com"}, SecretName: "expired-tls"},
+					{Hosts: []string{"blog.example.com"}, SecretName: "empty-tls"},
+					{Hosts: []string{"docs.example.com"}, SecretName: "missing-tls"},
+				},
+			},
+		},
+	)
+
+	config := common.Analyzer{
+		Client:    client,
+		Context:   context.Background(),
+		Namespace: "test",
+	}
+	analysisResults, err := CertificateAnalyzer{}.Analyze(config)
+	if err != nil {
+		t.Error(err)
+	}
+
+	texts := map[string][]string{}
+	for _, result := range analysisResults {
+		for _, failure := range result.Error {
+			texts[result.Name] = append(texts[result.Name], failure.Text)
+		}
+	}
+	assert.Equal(t, texts, map[string][]string{
+		"test/api-tls": {
+			"Secret test/api-tls holds a certificate for *.example.org which does not cover the host api.example.com of Gateway test/public listener https.",
+		},
+		"test/empty-tls": {
+			"Secret test/empty-tls used by Ingress test/web does not hold a valid certificate: the tls.crt key is empty.",
+		},
+		"test/expired-tls": {
+			"Secret test/expired-tls holds a certificate for shop.example.com which expired on 2020-01-01T00:00:00Z.",
+		},
+	})
+
+	// a Secret k8sgpt may not read is skipped, the other Secrets are still checked
+	client.Client.(*fake.Clientset).PrependReactor("get", "secrets", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		if action.(clienttesting.GetAction).GetName() != "api-tls" {
+			return false, nil, nil
+		}
+		return true, nil, errors.NewForbidden(v1.Resource("secrets"), "api-tls", nil)
+	})
+	analysisResults, err = CertificateAnalyzer{}.Analyze(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, result := range analysisResults {
+		names = append(names, result.Name)
+	}
+	assert.Equal(t, names, []string{"test/empty-tls", "test/expired-tls"})
+}
@@ -0,0 +1,255 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyzer
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/util"
+	"github.com/spf13/viper"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+type DaemonSetAnalyzer struct{}
+
+// DaemonSetConfig is read from the analyzers.daemonset key of the config file
+type DaemonSetConfig struct {
+	// StuckTimeout is how long a pod can stay unavailable during a rolling update before the rollout is reported
+	StuckTimeout string `mapstructure:"stucktimeout"`
+
+	stuckTimeout time.Duration
+}
+
+func getDaemonSetConfig() DaemonSetConfig {
+	config := DaemonSetConfig{
+		StuckTimeout: "15m",
+	}
+	_ = viper.UnmarshalKey("analyzers.daemonset", &config)
+	config.stuckTimeout = configDuration("analyzers.daemonset.stucktimeout", config.StuckTimeout)
+	return config
+}
+
+func (DaemonSetAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
+
+	kind := "DaemonSet"
+	apiDoc := kubernetes.K8sApiReference{
+		Kind: kind,
+		ApiVersion: schema.GroupVersion{
+			Group:   "apps",
+			Version: "v1",
+		},
+		OpenapiSchema: a.OpenapiSchema,
+	}
+
+	AnalyzerErrorsMetric.DeletePartialMatch(map[string]string{
+		"analyzer_name": kind,
+	})
+
+	config := getDaemonSetConfig()
+
+	list, err := a.Client.GetClient().AppsV1().DaemonSets(a.Namespace).List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var preAnalysis = map[string]common.PreAnalysis{}
+
+	for _, ds := range list.Items {
+		if SkipNamespace(ds.Namespace) {
+			continue
+		}
+		var failures []common.Failure
+		sensitive := []common.Sensitive{
+			{
+				Unmasked: ds.Namespace,
+				Masked:   util.MaskString(ds.Namespace),
+			},
+			{
+				Unmasked: ds.Name,
+				Masked:   util.MaskString(ds.Name),
+			},
+		}
+
+		status := ds.Status
+		var pods []v1.Pod
+		if status.NumberReady < status.DesiredNumberScheduled || status.NumberUnavailable > 0 {
+			selector, err := metav1.LabelSelectorAsSelector(ds.Spec.Selector)
+			if err == nil {
+				podList, err := a.Client.GetClient().CoreV1().Pods(ds.Namespace).List(a.Context, metav1.ListOptions{LabelSelector: selector.String()})
+				if err != nil {
+					return nil, err
+				}
+				pods = podList.Items
+			}
+		}
+
+		oldest := oldestUnavailable(pods)
+		// the pods replaced by a rolling update are unavailable for a while, they are only reported past the timeout
+		updating := status.ObservedGeneration >= ds.Generation && status.UpdatedNumberScheduled < status.DesiredNumberScheduled &&
+			config.stuckTimeout > 0 && oldest <= config.stuckTimeout
+
+		if status.NumberReady < status.DesiredNumberScheduled && !updating {
+			doc := apiDoc.GetApiDocV2("status.numberReady")
+
+			failures = append(failures, common.Failure{
+				Text:          fmt.Sprintf("DaemonSet %s/%s has %d ready pods out of %d desired.", ds.Namespace, ds.Name, status.NumberReady, status.DesiredNumberScheduled),
+				KubernetesDoc: doc,
+				Sensitive:     sensitive,
+			})
+		}
+
+		if status.NumberMisscheduled > 0 {
+			doc := apiDoc.GetApiDocV2("status.numberMisscheduled")
+
+			failures = append(failures, common.Failure{
+				Text:          fmt.Sprintf("DaemonSet %s/%s has %d pods running on nodes where they are not supposed to run.", ds.Namespace, ds.Name, status.NumberMisscheduled),
+				KubernetesDoc: doc,
+				Sensitive:     sensitive,
+			})
+		}
+
+		if isDaemonSetRolloutStuck(ds, oldest, config.stuckTimeout) {
+			doc := apiDoc.GetApiDocV2("spec.updateStrategy.rollingUpdate.maxUnavailable")
+
+			failures = append(failures, common.Failure{
+				Text: fmt.Sprintf("DaemonSet %s/%s rollout is stuck: %d of %d pods are updated and %d unavailable pods block the rolling update, the oldest has been unavailable for %s.",
+					ds.Namespace, ds.Name, status.UpdatedNumberScheduled, status.DesiredNumberScheduled, status.NumberUnavailable, oldest.Round(time.Second)),
+				KubernetesDoc: doc,
+				Sensitive:     sensitive,
+			})
+		}
+
+		// the daemon pods that cannot be placed stay pending with the node they are bound to in their affinity
+		if status.NumberReady < status.DesiredNumberScheduled {
+			for _, pod := range pods {
+				message, ok := unschedulableMessage(pod)
+				if !ok {
+					continue
+				}
+				doc := apiDoc.GetApiDocV2("spec.template.spec.tolerations")
+
+				failures = append(failures, common.Failure{
+					Text:          fmt.Sprintf("DaemonSet %s/%s pod cannot be placed on node %s: %s", ds.Namespace, ds.Name, daemonPodNode(pod), message),
+					KubernetesDoc: doc,
+					Sensitive:     sensitive,
+				})
+			}
+		}
+
+		if len(failures) > 0 {
+			preAnalysis[fmt.Sprintf("%s/%s", ds.Namespace, ds.Name)] = common.PreAnalysis{
+				Namespace:      ds.Namespace,
+				ResourceName:   ds.Name,
+				FailureDetails: failures,
+				DaemonSet:      ds,
+			}
+			AnalyzerErrorsMetric.WithLabelValues(kind, ds.Name, ds.Namespace).Set(float64(len(failures)))
+		}
+	}
+
+	for key, value := range preAnalysis {
+		var currentAnalysis = common.Result{
+			Namespace:    value.Namespace,
+			ResourceName: value.ResourceName,
+			Kind:         kind,
+			Name:         key,
+			Error:        value.FailureDetails,
+		}
+
+		parent, _ := util.GetParent(a.Client, value.DaemonSet.ObjectMeta)
+		currentAnalysis.ParentObject = parent
+		a.Results = append(a.Results, currentAnalysis)
+	}
+
+	return a.Results, nil
+}
+
+// isDaemonSetRolloutStuck reports a rolling update that cannot go on: the pods that are not updated yet
+// can only be replaced once the unavailable pods fall below maxUnavailable. Replacing a pod always makes it
+// unavailable for a while, so the rollout is only stuck once a pod has been unavailable for longer than timeout.
+func isDaemonSetRolloutStuck(ds appsv1.DaemonSet, oldestUnavailable time.Duration, timeout time.Duration) bool {
+	if ds.Spec.UpdateStrategy.Type != appsv1.RollingUpdateDaemonSetStrategyType || timeout <= 0 || oldestUnavailable <= timeout {
+		return false
+	}
+	status := ds.Status
+	if status.ObservedGeneration < ds.Generation || status.UpdatedNumberScheduled >= status.DesiredNumberScheduled {
+		return false
+	}
+	maxUnavailable := intstr.FromInt32(1)
+	if ds.Spec.UpdateStrategy.RollingUpdate != nil && ds.Spec.UpdateStrategy.RollingUpdate.MaxUnavailable != nil {
+		maxUnavailable = *ds.Spec.UpdateStrategy.RollingUpdate.MaxUnavailable
+	}
+	allowed, err := intstr.GetScaledValueFromIntOrPercent(&maxUnavailable, int(status.DesiredNumberScheduled), true)
+	if err != nil {
+		return false
+	}
+	return int(status.NumberUnavailable) >= allowed && allowed > 0
+}
+
+// oldestUnavailable returns how long the pod unavailable for the longest time has been unavailable
+func oldestUnavailable(pods []v1.Pod) time.Duration {
+	var oldest time.Duration
+	for _, pod := range pods {
+		if podReady(pod) || pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+			continue
+		}
+		since := notReadySince(pod)
+		if since.IsZero() {
+			continue
+		}
+		if unavailable := time.Since(since); unavailable > oldest {
+			oldest = unavailable
+		}
+	}
+	return oldest
+}
+
+// unschedulableMessage returns why the scheduler cannot place a pending pod
+func unschedulableMessage(pod v1.Pod) (string, bool) {
+	if pod.Status.Phase != v1.PodPending {
+		return "", false
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodScheduled && condition.Status == v1.ConditionFalse && condition.Reason == v1.PodReasonUnschedulable {
+			return condition.Message, true
+		}
+	}
+	return "", false
+}
+
+// daemonPodNode returns the node a daemon pod is created for, the controller pins it with a node affinity on metadata.name
+func daemonPodNode(pod v1.Pod) string {
+	if pod.Spec.NodeName != "" {
+		return pod.Spec.NodeName
+	}
+	affinity := pod.Spec.Affinity
+	if affinity == nil || affinity.NodeAffinity == nil || affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		return "unknown"
+	}
+	for _, term := range affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms {
+		for _, field := range term.MatchFields {
+			if field.Key == "metadata.name" && len(field.Values) > 0 {
+				return field.Values[0]
+			}
+		}
+	}
+	return "unknown"
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyzer
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
+	"github.com/magiconair/properties/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestDaemonSetAnalyzer(t *testing.T) {
+	selector := &metav1.LabelSelector{MatchLabels: map[string]string{"app": "agent"}}
+	clientset := fake.NewSimpleClientset(
+		&appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "healthy", Namespace: "test"},
+			Spec:       appsv1.DaemonSetSpec{Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "healthy"}}},
+			Status: appsv1.DaemonSetStatus{
+				DesiredNumberScheduled: 3,
+				NumberReady:            3,
+				UpdatedNumberScheduled: 3,
+			},
+		},
+		&appsv1.DaemonSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "agent", Namespace: "test", Generation: 2},
+			Spec: appsv1.DaemonSetSpec{
+				Selector:       selector,
+				UpdateStrategy: appsv1.DaemonSetUpdateStrategy{Type: appsv1.RollingUpdateDaemonSetStrategyType},
+			},
+			Status: appsv1.DaemonSetStatus{
+				ObservedGeneration:     2,
+				DesiredNumberScheduled: 3,
+				NumberReady:            2,
+				NumberUnavailable:      1,
+				NumberMisscheduled:     1,
+				UpdatedNumberScheduled: 1,
+			},
+		},
+		&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "agent-x1",
+				Namespace:         "test",
+				Labels:            map[string]string{"app": "agent"},
+				CreationTimestamp: metav1.NewTime(time.Now().Add(-time.Hour)),
+			},
+			Spec: v1.PodSpec{
+				Affinity: &v1.Affinity{
+					NodeAffinity: &v1.NodeAffinity{
+						RequiredDuringSchedulingIgnoredDuringExecution: &v1.NodeSelector{
+							NodeSelectorTerms: []v1.NodeSelectorTerm{
+								{MatchFields: []v1.NodeSelectorRequirement{{Key: "metadata.name", Operator: v1.NodeSelectorOpIn, Values: []string{"node3"}}}},
+							},
+						},
+					},
+				},
+			},
+			Status: v1.PodStatus{
+				Phase: v1.PodPending,
+				Conditions: []v1.PodCondition{
+					{
+						Type:    v1.PodScheduled,
+						Status:  v1.ConditionFalse,
+						Reason:  v1.PodReasonUnschedulable,
+						Message: "0/3 nodes are available: 1 Insufficient cpu.",
+					},
+				},
+			},
+		})
+
+	config := common.Analyzer{
+		Client: &kubernetes.Client{
+			Client: clientset,
+		},
+		Context:   context.Background(),
+		Namespace: "test",
+	}
+	analysisResults, err := DaemonSetAnalyzer{}.Analyze(config)
+	if err != nil {
+		t.Error(err)
+	}
+	assert.Equal(t, len(analysisResults), 1)
+	assert.Equal(t, analysisResults[0].Name, "test/agent")
+	// not ready, misscheduled, stuck rollout and unschedulable pod
+	assert.Equal(t, len(analysisResults[0].Error), 4)
+	assert.Equal(t, strings.Contains(analysisResults[0].Error[3].Text, "node3"), true)
+}
+
+func TestDaemonSetAnalyzerRollingUpdate(t *testing.T) {
+	rolling := func(name string, age time.Duration) []runtime.Object {
+		labels := map[string]string{"app": name}
+		return []runtime.Object{
+			&appsv1.DaemonSet{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test", Generation: 2},
+				Spec: appsv1.DaemonSetSpec{
+					Selector:       &metav1.LabelSelector{MatchLabels: labels},
+					UpdateStrategy: appsv1.DaemonSetUpdateStrategy{Type: appsv1.RollingUpdateDaemonSetStrategyType},
+				},
+				Status: appsv1.DaemonSetStatus{
+					ObservedGeneration:     2,
+					DesiredNumberScheduled: 3,
+					NumberReady:            2,
+					NumberUnavailable:      1,
+					UpdatedNumberScheduled: 1,
+				},
+			},
+			&v1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:              name + "-x1",
+					Namespace:         "test",
+					Labels:            labels,
+					CreationTimestamp: metav1.NewTime(time.Now().Add(-age)),
+				},
+				Status: v1.PodStatus{
+					Phase: v1.PodRunning,
+					Conditions: []v1.PodCondition{
+						{Type: v1.PodReady, Status: v1.ConditionFalse, LastTransitionTime: metav1.NewTime(time.Now().Add(-age))},
+					},
+				},
+			},
+		}
+	}
+	// a pod replaced a minute ago is the normal state of a rolling update
+	objects := append(rolling("progressing", time.Minute), rolling("stuck", time.Hour)...)
+
+	config := common.Analyzer{
+		Client: &kubernetes.Client{
+			Client: fake.NewSimpleClientset(objects...),
+		},
+		Context:   context.Background(),
+		Namespace: "test",
+	}
+	analysisResults, err := DaemonSetAnalyzer{}.Analyze(config)
+	if err != nil {
+		t.Error(err)
+	}
+	assert.Equal(t, len(analysisResults), 1)
+	assert.Equal(t, analysisResults[0].Name, "test/stuck")
+	assert.Equal(t, len(analysisResults[0].Error), 2)
+	assert.Equal(t, analysisResults[0].Error[1].Text,
+		"DaemonSet test/stuck rollout is stuck: 1 of 3 pods are updated and 1 unavailable pods block the rolling update, the oldest has been unavailable for 1h0m0s.")
+}
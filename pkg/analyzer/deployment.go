@@ -17,6 +17,8 @@ import (
 	"context"
 	"fmt"
 
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 
@@ -64,7 +66,8 @@ func (d DeploymentAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error)
 			continue
 		}
 		var failures []common.Failure
-		if *deployment.Spec.Replicas != deployment.Status.Replicas {
+		// the replicas differ during every rolling update, the stuck rollouts are reported by the Rollout analyzer
+		if *deployment.Spec.Replicas != deployment.Status.Replicas && !rolloutInProgress(deployment) {
 			doc := apiDoc.GetApiDocV2("spec.replicas")
 
 			failures = append(failures, common.Failure{
@@ -107,3 +110,13 @@ func (d DeploymentAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error)
 
 	return a.Results, nil
 }
+
+// rolloutInProgress tells whether a Deployment is rolling out a new ReplicaSet within its progress deadline
+func rolloutInProgress(deployment appsv1.Deployment) bool {
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type == appsv1.DeploymentProgressing {
+			return condition.Status == corev1.ConditionTrue && condition.Reason != "NewReplicaSetAvailable"
+		}
+	}
+	return false
+}
@@ -14,38 +14,162 @@ limitations under the License.
 package analyzer
 
 import (
-	"context"
+	"fmt"
+	"sort"
+	"time"
 
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
 	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/util"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-func FetchLatestEvent(ctx context.Context, kubernetesClient *kubernetes.Client, namespace string, name string) (*v1.Event, error) {
+// recurringEventReasons are the warnings reported by the Event analyzer when they repeat
+var recurringEventReasons = map[string]bool{
+	"FailedMount":      true,
+	"FailedScheduling": true,
+	"BackOff":          true,
+	"Evicted":          true,
+}
 
-	return nil, nil
-	// get the list of events
-	events, err := kubernetesClient.GetClient().CoreV1().Events(namespace).List(ctx,
-		metav1.ListOptions{
-			FieldSelector: "involvedObject.name=" + name,
-		})
+// recurringEventThreshold is the number of occurrences from which a warning is considered recurring,
+// an eviction is reported from the first one
+const recurringEventThreshold = 2
+
+// eventIndex returns the index shared by the run, or a new one when the analyzer is run on its own
+func eventIndex(a common.Analyzer) *kubernetes.EventIndex {
+	if a.Events != nil {
+		return a.Events
+	}
+	return kubernetes.NewEventIndex(a.Client, a.Namespace)
+}
 
+// FetchLatestEvent returns the most recent event about an object
+func FetchLatestEvent(a common.Analyzer, kind string, object metav1.ObjectMeta) (*kubernetes.Event, error) {
+	events, err := eventIndex(a).ForObject(a.Context, kind, object)
 	if err != nil {
 		return nil, err
 	}
 	// find most recent event
-	var latestEvent *v1.Event
-	for _, event := range events.Items {
-		if latestEvent == nil {
-			// this is required, as a pointer to a loop variable would always yield the latest value in the range
-			e := event
-			latestEvent = &e
-		}
-		if event.LastTimestamp.After(latestEvent.LastTimestamp.Time) {
-			// this is required, as a pointer to a loop variable would always yield the latest value in the range
-			e := event
-			latestEvent = &e
+	var latestEvent *kubernetes.Event
+	for i := range events {
+		if latestEvent == nil || events[i].LastTimestamp.After(latestEvent.LastTimestamp) {
+			latestEvent = &events[i]
 		}
 	}
 	return latestEvent, nil
 }
+
+type EventAnalyzer struct{}
+
+type eventGroup struct {
+	object  v1.ObjectReference
+	reason  string
+	message string
+	count   int32
+	first   time.Time
+	last    time.Time
+}
+
+func (EventAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
+
+	kind := "Event"
+
+	AnalyzerErrorsMetric.DeletePartialMatch(map[string]string{
+		"analyzer_name": kind,
+	})
+
+	events, err := eventIndex(a).All(a.Context)
+	if err != nil {
+		return nil, err
+	}
+
+	// group the warnings per object and reason, the message of the latest occurrence is kept
+	groups := map[string]*eventGroup{}
+	for _, event := range events {
+		if event.Type != v1.EventTypeWarning || !recurringEventReasons[event.Reason] {
+			continue
+		}
+		if SkipNamespace(event.InvolvedObject.Namespace) {
+			continue
+		}
+		key := fmt.Sprintf("%s/%s/%s/%s", event.InvolvedObject.Namespace, event.InvolvedObject.Kind, event.InvolvedObject.Name, event.Reason)
+		group, ok := groups[key]
+		if !ok {
+			group = &eventGroup{
+				object: event.InvolvedObject,
+				reason: event.Reason,
+				first:  event.FirstTimestamp,
+			}
+			groups[key] = group
+		}
+		group.count += event.Count
+		if event.FirstTimestamp.Before(group.first) {
+			group.first = event.FirstTimestamp
+		}
+		if !event.LastTimestamp.Before(group.last) {
+			group.last = event.LastTimestamp
+			group.message = event.Message
+		}
+	}
+
+	keys := make([]string, 0, len(groups))
+	for key := range groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	// the groups of an object are reported together, in a stable order
+	var results = map[string]*common.Result{}
+	var names []string
+	for _, key := range keys {
+		group := groups[key]
+		if group.count < recurringEventThreshold && group.reason != "Evicted" {
+			continue
+		}
+		name := fmt.Sprintf("%s/%s", group.object.Namespace, group.object.Name)
+		result, ok := results[name]
+		if !ok {
+			result = &common.Result{
+				Namespace:    group.object.Namespace,
+				ResourceName: group.object.Name,
+				Kind:         kind,
+				Name:         name,
+				ParentObject: fmt.Sprintf("%s/%s", group.object.Kind, group.object.Name),
+			}
+			results[name] = result
+			names = append(names, name)
+		}
+		result.Error = append(result.Error, common.Failure{
+			Text: fmt.Sprintf("%s %s had %d %s warnings over %s: %s", group.object.Kind, name, group.count, group.reason,
+				eventWindow(group.first, group.last), group.message),
+			Sensitive: []common.Sensitive{
+				{
+					Unmasked: group.object.Namespace,
+					Masked:   util.MaskString(group.object.Namespace),
+				},
+				{
+					Unmasked: group.object.Name,
+					Masked:   util.MaskString(group.object.Name),
+				},
+			},
+		})
+	}
+
+	for _, name := range names {
+		result := results[name]
+		AnalyzerErrorsMetric.WithLabelValues(kind, result.ResourceName, result.Namespace).Set(float64(len(result.Error)))
+		a.Results = append(a.Results, *result)
+	}
+
+	return a.Results, nil
+}
+
+// eventWindow describes the period in which the events happened
+func eventWindow(first time.Time, last time.Time) string {
+	if first.IsZero() || last.IsZero() {
+		return "an unknown period"
+	}
+	return last.Sub(first).Round(time.Second).String()
+}
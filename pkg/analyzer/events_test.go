@@ -0,0 +1,128 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyzer
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
+	"github.com/magiconair/properties/assert"
+	v1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestFetchLatestEvent(t *testing.T) {
+	now := time.Now()
+	clientset := fake.NewSimpleClientset(
+		&v1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "old", Namespace: "test"},
+			InvolvedObject: v1.ObjectReference{Kind: "Pod", Name: "example", Namespace: "test", UID: "current"},
+			Reason:         "Scheduled",
+			LastTimestamp:  metav1.NewTime(now.Add(-time.Hour)),
+		},
+		&v1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "new", Namespace: "test"},
+			InvolvedObject: v1.ObjectReference{Kind: "Pod", Name: "example", Namespace: "test", UID: "current"},
+			Reason:         "Unhealthy",
+			LastTimestamp:  metav1.NewTime(now.Add(-time.Minute)),
+		},
+		&v1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "deleted", Namespace: "test"},
+			InvolvedObject: v1.ObjectReference{Kind: "Pod", Name: "example", Namespace: "test", UID: "deleted"},
+			Reason:         "Killing",
+			LastTimestamp:  metav1.NewTime(now),
+		})
+	client := &kubernetes.Client{Client: clientset}
+	config := common.Analyzer{
+		Client:    client,
+		Context:   context.Background(),
+		Namespace: "test",
+		Events:    kubernetes.NewEventIndex(client, "test"),
+	}
+
+	evt, err := FetchLatestEvent(config, "Pod", metav1.ObjectMeta{Name: "example", Namespace: "test", UID: "current"})
+	if err != nil {
+		t.Error(err)
+	}
+	// the event of the pod deleted earlier under the same name is ignored
+	assert.Equal(t, evt.Reason, "Unhealthy")
+
+	evt, err = FetchLatestEvent(config, "Pod", metav1.ObjectMeta{Name: "missing", Namespace: "test"})
+	if err != nil {
+		t.Error(err)
+	}
+	assert.Equal(t, evt == nil, true)
+}
+
+func TestEventAnalyzer(t *testing.T) {
+	now := time.Now()
+	clientset := fake.NewSimpleClientset(
+		&eventsv1.Event{
+			ObjectMeta:               metav1.ObjectMeta{Name: "backoff", Namespace: "test"},
+			Regarding:                v1.ObjectReference{Kind: "Pod", Name: "web", Namespace: "test"},
+			Type:                     v1.EventTypeWarning,
+			Reason:                   "BackOff",
+			Note:                     "Back-off restarting failed container",
+			DeprecatedFirstTimestamp: metav1.NewTime(now.Add(-30 * time.Minute)),
+			Series:                   &eventsv1.EventSeries{Count: 12, LastObservedTime: metav1.NewMicroTime(now)},
+		},
+		&eventsv1.Event{
+			ObjectMeta: metav1.ObjectMeta{Name: "mount", Namespace: "test"},
+			Regarding:  v1.ObjectReference{Kind: "Pod", Name: "web", Namespace: "test"},
+			Type:       v1.EventTypeWarning,
+			Reason:     "FailedMount",
+			Note:       "MountVolume.SetUp failed for volume \"config\"",
+			EventTime:  metav1.NewMicroTime(now.Add(-10 * time.Minute)),
+		},
+		&eventsv1.Event{
+			ObjectMeta:      metav1.ObjectMeta{Name: "evicted", Namespace: "test"},
+			Regarding:       v1.ObjectReference{Kind: "Pod", Name: "batch", Namespace: "test"},
+			Type:            v1.EventTypeWarning,
+			Reason:          "Evicted",
+			Note:            "The node was low on resource: memory.",
+			DeprecatedCount: 1,
+			EventTime:       metav1.NewMicroTime(now),
+		},
+		&eventsv1.Event{
+			ObjectMeta:      metav1.ObjectMeta{Name: "normal", Namespace: "test"},
+			Regarding:       v1.ObjectReference{Kind: "Pod", Name: "web", Namespace: "test"},
+			Type:            v1.EventTypeNormal,
+			Reason:          "Pulled",
+			DeprecatedCount: 5,
+		})
+
+	config := common.Analyzer{
+		Client: &kubernetes.Client{
+			Client: clientset,
+		},
+		Context:   context.Background(),
+		Namespace: "test",
+	}
+	analysisResults, err := EventAnalyzer{}.Analyze(config)
+	if err != nil {
+		t.Error(err)
+	}
+	assert.Equal(t, len(analysisResults), 2)
+	assert.Equal(t, analysisResults[0].Name, "test/batch")
+	assert.Equal(t, analysisResults[1].Name, "test/web")
+	// the single FailedMount is not recurring
+	assert.Equal(t, len(analysisResults[1].Error), 1)
+	assert.Equal(t, strings.Contains(analysisResults[1].Error[0].Text, "12 BackOff warnings over 30m0s"), true)
+}
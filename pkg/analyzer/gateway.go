@@ -0,0 +1,187 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyzer
+
+import (
+	"fmt"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/util"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime/pkg/client"
+	gtwapi "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+type GatewayAnalyzer struct{}
+
+func (GatewayAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
+
+	kind := "Gateway"
+	apiDoc := kubernetes.K8sApiReference{
+		Kind: kind,
+		ApiVersion: schema.GroupVersion{
+			Group:   gtwapi.GroupName,
+			Version: "v1",
+		},
+		OpenapiSchema: a.OpenapiSchema,
+	}
+
+	AnalyzerErrorsMetric.DeletePartialMatch(map[string]string{
+		"analyzer_name": kind,
+	})
+
+	list := &gtwapi.GatewayList{}
+	if err := a.Client.GetCtrlClient().List(a.Context, list, ctrl.InNamespace(a.Namespace)); err != nil {
+		// the Gateway API CRDs are not installed, there is nothing to analyze
+		if meta.IsNoMatchError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var preAnalysis = map[string]common.PreAnalysis{}
+
+	for _, gtw := range list.Items {
+		if SkipNamespace(gtw.Namespace) {
+			continue
+		}
+		var failures []common.Failure
+
+		// check if the gatewayclass exists
+		gc := &gtwapi.GatewayClass{}
+		err := a.Client.GetCtrlClient().Get(a.Context, types.NamespacedName{Name: string(gtw.Spec.GatewayClassName)}, gc)
+		if err != nil {
+			doc := apiDoc.GetApiDocV2("spec.gatewayClassName")
+
+			failures = append(failures, common.Failure{
+				Text:          fmt.Sprintf("Gateway %s/%s uses the GatewayClass %s which does not exist.", gtw.Namespace, gtw.Name, gtw.Spec.GatewayClassName),
+				KubernetesDoc: doc,
+				Sensitive: []common.Sensitive{
+					{
+						Unmasked: gtw.Namespace,
+						Masked:   util.MaskString(gtw.Namespace),
+					},
+					{
+						Unmasked: gtw.Name,
+						Masked:   util.MaskString(gtw.Name),
+					},
+				},
+			})
+		}
+
+		// a listener is only serving traffic once the controller has programmed it in the data plane
+		listenerStatus := map[gtwapi.SectionName]gtwapi.ListenerStatus{}
+		for _, status := range gtw.Status.Listeners {
+			listenerStatus[status.Name] = status
+		}
+		for _, listener := range gtw.Spec.Listeners {
+			status, ok := listenerStatus[listener.Name]
+			if !ok {
+				continue
+			}
+			programmed := meta.FindStatusCondition(status.Conditions, string(gtwapi.ListenerConditionProgrammed))
+			if programmed != nil && programmed.Status != metav1.ConditionTrue {
+				doc := apiDoc.GetApiDocV2("spec.listeners")
+
+				failures = append(failures, common.Failure{
+					Text:          fmt.Sprintf("Listener %s of Gateway %s/%s is not programmed: %s - %s", listener.Name, gtw.Namespace, gtw.Name, programmed.Reason, programmed.Message),
+					KubernetesDoc: doc,
+					Sensitive: []common.Sensitive{
+						{
+							Unmasked: gtw.Namespace,
+							Masked:   util.MaskString(gtw.Namespace),
+						},
+						{
+							Unmasked: gtw.Name,
+							Masked:   util.MaskString(gtw.Name),
+						},
+					},
+				})
+			}
+		}
+
+		for _, listener := range gtw.Spec.Listeners {
+			if listener.TLS == nil {
+				continue
+			}
+			for _, ref := range listener.TLS.CertificateRefs {
+				// only Secrets are supported by every implementation
+				if ref.Kind != nil && *ref.Kind != "Secret" {
+					continue
+				}
+				namespace := gtw.Namespace
+				if ref.Namespace != nil {
+					namespace = string(*ref.Namespace)
+				}
+				_, err := a.Client.GetClient().CoreV1().Secrets(namespace).Get(a.Context, string(ref.Name), metav1.GetOptions{})
+				if err != nil {
+					doc := apiDoc.GetApiDocV2("spec.listeners.tls.certificateRefs")
+
+					failures = append(failures, common.Failure{
+						Text:          fmt.Sprintf("Listener %s of Gateway %s/%s uses the secret %s/%s as a TLS certificate which does not exist.", listener.Name, gtw.Namespace, gtw.Name, namespace, ref.Name),
+						KubernetesDoc: doc,
+						Sensitive: []common.Sensitive{
+							{
+								Unmasked: gtw.Namespace,
+								Masked:   util.MaskString(gtw.Namespace),
+							},
+							{
+								Unmasked: gtw.Name,
+								Masked:   util.MaskString(gtw.Name),
+							},
+							{
+								Unmasked: namespace,
+								Masked:   util.MaskString(namespace),
+							},
+							{
+								Unmasked: string(ref.Name),
+								Masked:   util.MaskString(string(ref.Name)),
+							},
+						},
+					})
+				}
+			}
+		}
+
+		if len(failures) > 0 {
+			preAnalysis[fmt.Sprintf("%s/%s", gtw.Namespace, gtw.Name)] = common.PreAnalysis{
+				Namespace:      gtw.Namespace,
+				ResourceName:   gtw.Name,
+				Gateway:        gtw,
+				FailureDetails: failures,
+			}
+			AnalyzerErrorsMetric.WithLabelValues(kind, gtw.Name, gtw.Namespace).Set(float64(len(failures)))
+		}
+	}
+
+	for key, value := range preAnalysis {
+		var currentAnalysis = common.Result{
+			Namespace:    value.Namespace,
+			ResourceName: value.ResourceName,
+			Kind:         kind,
+			Name:         key,
+			Error:        value.FailureDetails,
+		}
+
+		parent, _ := util.GetParent(a.Client, value.Gateway.ObjectMeta)
+		currentAnalysis.ParentObject = parent
+		a.Results = append(a.Results, currentAnalysis)
+	}
+
+	return a.Results, nil
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyzer
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/magiconair/properties/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime/pkg/client"
+	gtwapi "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+func TestGatewayAnalyzer(t *testing.T) {
+	client := gatewayTestClient(t, []ctrl.Object{
+		&gtwapi.GatewayClass{
+			ObjectMeta: metav1.ObjectMeta{Name: "example"},
+		},
+		&gtwapi.Gateway{
+			ObjectMeta: metav1.ObjectMeta{Name: "healthy", Namespace: "test"},
+			Spec: gtwapi.GatewaySpec{
+				GatewayClassName: "example",
+				Listeners: []gtwapi.Listener{
+					{
+						Name:     "https",
+						Port:     443,
+						Protocol: gtwapi.HTTPSProtocolType,
+						TLS: &gtwapi.GatewayTLSConfig{
+							CertificateRefs: []gtwapi.SecretObjectReference{{Name: "tls-cert"}},
+						},
+					},
+				},
+			},
+			Status: gtwapi.GatewayStatus{
+				Listeners: []gtwapi.ListenerStatus{
+					{
+						Name:       "https",
+						Conditions: []metav1.Condition{{Type: "Programmed", Status: metav1.ConditionTrue, Reason: "Programmed"}},
+					},
+				},
+			},
+		},
+		&gtwapi.Gateway{
+			ObjectMeta: metav1.ObjectMeta{Name: "missing-class", Namespace: "test"},
+			Spec: gtwapi.GatewaySpec{
+				GatewayClassName: "unknown",
+				Listeners:        []gtwapi.Listener{{Name: "http", Port: 80, Protocol: gtwapi.HTTPProtocolType}},
+			},
+		},
+		&gtwapi.Gateway{
+			ObjectMeta: metav1.ObjectMeta{Name: "broken-listener", Namespace: "test"},
+			Spec: gtwapi.GatewaySpec{
+				GatewayClassName: "example",
+				Listeners: []gtwapi.Listener{
+					{Name: "http", Port: 80, Protocol: gtwapi.HTTPProtocolType},
+					{
+						Name:     "https",
+						Port:     443,
+						Protocol: gtwapi.HTTPSProtocolType,
+						TLS: &gtwapi.GatewayTLSConfig{
+							CertificateRefs: []gtwapi.SecretObjectReference{{Name: "missing-cert"}},
+						},
+					},
+				},
+			},
+			Status: gtwapi.GatewayStatus{
+				Listeners: []gtwapi.ListenerStatus{
+					{
+						Name:       "http",
+						Conditions: []metav1.Condition{{Type: "Programmed", Status: metav1.ConditionFalse, Reason: "Invalid", Message: "port already in use"}},
+					},
+				},
+			},
+		},
+	}, &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "tls-cert", Namespace: "test"},
+	})
+
+	config := common.Analyzer{
+		Client:    client,
+		Context:   context.Background(),
+		Namespace: "test",
+	}
+	analysisResults, err := GatewayAnalyzer{}.Analyze(config)
+	if err != nil {
+		t.Error(err)
+	}
+	sort.Slice(analysisResults, func(i, j int) bool {
+		return analysisResults[i].Name < analysisResults[j].Name
+	})
+	assert.Equal(t, len(analysisResults), 2)
+	assert.Equal(t, analysisResults[0].Name, "test/broken-listener")
+	assert.Equal(t, len(analysisResults[0].Error), 2)
+	assert.Equal(t, analysisResults[1].Name, "test/missing-class")
+	assert.Equal(t, len(analysisResults[1].Error), 1)
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyzer
+
+import (
+	"fmt"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/util"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	gtwapi "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+type GatewayClassAnalyzer struct{}
+
+func (GatewayClassAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
+
+	kind := "GatewayClass"
+	apiDoc := kubernetes.K8sApiReference{
+		Kind: kind,
+		ApiVersion: schema.GroupVersion{
+			Group:   gtwapi.GroupName,
+			Version: "v1",
+		},
+		OpenapiSchema: a.OpenapiSchema,
+	}
+
+	AnalyzerErrorsMetric.DeletePartialMatch(map[string]string{
+		"analyzer_name": kind,
+	})
+
+	list := &gtwapi.GatewayClassList{}
+	if err := a.Client.GetCtrlClient().List(a.Context, list); err != nil {
+		// the Gateway API CRDs are not installed, there is nothing to analyze
+		if meta.IsNoMatchError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var preAnalysis = map[string]common.PreAnalysis{}
+
+	for _, gc := range list.Items {
+		var failures []common.Failure
+
+		accepted := meta.FindStatusCondition(gc.Status.Conditions, string(gtwapi.GatewayClassConditionStatusAccepted))
+		if accepted == nil || accepted.Status == metav1.ConditionUnknown && accepted.Reason == string(gtwapi.GatewayClassReasonPending) {
+			doc := apiDoc.GetApiDocV2("spec.controllerName")
+
+			failures = append(failures, common.Failure{
+				Text:          fmt.Sprintf("GatewayClass %s has not been accepted yet, check that the controller %s is running.", gc.Name, gc.Spec.ControllerName),
+				KubernetesDoc: doc,
+				Sensitive: []common.Sensitive{
+					{
+						Unmasked: gc.Name,
+						Masked:   util.MaskString(gc.Name),
+					},
+				},
+			})
+		} else if accepted.Status != metav1.ConditionTrue {
+			doc := apiDoc.GetApiDocV2("spec.controllerName")
+
+			failures = append(failures, common.Failure{
+				Text:          fmt.Sprintf("GatewayClass %s is not accepted by the controller %s: %s - %s", gc.Name, gc.Spec.ControllerName, accepted.Reason, accepted.Message),
+				KubernetesDoc: doc,
+				Sensitive: []common.Sensitive{
+					{
+						Unmasked: gc.Name,
+						Masked:   util.MaskString(gc.Name),
+					},
+				},
+			})
+		}
+
+		if len(failures) > 0 {
+			preAnalysis[gc.Name] = common.PreAnalysis{
+				ResourceName:   gc.Name,
+				GatewayClass:   gc,
+				FailureDetails: failures,
+			}
+			AnalyzerErrorsMetric.WithLabelValues(kind, gc.Name, "").Set(float64(len(failures)))
+		}
+	}
+
+	for key, value := range preAnalysis {
+		var currentAnalysis = common.Result{
+			ResourceName: value.ResourceName,
+			Kind:         kind,
+			Name:         key,
+			Error:        value.FailureDetails,
+		}
+
+		parent, _ := util.GetParent(a.Client, value.GatewayClass.ObjectMeta)
+		currentAnalysis.ParentObject = parent
+		a.Results = append(a.Results, currentAnalysis)
+	}
+
+	return a.Results, nil
+}
@@ -0,0 +1,81 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyzer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
+	"github.com/magiconair/properties/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	ctrl "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrl "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	gtwapi "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+// gatewayTestClient builds a client whose Gateway API objects are served by a fake controller-runtime client
+func gatewayTestClient(t *testing.T, objects []ctrl.Object, coreObjects ...runtime.Object) *kubernetes.Client {
+	scheme, err := kubernetes.NewScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &kubernetes.Client{
+		Client:     fake.NewSimpleClientset(coreObjects...),
+		CtrlClient: fakectrl.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build(),
+	}
+}
+
+func TestGatewayClassAnalyzer(t *testing.T) {
+	client := gatewayTestClient(t, []ctrl.Object{
+		&gtwapi.GatewayClass{
+			ObjectMeta: metav1.ObjectMeta{Name: "accepted"},
+			Spec:       gtwapi.GatewayClassSpec{ControllerName: "example.com/gateway-controller"},
+			Status: gtwapi.GatewayClassStatus{
+				Conditions: []metav1.Condition{
+					{Type: "Accepted", Status: metav1.ConditionTrue, Reason: "Accepted"},
+				},
+			},
+		},
+		&gtwapi.GatewayClass{
+			ObjectMeta: metav1.ObjectMeta{Name: "rejected"},
+			Spec:       gtwapi.GatewayClassSpec{ControllerName: "example.com/gateway-controller"},
+			Status: gtwapi.GatewayClassStatus{
+				Conditions: []metav1.Condition{
+					{Type: "Accepted", Status: metav1.ConditionFalse, Reason: "InvalidParameters", Message: "parameters not found"},
+				},
+			},
+		},
+		&gtwapi.GatewayClass{
+			ObjectMeta: metav1.ObjectMeta{Name: "pending"},
+			Spec:       gtwapi.GatewayClassSpec{ControllerName: "example.com/missing-controller"},
+		},
+	})
+
+	config := common.Analyzer{
+		Client:  client,
+		Context: context.Background(),
+	}
+	analysisResults, err := GatewayClassAnalyzer{}.Analyze(config)
+	if err != nil {
+		t.Error(err)
+	}
+	assert.Equal(t, len(analysisResults), 2)
+	for _, result := range analysisResults {
+		assert.Equal(t, result.Name != "accepted", true)
+	}
+}
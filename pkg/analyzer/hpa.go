@@ -14,12 +14,18 @@ limitations under the License.
 package analyzer
 
 import (
+	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
 	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
 	"github.com/k8sgpt-ai/k8sgpt/pkg/util"
+	"github.com/spf13/viper"
 	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -27,6 +33,36 @@ import (
 
 type HpaAnalyzer struct{}
 
+// HpaConfig is read from the analyzers.hpa key of the config file
+type HpaConfig struct {
+	// MaxReplicasDuration is how long an HPA can be pinned at its maxReplicas before it is reported
+	MaxReplicasDuration string `mapstructure:"maxreplicasduration"`
+	// GitOpsManagers are the prefixes of the field managers of the GitOps tools, they must not own the
+	// replicas of a workload scaled by an HPA
+	GitOpsManagers []string `mapstructure:"gitopsmanagers"`
+
+	maxReplicasDuration time.Duration
+}
+
+func getHpaConfig() HpaConfig {
+	config := HpaConfig{
+		MaxReplicasDuration: "1h",
+		GitOpsManagers:      []string{"argocd", "kustomize-controller", "helm-controller", "flux"},
+	}
+	_ = viper.UnmarshalKey("analyzers.hpa", &config)
+	config.maxReplicasDuration = configDuration("analyzers.hpa.maxreplicasduration", config.MaxReplicasDuration)
+	return config
+}
+
+// metricsAPIs are the API groups serving the metrics of every metric type, with what provides them
+var metricsAPIs = map[autoscalingv2.MetricSourceType][2]string{
+	autoscalingv2.ResourceMetricSourceType:          {"metrics.k8s.io", "metrics-server"},
+	autoscalingv2.ContainerResourceMetricSourceType: {"metrics.k8s.io", "metrics-server"},
+	autoscalingv2.PodsMetricSourceType:              {"custom.metrics.k8s.io", "a custom metrics adapter such as prometheus-adapter"},
+	autoscalingv2.ObjectMetricSourceType:            {"custom.metrics.k8s.io", "a custom metrics adapter such as prometheus-adapter"},
+	autoscalingv2.ExternalMetricSourceType:          {"external.metrics.k8s.io", "an external metrics adapter such as KEDA"},
+}
+
 func (HpaAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
 
 	kind := "HorizontalPodAutoscaler"
@@ -34,7 +70,7 @@ func (HpaAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
 		Kind: kind,
 		ApiVersion: schema.GroupVersion{
 			Group:   "autoscaling",
-			Version: "v1",
+			Version: "v2",
 		},
 		OpenapiSchema: a.OpenapiSchema,
 	}
@@ -43,15 +79,40 @@ func (HpaAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
 		"analyzer_name": kind,
 	})
 
-	list, err := a.Client.GetClient().AutoscalingV1().HorizontalPodAutoscalers(a.Namespace).List(a.Context, metav1.ListOptions{})
+	config := getHpaConfig()
+
+	list, err := a.Client.GetClient().AutoscalingV2().HorizontalPodAutoscalers(a.Namespace).List(a.Context, metav1.ListOptions{})
 	if err != nil {
 		return nil, err
 	}
 
+	// the metrics APIs are only checked when the discovery succeeds, an API server always serves some groups
+	var servedGroups map[string]bool
+	if groups, err := a.Client.GetClient().Discovery().ServerGroups(); err == nil && len(groups.Groups) > 0 {
+		servedGroups = map[string]bool{}
+		for _, group := range groups.Groups {
+			servedGroups[group.Name] = true
+		}
+	}
+
+	// the HPAs sharing a scale target compete over its replicas
+	targets := map[string][]string{}
+	for _, hpa := range list.Items {
+		key := fmt.Sprintf("%s/%s/%s", hpa.Namespace, hpa.Spec.ScaleTargetRef.Kind, hpa.Spec.ScaleTargetRef.Name)
+		targets[key] = append(targets[key], hpa.Name)
+	}
+
 	var preAnalysis = map[string]common.PreAnalysis{}
 
 	for _, hpa := range list.Items {
 		var failures []common.Failure
+		report := func(path string, format string, args ...interface{}) {
+			failures = append(failures, common.Failure{
+				Text:          fmt.Sprintf("HorizontalPodAutoscaler %s ", hpa.Name) + fmt.Sprintf(format, args...),
+				KubernetesDoc: apiDoc.GetApiDocV2(path),
+				Sensitive:     sensitiveValues(hpa.Name),
+			})
+		}
 
 		// check ScaleTargetRef exist
 		scaleTargetRef := hpa.Spec.ScaleTargetRef
@@ -121,6 +182,51 @@ func (HpaAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
 				})
 			}
 
+			if owners := replicaOwners(podInfo.GetManagedFields(), config.GitOpsManagers); len(owners) > 0 {
+				report("spec.scaleTargetRef", "scales %s %s whose spec.replicas is also managed by %s, every sync resets the replicas set by the autoscaler.",
+					scaleTargetRef.Kind, scaleTargetRef.Name, strings.Join(owners, ", "))
+			}
+		}
+
+		competing := targets[fmt.Sprintf("%s/%s/%s", hpa.Namespace, scaleTargetRef.Kind, scaleTargetRef.Name)]
+		if len(competing) > 1 {
+			var others []string
+			for _, name := range competing {
+				if name != hpa.Name {
+					others = append(others, name)
+				}
+			}
+			report("spec.scaleTargetRef", "scales %s %s like the HorizontalPodAutoscalers %s, they overwrite each other's replicas.",
+				scaleTargetRef.Kind, scaleTargetRef.Name, strings.Join(others, ", "))
+		}
+
+		if servedGroups != nil {
+			for _, metricType := range hpaMetricTypes(hpa) {
+				api := metricsAPIs[metricType]
+				if api[0] != "" && !servedGroups[api[0]] {
+					report("spec.metrics", "uses %s metrics but the %s API is not served, %s must be installed.", metricType, api[0], api[1])
+				}
+			}
+		}
+
+		for _, condition := range hpa.Status.Conditions {
+			switch {
+			case condition.Type == autoscalingv2.AbleToScale && condition.Status == corev1.ConditionFalse:
+				report("status.conditions", "is not able to scale (%s): %s", condition.Reason, condition.Message)
+			// the scaling is disabled on purpose when the target is scaled to zero
+			case condition.Type == autoscalingv2.ScalingActive && condition.Status == corev1.ConditionFalse && condition.Reason != "ScalingDisabled":
+				if strings.HasPrefix(condition.Reason, "FailedGet") {
+					report("status.conditions", "cannot fetch its metrics (%s): %s", condition.Reason, condition.Message)
+				} else {
+					report("status.conditions", "is not active (%s): %s", condition.Reason, condition.Message)
+				}
+			case condition.Type == autoscalingv2.ScalingLimited && condition.Status == corev1.ConditionTrue && condition.Reason == "TooManyReplicas":
+				pinned := time.Since(condition.LastTransitionTime.Time)
+				if config.maxReplicasDuration > 0 && pinned > config.maxReplicasDuration && hpa.Status.CurrentReplicas >= hpa.Spec.MaxReplicas {
+					report("spec.maxReplicas", "has been pinned at its maxReplicas of %d for %s, the load needs more replicas than it allows.",
+						hpa.Spec.MaxReplicas, pinned.Round(time.Minute))
+				}
+			}
 		}
 
 		if len(failures) > 0 {
@@ -154,6 +260,7 @@ func (HpaAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
 
 type PodInfo interface {
 	GetPodSpec() corev1.PodSpec
+	GetManagedFields() []metav1.ManagedFieldsEntry
 }
 
 type DeploymentInfo struct {
@@ -191,3 +298,48 @@ type StatefulSetInfo struct {
 func (ss StatefulSetInfo) GetPodSpec() corev1.PodSpec {
 	return ss.Spec.Template.Spec
 }
+
+// hpaMetricTypes returns the types of the metrics of an HPA, without metrics it scales on the CPU usage
+func hpaMetricTypes(hpa autoscalingv2.HorizontalPodAutoscaler) []autoscalingv2.MetricSourceType {
+	if len(hpa.Spec.Metrics) == 0 {
+		return []autoscalingv2.MetricSourceType{autoscalingv2.ResourceMetricSourceType}
+	}
+	seen := map[autoscalingv2.MetricSourceType]bool{}
+	var types []autoscalingv2.MetricSourceType
+	for _, metric := range hpa.Spec.Metrics {
+		if !seen[metric.Type] {
+			seen[metric.Type] = true
+			types = append(types, metric.Type)
+		}
+	}
+	return types
+}
+
+// replicaOwners returns the GitOps field managers owning spec.replicas, matched by the prefix of their names
+func replicaOwners(entries []metav1.ManagedFieldsEntry, managers []string) []string {
+	var owners []string
+	for _, entry := range entries {
+		// the autoscaler writes through the scale subresource
+		if entry.FieldsV1 == nil || entry.Subresource != "" {
+			continue
+		}
+		gitops := false
+		for _, prefix := range managers {
+			if strings.HasPrefix(entry.Manager, prefix) {
+				gitops = true
+			}
+		}
+		if !gitops {
+			continue
+		}
+		var fields map[string]map[string]interface{}
+		if err := json.Unmarshal(entry.FieldsV1.Raw, &fields); err != nil {
+			continue
+		}
+		if _, ok := fields["f:spec"]["f:replicas"]; ok && !util.SliceContainsString(owners, entry.Manager) {
+			owners = append(owners, entry.Manager)
+		}
+	}
+	sort.Strings(owners)
+	return owners
+}
@@ -17,21 +17,23 @@ import (
 	"context"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
 	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
 	"github.com/magiconair/properties/assert"
 	appsv1 "k8s.io/api/apps/v1"
-	autoscalingv1 "k8s.io/api/autoscaling/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fakediscovery "k8s.io/client-go/discovery/fake"
 	"k8s.io/client-go/kubernetes/fake"
 )
 
 func TestHPAAnalyzer(t *testing.T) {
 	clientset := fake.NewSimpleClientset(
-		&autoscalingv1.HorizontalPodAutoscaler{
+		&autoscalingv2.HorizontalPodAutoscaler{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:        "example",
 				Namespace:   "default",
@@ -55,14 +57,14 @@ func TestHPAAnalyzer(t *testing.T) {
 
 func TestHPAAnalyzerWithMultipleHPA(t *testing.T) {
 	clientset := fake.NewSimpleClientset(
-		&autoscalingv1.HorizontalPodAutoscaler{
+		&autoscalingv2.HorizontalPodAutoscaler{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:        "example",
 				Namespace:   "default",
 				Annotations: map[string]string{},
 			},
 		},
-		&autoscalingv1.HorizontalPodAutoscaler{
+		&autoscalingv2.HorizontalPodAutoscaler{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:        "example-2",
 				Namespace:   "default",
@@ -88,14 +90,14 @@ func TestHPAAnalyzerWithMultipleHPA(t *testing.T) {
 func TestHPAAnalyzerWithUnsuportedScaleTargetRef(t *testing.T) {
 
 	clientset := fake.NewSimpleClientset(
-		&autoscalingv1.HorizontalPodAutoscaler{
+		&autoscalingv2.HorizontalPodAutoscaler{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:        "example",
 				Namespace:   "default",
 				Annotations: map[string]string{},
 			},
-			Spec: autoscalingv1.HorizontalPodAutoscalerSpec{
-				ScaleTargetRef: autoscalingv1.CrossVersionObjectReference{
+			Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+				ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
 					Kind: "unsupported",
 				},
 			},
@@ -134,14 +136,14 @@ func TestHPAAnalyzerWithUnsuportedScaleTargetRef(t *testing.T) {
 func TestHPAAnalyzerWithNonExistentScaleTargetRef(t *testing.T) {
 
 	clientset := fake.NewSimpleClientset(
-		&autoscalingv1.HorizontalPodAutoscaler{
+		&autoscalingv2.HorizontalPodAutoscaler{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:        "example",
 				Namespace:   "default",
 				Annotations: map[string]string{},
 			},
-			Spec: autoscalingv1.HorizontalPodAutoscalerSpec{
-				ScaleTargetRef: autoscalingv1.CrossVersionObjectReference{
+			Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+				ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
 					Kind: "Deployment",
 					Name: "non-existent",
 				},
@@ -181,14 +183,14 @@ func TestHPAAnalyzerWithNonExistentScaleTargetRef(t *testing.T) {
 func TestHPAAnalyzerWithExistingScaleTargetRefAsDeployment(t *testing.T) {
 
 	clientset := fake.NewSimpleClientset(
-		&autoscalingv1.HorizontalPodAutoscaler{
+		&autoscalingv2.HorizontalPodAutoscaler{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:        "example",
 				Namespace:   "default",
 				Annotations: map[string]string{},
 			},
-			Spec: autoscalingv1.HorizontalPodAutoscalerSpec{
-				ScaleTargetRef: autoscalingv1.CrossVersionObjectReference{
+			Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+				ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
 					Kind: "Deployment",
 					Name: "example",
 				},
@@ -245,14 +247,14 @@ func TestHPAAnalyzerWithExistingScaleTargetRefAsDeployment(t *testing.T) {
 func TestHPAAnalyzerWithExistingScaleTargetRefAsReplicationController(t *testing.T) {
 
 	clientset := fake.NewSimpleClientset(
-		&autoscalingv1.HorizontalPodAutoscaler{
+		&autoscalingv2.HorizontalPodAutoscaler{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:        "example",
 				Namespace:   "default",
 				Annotations: map[string]string{},
 			},
-			Spec: autoscalingv1.HorizontalPodAutoscalerSpec{
-				ScaleTargetRef: autoscalingv1.CrossVersionObjectReference{
+			Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+				ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
 					Kind: "ReplicationController",
 					Name: "example",
 				},
@@ -309,14 +311,14 @@ func TestHPAAnalyzerWithExistingScaleTargetRefAsReplicationController(t *testing
 func TestHPAAnalyzerWithExistingScaleTargetRefAsReplicaSet(t *testing.T) {
 
 	clientset := fake.NewSimpleClientset(
-		&autoscalingv1.HorizontalPodAutoscaler{
+		&autoscalingv2.HorizontalPodAutoscaler{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:        "example",
 				Namespace:   "default",
 				Annotations: map[string]string{},
 			},
-			Spec: autoscalingv1.HorizontalPodAutoscalerSpec{
-				ScaleTargetRef: autoscalingv1.CrossVersionObjectReference{
+			Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+				ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
 					Kind: "ReplicaSet",
 					Name: "example",
 				},
@@ -373,14 +375,14 @@ func TestHPAAnalyzerWithExistingScaleTargetRefAsReplicaSet(t *testing.T) {
 func TestHPAAnalyzerWithExistingScaleTargetRefAsStatefulSet(t *testing.T) {
 
 	clientset := fake.NewSimpleClientset(
-		&autoscalingv1.HorizontalPodAutoscaler{
+		&autoscalingv2.HorizontalPodAutoscaler{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:        "example",
 				Namespace:   "default",
 				Annotations: map[string]string{},
 			},
-			Spec: autoscalingv1.HorizontalPodAutoscalerSpec{
-				ScaleTargetRef: autoscalingv1.CrossVersionObjectReference{
+			Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+				ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
 					Kind: "StatefulSet",
 					Name: "example",
 				},
@@ -437,14 +439,14 @@ func TestHPAAnalyzerWithExistingScaleTargetRefAsStatefulSet(t *testing.T) {
 func TestHPAAnalyzerWithExistingScaleTargetRefWithoutSpecifyingResources(t *testing.T) {
 
 	clientset := fake.NewSimpleClientset(
-		&autoscalingv1.HorizontalPodAutoscaler{
+		&autoscalingv2.HorizontalPodAutoscaler{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:        "example",
 				Namespace:   "default",
 				Annotations: map[string]string{},
 			},
-			Spec: autoscalingv1.HorizontalPodAutoscalerSpec{
-				ScaleTargetRef: autoscalingv1.CrossVersionObjectReference{
+			Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+				ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
 					Kind: "Deployment",
 					Name: "example",
 				},
@@ -503,14 +505,14 @@ func TestHPAAnalyzerWithExistingScaleTargetRefWithoutSpecifyingResources(t *test
 
 func TestHPAAnalyzerNamespaceFiltering(t *testing.T) {
 	clientset := fake.NewSimpleClientset(
-		&autoscalingv1.HorizontalPodAutoscaler{
+		&autoscalingv2.HorizontalPodAutoscaler{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:        "example",
 				Namespace:   "default",
 				Annotations: map[string]string{},
 			},
 		},
-		&autoscalingv1.HorizontalPodAutoscaler{
+		&autoscalingv2.HorizontalPodAutoscaler{
 			ObjectMeta: metav1.ObjectMeta{
 				Name:        "example",
 				Namespace:   "other-namespace",
@@ -531,3 +533,113 @@ func TestHPAAnalyzerNamespaceFiltering(t *testing.T) {
 	}
 	assert.Equal(t, len(analysisResults), 1)
 }
+
+func TestHPAAnalyzerWithAutoscalingV2(t *testing.T) {
+	target := autoscalingv2.CrossVersionObjectReference{Kind: "Deployment", Name: "web", APIVersion: "apps/v1"}
+	clientset := fake.NewSimpleClientset(
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "web",
+				Namespace: "test",
+				// Argo CD keeps the replicas of the manifest
+				ManagedFields: []metav1.ManagedFieldsEntry{
+					{
+						Manager:  "argocd-controller",
+						FieldsV1: &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:replicas":{},"f:template":{}}}`)},
+					},
+					{
+						Manager:     "kube-controller-manager",
+						Subresource: "scale",
+						FieldsV1:    &metav1.FieldsV1{Raw: []byte(`{"f:spec":{"f:replicas":{}}}`)},
+					},
+				},
+			},
+			Spec: appsv1.DeploymentSpec{
+				Template: corev1.PodTemplateSpec{
+					Spec: corev1.PodSpec{
+						Containers: []corev1.Container{
+							{
+								Name: "web",
+								Resources: corev1.ResourceRequirements{
+									Requests: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("100m")},
+									Limits:   corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("500m")},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+		&autoscalingv2.HorizontalPodAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "test"},
+			Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+				ScaleTargetRef: target,
+				MaxReplicas:    5,
+				Metrics: []autoscalingv2.MetricSpec{
+					{Type: autoscalingv2.ExternalMetricSourceType},
+				},
+			},
+			Status: autoscalingv2.HorizontalPodAutoscalerStatus{
+				CurrentReplicas: 5,
+				Conditions: []autoscalingv2.HorizontalPodAutoscalerCondition{
+					{
+						Type:    autoscalingv2.ScalingActive,
+						Status:  corev1.ConditionFalse,
+						Reason:  "FailedGetExternalMetric",
+						Message: "unable to get external metric test/queue_length",
+					},
+					{
+						Type:               autoscalingv2.ScalingLimited,
+						Status:             corev1.ConditionTrue,
+						Reason:             "TooManyReplicas",
+						LastTransitionTime: metav1.NewTime(time.Now().Add(-2 * time.Hour)),
+					},
+				},
+			},
+		},
+		&autoscalingv2.HorizontalPodAutoscaler{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-memory", Namespace: "test"},
+			Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+				ScaleTargetRef: target,
+				MaxReplicas:    10,
+			},
+		},
+	)
+	// metrics-server is installed but no external metrics adapter
+	clientset.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{
+		{GroupVersion: "apps/v1"},
+		{GroupVersion: "metrics.k8s.io/v1beta1"},
+	}
+
+	config := common.Analyzer{
+		Client: &kubernetes.Client{
+			Client: clientset,
+		},
+		Context:   context.Background(),
+		Namespace: "test",
+	}
+	analysisResults, err := HpaAnalyzer{}.Analyze(config)
+	if err != nil {
+		t.Error(err)
+	}
+
+	texts := map[string][]string{}
+	for _, result := range analysisResults {
+		for _, failure := range result.Error {
+			texts[result.Name] = append(texts[result.Name], failure.Text)
+		}
+	}
+	assert.Equal(t, texts, map[string][]string{
+		"test/web": {
+			"HorizontalPodAutoscaler web scales Deployment web whose spec.replicas is also managed by argocd-controller, every sync resets the replicas set by the autoscaler.",
+			"HorizontalPodAutoscaler web scales Deployment web like the HorizontalPodAutoscalers web-memory, they overwrite each other's replicas.",
+			"HorizontalPodAutoscaler web uses External metrics but the external.metrics.k8s.io API is not served, an external metrics adapter such as KEDA must be installed.",
+			"HorizontalPodAutoscaler web cannot fetch its metrics (FailedGetExternalMetric): unable to get external metric test/queue_length",
+			"HorizontalPodAutoscaler web has been pinned at its maxReplicas of 5 for 2h0m0s, the load needs more replicas than it allows.",
+		},
+		"test/web-memory": {
+			"HorizontalPodAutoscaler web-memory scales Deployment web whose spec.replicas is also managed by argocd-controller, every sync resets the replicas set by the autoscaler.",
+			"HorizontalPodAutoscaler web-memory scales Deployment web like the HorizontalPodAutoscalers web, they overwrite each other's replicas.",
+		},
+	})
+}
@@ -0,0 +1,214 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyzer
+
+import (
+	"fmt"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/util"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime/pkg/client"
+	gtwapi "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+type HTTPRouteAnalyzer struct{}
+
+func (HTTPRouteAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
+
+	kind := "HTTPRoute"
+	apiDoc := kubernetes.K8sApiReference{
+		Kind: kind,
+		ApiVersion: schema.GroupVersion{
+			Group:   gtwapi.GroupName,
+			Version: "v1",
+		},
+		OpenapiSchema: a.OpenapiSchema,
+	}
+
+	AnalyzerErrorsMetric.DeletePartialMatch(map[string]string{
+		"analyzer_name": kind,
+	})
+
+	list := &gtwapi.HTTPRouteList{}
+	if err := a.Client.GetCtrlClient().List(a.Context, list, ctrl.InNamespace(a.Namespace)); err != nil {
+		// the Gateway API CRDs are not installed, there is nothing to analyze
+		if meta.IsNoMatchError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var preAnalysis = map[string]common.PreAnalysis{}
+
+	for _, route := range list.Items {
+		if SkipNamespace(route.Namespace) {
+			continue
+		}
+		var failures []common.Failure
+
+		for _, parentRef := range route.Spec.ParentRefs {
+			// routes can also be attached to Services for mesh support, only Gateways are checked
+			if parentRef.Kind != nil && *parentRef.Kind != "Gateway" {
+				continue
+			}
+			namespace := route.Namespace
+			if parentRef.Namespace != nil {
+				namespace = string(*parentRef.Namespace)
+			}
+
+			gtw := &gtwapi.Gateway{}
+			err := a.Client.GetCtrlClient().Get(a.Context, types.NamespacedName{Namespace: namespace, Name: string(parentRef.Name)}, gtw)
+			if err != nil {
+				doc := apiDoc.GetApiDocV2("spec.parentRefs")
+
+				failures = append(failures, common.Failure{
+					Text:          fmt.Sprintf("HTTPRoute uses the Gateway %s/%s which does not exist.", namespace, parentRef.Name),
+					KubernetesDoc: doc,
+					Sensitive: []common.Sensitive{
+						{
+							Unmasked: namespace,
+							Masked:   util.MaskString(namespace),
+						},
+						{
+							Unmasked: string(parentRef.Name),
+							Masked:   util.MaskString(string(parentRef.Name)),
+						},
+					},
+				})
+				continue
+			}
+
+			if parentRef.SectionName != nil && !hasListener(gtw, *parentRef.SectionName) {
+				doc := apiDoc.GetApiDocV2("spec.parentRefs.sectionName")
+
+				failures = append(failures, common.Failure{
+					Text:          fmt.Sprintf("HTTPRoute uses the listener %s of Gateway %s/%s which does not exist.", *parentRef.SectionName, namespace, parentRef.Name),
+					KubernetesDoc: doc,
+					Sensitive: []common.Sensitive{
+						{
+							Unmasked: namespace,
+							Masked:   util.MaskString(namespace),
+						},
+						{
+							Unmasked: string(parentRef.Name),
+							Masked:   util.MaskString(string(parentRef.Name)),
+						},
+					},
+				})
+			}
+		}
+
+		for _, rule := range route.Spec.Rules {
+			for _, backendRef := range rule.BackendRefs {
+				if backendRef.Kind != nil && *backendRef.Kind != "Service" {
+					continue
+				}
+				namespace := route.Namespace
+				if backendRef.Namespace != nil {
+					namespace = string(*backendRef.Namespace)
+				}
+
+				svc, err := a.Client.GetClient().CoreV1().Services(namespace).Get(a.Context, string(backendRef.Name), metav1.GetOptions{})
+				if err != nil {
+					doc := apiDoc.GetApiDocV2("spec.rules.backendRefs")
+
+					failures = append(failures, common.Failure{
+						Text:          fmt.Sprintf("HTTPRoute uses the service %s/%s which does not exist.", namespace, backendRef.Name),
+						KubernetesDoc: doc,
+						Sensitive: []common.Sensitive{
+							{
+								Unmasked: namespace,
+								Masked:   util.MaskString(namespace),
+							},
+							{
+								Unmasked: string(backendRef.Name),
+								Masked:   util.MaskString(string(backendRef.Name)),
+							},
+						},
+					})
+					continue
+				}
+
+				if backendRef.Port == nil {
+					continue
+				}
+				found := false
+				for _, port := range svc.Spec.Ports {
+					if port.Port == int32(*backendRef.Port) {
+						found = true
+						break
+					}
+				}
+				if !found {
+					doc := apiDoc.GetApiDocV2("spec.rules.backendRefs.port")
+
+					failures = append(failures, common.Failure{
+						Text:          fmt.Sprintf("HTTPRoute uses the port %d of service %s/%s which is not exposed by the service.", *backendRef.Port, namespace, backendRef.Name),
+						KubernetesDoc: doc,
+						Sensitive: []common.Sensitive{
+							{
+								Unmasked: namespace,
+								Masked:   util.MaskString(namespace),
+							},
+							{
+								Unmasked: string(backendRef.Name),
+								Masked:   util.MaskString(string(backendRef.Name)),
+							},
+						},
+					})
+				}
+			}
+		}
+
+		if len(failures) > 0 {
+			preAnalysis[fmt.Sprintf("%s/%s", route.Namespace, route.Name)] = common.PreAnalysis{
+				Namespace:      route.Namespace,
+				ResourceName:   route.Name,
+				HTTPRoute:      route,
+				FailureDetails: failures,
+			}
+			AnalyzerErrorsMetric.WithLabelValues(kind, route.Name, route.Namespace).Set(float64(len(failures)))
+		}
+	}
+
+	for key, value := range preAnalysis {
+		var currentAnalysis = common.Result{
+			Namespace:    value.Namespace,
+			ResourceName: value.ResourceName,
+			Kind:         kind,
+			Name:         key,
+			Error:        value.FailureDetails,
+		}
+
+		parent, _ := util.GetParent(a.Client, value.HTTPRoute.ObjectMeta)
+		currentAnalysis.ParentObject = parent
+		a.Results = append(a.Results, currentAnalysis)
+	}
+
+	return a.Results, nil
+}
+
+func hasListener(gtw *gtwapi.Gateway, name gtwapi.SectionName) bool {
+	for _, listener := range gtw.Spec.Listeners {
+		if listener.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,91 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyzer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/magiconair/properties/assert"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime/pkg/client"
+	gtwapi "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+func TestHTTPRouteAnalyzer(t *testing.T) {
+	sectionName := gtwapi.SectionName("https")
+	port := gtwapi.PortNumber(8080)
+	wrongPort := gtwapi.PortNumber(9090)
+
+	client := gatewayTestClient(t, []ctrl.Object{
+		&gtwapi.Gateway{
+			ObjectMeta: metav1.ObjectMeta{Name: "example", Namespace: "test"},
+			Spec: gtwapi.GatewaySpec{
+				GatewayClassName: "example",
+				Listeners:        []gtwapi.Listener{{Name: "http", Port: 80, Protocol: gtwapi.HTTPProtocolType}},
+			},
+		},
+		&gtwapi.HTTPRoute{
+			ObjectMeta: metav1.ObjectMeta{Name: "healthy", Namespace: "test"},
+			Spec: gtwapi.HTTPRouteSpec{
+				CommonRouteSpec: gtwapi.CommonRouteSpec{
+					ParentRefs: []gtwapi.ParentReference{{Name: "example"}},
+				},
+				Rules: []gtwapi.HTTPRouteRule{
+					{BackendRefs: []gtwapi.HTTPBackendRef{
+						{BackendRef: gtwapi.BackendRef{BackendObjectReference: gtwapi.BackendObjectReference{Name: "backend", Port: &port}}},
+					}},
+				},
+			},
+		},
+		&gtwapi.HTTPRoute{
+			ObjectMeta: metav1.ObjectMeta{Name: "broken", Namespace: "test"},
+			Spec: gtwapi.HTTPRouteSpec{
+				CommonRouteSpec: gtwapi.CommonRouteSpec{
+					ParentRefs: []gtwapi.ParentReference{
+						{Name: "missing"},
+						{Name: "example", SectionName: &sectionName},
+					},
+				},
+				Rules: []gtwapi.HTTPRouteRule{
+					{BackendRefs: []gtwapi.HTTPBackendRef{
+						{BackendRef: gtwapi.BackendRef{BackendObjectReference: gtwapi.BackendObjectReference{Name: "missing", Port: &port}}},
+						{BackendRef: gtwapi.BackendRef{BackendObjectReference: gtwapi.BackendObjectReference{Name: "backend", Port: &wrongPort}}},
+					}},
+				},
+			},
+		},
+	}, &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "backend", Namespace: "test"},
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{{Port: 8080}},
+		},
+	})
+
+	config := common.Analyzer{
+		Client:    client,
+		Context:   context.Background(),
+		Namespace: "test",
+	}
+	analysisResults, err := HTTPRouteAnalyzer{}.Analyze(config)
+	if err != nil {
+		t.Error(err)
+	}
+	assert.Equal(t, len(analysisResults), 1)
+	assert.Equal(t, analysisResults[0].Name, "test/broken")
+	// missing gateway, missing listener, missing service and unexposed port
+	assert.Equal(t, len(analysisResults[0].Error), 4)
+}
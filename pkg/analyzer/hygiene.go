@@ -0,0 +1,284 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/util"
+	"github.com/spf13/viper"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// HygieneAnalyzer reports risky configurations of the workload templates, they are not broken yet
+type HygieneAnalyzer struct{}
+
+// HygieneConfig is read from the analyzers.hygiene key of the config file, every check can be switched off
+type HygieneConfig struct {
+	Requests         bool `mapstructure:"requests"`
+	Limits           bool `mapstructure:"limits"`
+	Usage            bool `mapstructure:"usage"`
+	Probes           bool `mapstructure:"probes"`
+	ImageTags        bool `mapstructure:"imagetags"`
+	PullPolicy       bool `mapstructure:"pullpolicy"`
+	SingleReplicaPDB bool `mapstructure:"singlereplicapdb"`
+	// UsageRatio is the share of a limit from which the observed usage is reported
+	UsageRatio float64 `mapstructure:"usageratio"`
+}
+
+func getHygieneConfig() HygieneConfig {
+	config := HygieneConfig{
+		Requests:         true,
+		Limits:           true,
+		Usage:            true,
+		Probes:           true,
+		ImageTags:        true,
+		PullPolicy:       true,
+		SingleReplicaPDB: true,
+		UsageRatio:       0.9,
+	}
+	_ = viper.UnmarshalKey("analyzers.hygiene", &config)
+	return config
+}
+
+// podMetrics is the part of the metrics.k8s.io PodMetrics used here, read without the metrics client
+type podMetrics struct {
+	Items []struct {
+		Metadata   metav1.ObjectMeta `json:"metadata"`
+		Containers []struct {
+			Name  string          `json:"name"`
+			Usage v1.ResourceList `json:"usage"`
+		} `json:"containers"`
+	} `json:"items"`
+}
+
+func (HygieneAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
+
+	kind := "Hygiene"
+	apiDoc := kubernetes.K8sApiReference{
+		Kind: "Pod",
+		ApiVersion: schema.GroupVersion{
+			Group:   "",
+			Version: "v1",
+		},
+		OpenapiSchema: a.OpenapiSchema,
+	}
+
+	AnalyzerErrorsMetric.DeletePartialMatch(map[string]string{
+		"analyzer_name": kind,
+	})
+
+	config := getHygieneConfig()
+
+	workloads, err := listWorkloads(a)
+	if err != nil {
+		return nil, err
+	}
+	services, err := a.Client.GetClient().CoreV1().Services(a.Namespace).List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	pdbs, err := a.Client.GetClient().PolicyV1().PodDisruptionBudgets(a.Namespace).List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var metrics *podMetrics
+	if config.Usage {
+		metrics = fetchPodMetrics(a)
+	}
+
+	for _, w := range workloads {
+		if SkipNamespace(w.ObjectMeta.Namespace) {
+			continue
+		}
+		var failures []common.Failure
+		namespace := w.ObjectMeta.Namespace
+		podLabels := labels.Set(w.Template.Labels)
+		report := func(path string, format string, args ...interface{}) {
+			failures = append(failures, common.Failure{
+				Text:          fmt.Sprintf("%s %s/%s ", w.Kind, namespace, w.ObjectMeta.Name) + fmt.Sprintf(format, args...),
+				KubernetesDoc: apiDoc.GetApiDocV2(path),
+				Sensitive: []common.Sensitive{
+					{
+						Unmasked: namespace,
+						Masked:   util.MaskString(namespace),
+					},
+					{
+						Unmasked: w.ObjectMeta.Name,
+						Masked:   util.MaskString(w.ObjectMeta.Name),
+					},
+				},
+			})
+		}
+
+		// the probes only matter for the pods receiving traffic from a Service
+		serviceBacked := false
+		for _, svc := range services.Items {
+			if svc.Namespace == namespace && len(svc.Spec.Selector) > 0 && labels.SelectorFromSet(svc.Spec.Selector).Matches(podLabels) {
+				serviceBacked = true
+				break
+			}
+		}
+
+		for _, container := range w.Template.Spec.Containers {
+			for _, resourceName := range []v1.ResourceName{v1.ResourceCPU, v1.ResourceMemory} {
+				if _, ok := container.Resources.Requests[resourceName]; config.Requests && !ok {
+					report("spec.containers.resources.requests", "container %s has no %s request.", container.Name, resourceName)
+				}
+				if _, ok := container.Resources.Limits[resourceName]; config.Limits && !ok {
+					report("spec.containers.resources.limits", "container %s has no %s limit.", container.Name, resourceName)
+				}
+			}
+
+			if config.Probes && serviceBacked {
+				if container.ReadinessProbe == nil {
+					report("spec.containers.readinessProbe", "container %s receives Service traffic but has no readiness probe.", container.Name)
+				}
+				if container.LivenessProbe == nil {
+					report("spec.containers.livenessProbe", "container %s receives Service traffic but has no liveness probe.", container.Name)
+				}
+			}
+
+			digest := strings.Contains(container.Image, "@")
+			if config.ImageTags && !digest {
+				if tag := imageTag(container.Image); tag == "" {
+					report("spec.containers.image", "container %s uses the untagged image %s, which resolves to latest.", container.Name, container.Image)
+				} else if tag == "latest" {
+					report("spec.containers.image", "container %s uses the image %s, the latest tag is not reproducible.", container.Name, container.Image)
+				}
+			}
+			if config.PullPolicy && digest && container.ImagePullPolicy == v1.PullAlways {
+				report("spec.containers.imagePullPolicy", "container %s pulls the image %s on every start although it is pinned by digest.", container.Name, container.Image)
+			}
+
+			if metrics != nil {
+				for _, usage := range containerUsage(metrics, namespace, podLabels, container.Name) {
+					for _, resourceName := range []v1.ResourceName{v1.ResourceCPU, v1.ResourceMemory} {
+						limit, ok := container.Resources.Limits[resourceName]
+						used, observed := usage[resourceName]
+						if !ok || !observed || limit.IsZero() {
+							continue
+						}
+						if used.AsApproximateFloat64() >= limit.AsApproximateFloat64()*config.UsageRatio {
+							report("spec.containers.resources.limits", "container %s uses %s of %s, close to its limit of %s.", container.Name, used.String(), resourceName, limit.String())
+						}
+					}
+				}
+			}
+		}
+
+		if config.SingleReplicaPDB && w.Kind == "Deployment" && w.Replicas != nil && *w.Replicas == 1 {
+			for _, pdb := range pdbs.Items {
+				if pdb.Namespace != namespace || pdb.Spec.Selector == nil {
+					continue
+				}
+				selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+				if err != nil || selector.Empty() || !selector.Matches(podLabels) {
+					continue
+				}
+				failures = append(failures, common.Failure{
+					Text: fmt.Sprintf("Deployment %s/%s has a single replica behind the PodDisruptionBudget %s, it is either unprotected or blocks node drains.",
+						namespace, w.ObjectMeta.Name, pdb.Name),
+					Sensitive: []common.Sensitive{
+						{
+							Unmasked: namespace,
+							Masked:   util.MaskString(namespace),
+						},
+						{
+							Unmasked: w.ObjectMeta.Name,
+							Masked:   util.MaskString(w.ObjectMeta.Name),
+						},
+					},
+				})
+			}
+		}
+
+		if len(failures) == 0 {
+			continue
+		}
+		AnalyzerErrorsMetric.WithLabelValues(kind, w.ObjectMeta.Name, namespace).Set(float64(len(failures)))
+
+		a.Results = append(a.Results, common.Result{
+			Namespace:    namespace,
+			ResourceName: w.ObjectMeta.Name,
+			Kind:         w.Kind,
+			Name:         fmt.Sprintf("%s/%s", namespace, w.ObjectMeta.Name),
+			Error:        failures,
+			ParentObject: fmt.Sprintf("%s/%s", w.Kind, w.ObjectMeta.Name),
+		})
+	}
+
+	return a.Results, nil
+}
+
+// imageTag returns the tag of an image reference, a port of the registry is not a tag
+func imageTag(image string) string {
+	name := image[strings.LastIndex(image, "/")+1:]
+	if i := strings.LastIndex(name, ":"); i >= 0 {
+		return name[i+1:]
+	}
+	return ""
+}
+
+// fetchPodMetrics reads the pod usage from metrics-server, nil is returned when it is not installed
+func fetchPodMetrics(a common.Analyzer) *podMetrics {
+	discovery := a.Client.GetClient().Discovery()
+	if _, err := discovery.ServerResourcesForGroupVersion("metrics.k8s.io/v1beta1"); err != nil {
+		return nil
+	}
+	restClient := discovery.RESTClient()
+	if restClient == nil {
+		return nil
+	}
+	path := "/apis/metrics.k8s.io/v1beta1/pods"
+	if a.Namespace != "" {
+		path = fmt.Sprintf("/apis/metrics.k8s.io/v1beta1/namespaces/%s/pods", a.Namespace)
+	}
+	data, err := restClient.Get().AbsPath(path).DoRaw(a.Context)
+	if err != nil {
+		return nil
+	}
+	var metrics podMetrics
+	if err := json.Unmarshal(data, &metrics); err != nil {
+		return nil
+	}
+	return &metrics
+}
+
+// containerUsage returns the usage of a container in every pod created from a template
+func containerUsage(metrics *podMetrics, namespace string, podLabels labels.Set, containerName string) []v1.ResourceList {
+	var usages []v1.ResourceList
+	if len(podLabels) == 0 {
+		return nil
+	}
+	selector := labels.SelectorFromSet(podLabels)
+	for _, item := range metrics.Items {
+		if item.Metadata.Namespace != namespace || !selector.Matches(labels.Set(item.Metadata.Labels)) {
+			continue
+		}
+		for _, container := range item.Containers {
+			if container.Name == containerName {
+				usages = append(usages, container.Usage)
+			}
+		}
+	}
+	return usages
+}
@@ -0,0 +1,124 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyzer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
+	"github.com/magiconair/properties/assert"
+	"github.com/spf13/viper"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func hygieneTestClient() *fake.Clientset {
+	replicas := int32(1)
+	resources := v1.ResourceRequirements{
+		Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("100m"), v1.ResourceMemory: resource.MustParse("64Mi")},
+		Limits:   v1.ResourceList{v1.ResourceCPU: resource.MustParse("1"), v1.ResourceMemory: resource.MustParse("256Mi")},
+	}
+	probe := &v1.Probe{ProbeHandler: v1.ProbeHandler{HTTPGet: &v1.HTTPGetAction{Path: "/healthz"}}}
+	return fake.NewSimpleClientset(
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "tidy", Namespace: "test"},
+			Spec: appsv1.DeploymentSpec{
+				Template: v1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "tidy"}},
+					Spec: v1.PodSpec{
+						Containers: []v1.Container{
+							{Name: "app", Image: "registry.example.com:5000/tidy:1.2.3", Resources: resources, ReadinessProbe: probe, LivenessProbe: probe},
+						},
+					},
+				},
+			},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "test"},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: &replicas,
+				Template: v1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "web"}},
+					Spec: v1.PodSpec{
+						Containers: []v1.Container{
+							{Name: "app", Image: "nginx", Resources: resources},
+							{Name: "sidecar", Image: "envoy@sha256:0123456789abcdef", ImagePullPolicy: v1.PullAlways, Resources: resources, ReadinessProbe: probe, LivenessProbe: probe},
+						},
+					},
+				},
+			},
+		},
+		&v1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "test"},
+			Spec:       v1.ServiceSpec{Selector: map[string]string{"app": "web"}},
+		},
+		&policyv1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "test"},
+			Spec: policyv1.PodDisruptionBudgetSpec{
+				Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+			},
+		})
+}
+
+func TestHygieneAnalyzer(t *testing.T) {
+	config := common.Analyzer{
+		Client: &kubernetes.Client{
+			Client: hygieneTestClient(),
+		},
+		Context:   context.Background(),
+		Namespace: "test",
+	}
+	analysisResults, err := HygieneAnalyzer{}.Analyze(config)
+	if err != nil {
+		t.Error(err)
+	}
+	assert.Equal(t, len(analysisResults), 1)
+	var texts []string
+	for _, failure := range analysisResults[0].Error {
+		texts = append(texts, failure.Text)
+	}
+	assert.Equal(t, texts, []string{
+		"Deployment test/web container app receives Service traffic but has no readiness probe.",
+		"Deployment test/web container app receives Service traffic but has no liveness probe.",
+		"Deployment test/web container app uses the untagged image nginx, which resolves to latest.",
+		"Deployment test/web container sidecar pulls the image envoy@sha256:0123456789abcdef on every start although it is pinned by digest.",
+		"Deployment test/web has a single replica behind the PodDisruptionBudget web, it is either unprotected or blocks node drains.",
+	})
+}
+
+func TestHygieneAnalyzerToggles(t *testing.T) {
+	for _, check := range []string{"probes", "imagetags", "pullpolicy", "singlereplicapdb"} {
+		viper.Set("analyzers.hygiene."+check, false)
+	}
+	defer viper.Set("analyzers.hygiene", nil)
+
+	config := common.Analyzer{
+		Client: &kubernetes.Client{
+			Client: hygieneTestClient(),
+		},
+		Context:   context.Background(),
+		Namespace: "test",
+	}
+	analysisResults, err := HygieneAnalyzer{}.Analyze(config)
+	if err != nil {
+		t.Error(err)
+	}
+	assert.Equal(t, len(analysisResults), 0)
+}
@@ -0,0 +1,237 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/util"
+	cron "github.com/robfig/cron/v3"
+	"github.com/spf13/viper"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+type JobAnalyzer struct{}
+
+// JobConfig is read from the analyzers.job key of the config file
+type JobConfig struct {
+	// MaxActiveDuration is how long a job may run, the jobs of a CronJob are expected to end before the next run
+	MaxActiveDuration string `mapstructure:"maxactiveduration"`
+	// FailedHistory is the number of consecutive failed runs from which a CronJob is reported
+	FailedHistory int `mapstructure:"failedhistory"`
+
+	maxActiveDuration time.Duration
+}
+
+func getJobConfig() JobConfig {
+	config := JobConfig{
+		MaxActiveDuration: "1h",
+		FailedHistory:     3,
+	}
+	_ = viper.UnmarshalKey("analyzers.job", &config)
+	config.maxActiveDuration = configDuration("analyzers.job.maxactiveduration", config.MaxActiveDuration)
+	return config
+}
+
+func (JobAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
+
+	kind := "Job"
+	apiDoc := kubernetes.K8sApiReference{
+		Kind: kind,
+		ApiVersion: schema.GroupVersion{
+			Group:   "batch",
+			Version: "v1",
+		},
+		OpenapiSchema: a.OpenapiSchema,
+	}
+
+	AnalyzerErrorsMetric.DeletePartialMatch(map[string]string{
+		"analyzer_name": kind,
+	})
+
+	config := getJobConfig()
+
+	list, err := a.Client.GetClient().BatchV1().Jobs(a.Namespace).List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	cronJobs, err := a.Client.GetClient().BatchV1().CronJobs(a.Namespace).List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	schedules := map[string]string{}
+	for _, cronJob := range cronJobs.Items {
+		schedules[cronJob.Namespace+"/"+cronJob.Name] = cronJob.Spec.Schedule
+	}
+
+	// the runs of a CronJob are history, only the latest one is analyzed on its own
+	runs := map[string][]batchv1.Job{}
+	var jobs []batchv1.Job
+	for _, job := range list.Items {
+		if SkipNamespace(job.Namespace) {
+			continue
+		}
+		if owner := cronJobOwner(job); owner != "" {
+			key := job.Namespace + "/" + owner
+			runs[key] = append(runs[key], job)
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+	history := map[string][]batchv1.Job{}
+	for _, cronJobRuns := range runs {
+		sort.Slice(cronJobRuns, func(i, j int) bool {
+			return cronJobRuns[i].CreationTimestamp.Before(&cronJobRuns[j].CreationTimestamp)
+		})
+		latest := cronJobRuns[len(cronJobRuns)-1]
+		jobs = append(jobs, latest)
+		history[latest.Namespace+"/"+latest.Name] = cronJobRuns
+	}
+
+	var preAnalysis = map[string]common.PreAnalysis{}
+
+	for _, job := range jobs {
+		var failures []common.Failure
+		sensitive := []common.Sensitive{
+			{
+				Unmasked: job.Namespace,
+				Masked:   util.MaskString(job.Namespace),
+			},
+			{
+				Unmasked: job.Name,
+				Masked:   util.MaskString(job.Name),
+			},
+		}
+
+		if failed := jobCondition(job, batchv1.JobFailed); failed != nil {
+			var doc string
+			switch failed.Reason {
+			case "BackoffLimitExceeded":
+				doc = apiDoc.GetApiDocV2("spec.backoffLimit")
+			case "DeadlineExceeded":
+				doc = apiDoc.GetApiDocV2("spec.activeDeadlineSeconds")
+			}
+
+			failures = append(failures, common.Failure{
+				Text:          fmt.Sprintf("Job %s/%s has failed with reason %s: %s", job.Namespace, job.Name, failed.Reason, failed.Message),
+				KubernetesDoc: doc,
+				Sensitive:     sensitive,
+			})
+		}
+
+		if job.Status.Active > 0 && job.Status.StartTime != nil && jobCondition(job, batchv1.JobFailed) == nil {
+			// an unset maximum leaves the CronJob schedules only
+			expected := config.maxActiveDuration
+			if owner := cronJobOwner(job); owner != "" {
+				if interval, ok := scheduleInterval(schedules[job.Namespace+"/"+owner]); ok && (expected == 0 || interval < expected) {
+					expected = interval
+				}
+			}
+			if active := time.Since(job.Status.StartTime.Time); expected > 0 && active > expected {
+				doc := apiDoc.GetApiDocV2("spec.activeDeadlineSeconds")
+
+				failures = append(failures, common.Failure{
+					Text: fmt.Sprintf("Job %s/%s has been active for %s, longer than the expected %s.", job.Namespace, job.Name,
+						active.Round(time.Second), expected),
+					KubernetesDoc: doc,
+					Sensitive:     sensitive,
+				})
+			}
+		}
+
+		if cronJobRuns := history[job.Namespace+"/"+job.Name]; config.FailedHistory > 0 && len(cronJobRuns) >= config.FailedHistory {
+			allFailed := true
+			for _, run := range cronJobRuns[len(cronJobRuns)-config.FailedHistory:] {
+				if jobCondition(run, batchv1.JobFailed) == nil {
+					allFailed = false
+					break
+				}
+			}
+			if allFailed {
+				owner := cronJobOwner(job)
+				failures = append(failures, common.Failure{
+					Text: fmt.Sprintf("The last %d jobs of CronJob %s/%s have all failed.", config.FailedHistory, job.Namespace, owner),
+					Sensitive: append(sensitive, common.Sensitive{
+						Unmasked: owner,
+						Masked:   util.MaskString(owner),
+					}),
+				})
+			}
+		}
+
+		if len(failures) > 0 {
+			preAnalysis[fmt.Sprintf("%s/%s", job.Namespace, job.Name)] = common.PreAnalysis{
+				Namespace:      job.Namespace,
+				ResourceName:   job.Name,
+				FailureDetails: failures,
+				Job:            job,
+			}
+			AnalyzerErrorsMetric.WithLabelValues(kind, job.Name, job.Namespace).Set(float64(len(failures)))
+		}
+	}
+
+	for key, value := range preAnalysis {
+		var currentAnalysis = common.Result{
+			Namespace:    value.Namespace,
+			ResourceName: value.ResourceName,
+			Kind:         kind,
+			Name:         key,
+			Error:        value.FailureDetails,
+		}
+
+		parent, _ := util.GetParent(a.Client, value.Job.ObjectMeta)
+		currentAnalysis.ParentObject = parent
+		a.Results = append(a.Results, currentAnalysis)
+	}
+
+	return a.Results, nil
+}
+
+func jobCondition(job batchv1.Job, conditionType batchv1.JobConditionType) *batchv1.JobCondition {
+	for i, condition := range job.Status.Conditions {
+		if condition.Type == conditionType && condition.Status == v1.ConditionTrue {
+			return &job.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+func cronJobOwner(job batchv1.Job) string {
+	for _, owner := range job.OwnerReferences {
+		if owner.Kind == "CronJob" {
+			return owner.Name
+		}
+	}
+	return ""
+}
+
+// scheduleInterval returns the time between two runs of a cron schedule
+func scheduleInterval(schedule string) (time.Duration, bool) {
+	if schedule == "" {
+		return 0, false
+	}
+	sched, err := cron.ParseStandard(schedule)
+	if err != nil {
+		return 0, false
+	}
+	next := sched.Next(time.Now())
+	return sched.Next(next).Sub(next), true
+}
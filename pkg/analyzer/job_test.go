@@ -0,0 +1,96 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyzer
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
+	"github.com/magiconair/properties/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func failedJob(name string, reason string, created time.Time, owner string) *batchv1.Job {
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test", CreationTimestamp: metav1.NewTime(created)},
+		Status: batchv1.JobStatus{
+			Conditions: []batchv1.JobCondition{
+				{Type: batchv1.JobFailed, Status: v1.ConditionTrue, Reason: reason, Message: "Job has reached the specified backoff limit"},
+			},
+		},
+	}
+	if owner != "" {
+		job.OwnerReferences = []metav1.OwnerReference{{Kind: "CronJob", Name: owner}}
+	}
+	return job
+}
+
+func TestJobAnalyzer(t *testing.T) {
+	now := time.Now()
+	objects := []runtime.Object{
+		failedJob("migrate", "BackoffLimitExceeded", now, ""),
+		&batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: "stuck", Namespace: "test"},
+			Status: batchv1.JobStatus{
+				Active:    1,
+				StartTime: &metav1.Time{Time: now.Add(-2 * time.Hour)},
+			},
+		},
+		&batchv1.Job{
+			ObjectMeta: metav1.ObjectMeta{Name: "running", Namespace: "test"},
+			Status: batchv1.JobStatus{
+				Active:    1,
+				StartTime: &metav1.Time{Time: now.Add(-time.Minute)},
+			},
+		},
+		&batchv1.CronJob{
+			ObjectMeta: metav1.ObjectMeta{Name: "backup", Namespace: "test"},
+			Spec:       batchv1.CronJobSpec{Schedule: "*/5 * * * *"},
+		},
+	}
+	// only the latest run of the CronJob is reported, with the failed history
+	for i := 0; i < 3; i++ {
+		objects = append(objects, failedJob("backup-"+string(rune('a'+i)), "DeadlineExceeded", now.Add(time.Duration(i-3)*time.Minute), "backup"))
+	}
+	clientset := fake.NewSimpleClientset(objects...)
+
+	config := common.Analyzer{
+		Client: &kubernetes.Client{
+			Client: clientset,
+		},
+		Context:   context.Background(),
+		Namespace: "test",
+	}
+	analysisResults, err := JobAnalyzer{}.Analyze(config)
+	if err != nil {
+		t.Error(err)
+	}
+	sort.Slice(analysisResults, func(i, j int) bool {
+		return analysisResults[i].Name < analysisResults[j].Name
+	})
+	assert.Equal(t, len(analysisResults), 3)
+	assert.Equal(t, analysisResults[0].Name, "test/backup-c")
+	assert.Equal(t, len(analysisResults[0].Error), 2)
+	assert.Equal(t, analysisResults[0].ParentObject, "CronJob/backup")
+	assert.Equal(t, analysisResults[1].Name, "test/migrate")
+	assert.Equal(t, analysisResults[2].Name, "test/stuck")
+}
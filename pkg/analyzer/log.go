@@ -17,21 +17,204 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
 	"github.com/k8sgpt-ai/k8sgpt/pkg/util"
+	"github.com/spf13/viper"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
-var (
-	errorPattern = regexp.MustCompile(`(error|exception|fail)`)
-	tailLines    = int64(100)
-)
+// maxTraceLines bounds the lines of a stack trace kept in a failure
+const maxTraceLines = 20
 
 type LogAnalyzer struct {
 }
 
+// LogConfig is read from the analyzers.log key of the config file
+type LogConfig struct {
+	// TailLines is the number of lines read from the end of the logs of every container
+	TailLines int64 `mapstructure:"taillines"`
+	// Since only reads the lines logged during this duration, all the tail lines are read when empty
+	Since string `mapstructure:"since"`
+	// Previous also reads the logs of the previous instance of the restarted containers
+	Previous bool `mapstructure:"previous"`
+	// Patterns are added to the built-in pattern library, before the generic error pattern
+	Patterns []LogPattern `mapstructure:"patterns"`
+	// MaxFindings is the number of findings reported for a container, its current and previous instances together,
+	// 0 reports them all
+	MaxFindings int `mapstructure:"maxfindings"`
+
+	since time.Duration
+}
+
+// LogPattern recognizes a problem in the logs. A multi-line problem, like a stack trace, starts with a line
+// matching Start and goes on with the lines matching Continuation
+type LogPattern struct {
+	Name         string `mapstructure:"name"`
+	Start        string `mapstructure:"start"`
+	Continuation string `mapstructure:"continuation"`
+
+	start        *regexp.Regexp
+	continuation *regexp.Regexp
+}
+
+func getLogConfig() LogConfig {
+	config := LogConfig{
+		TailLines:   100,
+		Previous:    true,
+		MaxFindings: 10,
+	}
+	_ = viper.UnmarshalKey("analyzers.log", &config)
+	config.since = configDuration("analyzers.log.since", config.Since)
+	return config
+}
+
+// logPatterns is the pattern library, the first matching pattern wins and the last one catches the generic errors,
+// the memory errors come first as they are also Java exceptions
+var logPatterns = []LogPattern{
+	{
+		Name:  "Out of memory",
+		Start: `(?i)(out of memory|outofmemoryerror|java heap space|cannot allocate memory|oom-?kill)`,
+	},
+	{
+		Name:         "Java exception",
+		Start:        `^(Exception in thread ".*" )?([a-zA-Z_$][\w$]*\.)+[\w$]*(Exception|Error)(: .*)?$`,
+		Continuation: `^(\s+at |\s*\.\.\. \d+ (more|common frames omitted)|Caused by: |Suppressed: )`,
+	},
+	{
+		Name:         "Go panic",
+		Start:        `^(panic: |fatal error: )`,
+		Continuation: `^(\s*$|\t|goroutine \d+ \[|\[signal |created by |[\w./*()-]+\(.*\)$|exit status )`,
+	},
+	{
+		Name:         "Python traceback",
+		Start:        `^Traceback \(most recent call last\):`,
+		Continuation: `^(\s+|[\w.]+(Error|Exception|Exit|Interrupt)(: .*)?$)`,
+	},
+	{
+		Name:  "Connection refused",
+		Start: `(?i)connection refused`,
+	},
+	{
+		Name:  "DNS failure",
+		Start: `(?i)(no such host|name or service not known|temporary failure in name resolution|server misbehaving|could not resolve host|nxdomain)`,
+	},
+	{
+		Name:  genericLogPattern,
+		Start: `(?i)(error|exception|fail)`,
+	},
+}
+
+// genericLogPattern catches the errors no other pattern recognizes, it is only reported for the containers
+// where no other pattern matched
+const genericLogPattern = "Error"
+
+// logPrefix matches the timestamps and levels logged before the messages, they differ between repeated lines
+var logPrefix = regexp.MustCompile(`^(\S*\d{2}:\d{2}:\d{2}[.,\d]*\S*\s+|[IWEF]\d{4}\s+\d{2}:\d{2}:\d{2}\.\d+\s+\d+\s+\S+\]\s+)`)
+
+// logFinding is a problem found in the logs, a stack trace or a line repeated Count times
+type logFinding struct {
+	Pattern string
+	Lines   []string
+	Count   int
+}
+
+// compileLogPatterns returns the configured patterns followed by the library, the invalid ones are left out
+func compileLogPatterns(extra []LogPattern) []LogPattern {
+	var patterns []LogPattern
+	for _, pattern := range append(append([]LogPattern{}, extra...), logPatterns...) {
+		start, err := regexp.Compile(pattern.Start)
+		if err != nil || pattern.Start == "" {
+			continue
+		}
+		pattern.start = start
+		if pattern.Continuation != "" {
+			if pattern.continuation, err = regexp.Compile(pattern.Continuation); err != nil {
+				continue
+			}
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns
+}
+
+// analyzeLogs groups the lines of a stack trace in one finding and counts the repeated lines
+func analyzeLogs(logs string, patterns []LogPattern) []logFinding {
+	var findings []logFinding
+	seen := map[string]int{}
+	lines := strings.Split(strings.TrimRight(logs, "\n"), "\n")
+
+	for i := 0; i < len(lines); i++ {
+		line := logPrefix.ReplaceAllString(strings.TrimRight(lines[i], "\r"), "")
+		for _, pattern := range patterns {
+			if !pattern.start.MatchString(line) {
+				continue
+			}
+			finding := logFinding{Pattern: pattern.Name, Lines: []string{line}, Count: 1}
+			if pattern.continuation != nil {
+				for i+1 < len(lines) && pattern.continuation.MatchString(strings.TrimRight(lines[i+1], "\r")) {
+					i++
+					if len(finding.Lines) < maxTraceLines {
+						finding.Lines = append(finding.Lines, strings.TrimRight(lines[i], "\r"))
+					}
+				}
+			}
+			key := strings.Join(finding.Lines, "\n")
+			if index, ok := seen[key]; ok {
+				findings[index].Count++
+			} else {
+				seen[key] = len(findings)
+				findings = append(findings, finding)
+			}
+			break
+		}
+	}
+	return findings
+}
+
+// containerFinding is a finding in the logs of a container instance
+type containerFinding struct {
+	Instance string
+	logFinding
+}
+
+// selectFindings keeps the generic findings only when nothing more specific was found, then the first max findings.
+// It returns how many findings were left out by max.
+func selectFindings(findings []containerFinding, max int) ([]containerFinding, int) {
+	specific := false
+	for _, finding := range findings {
+		if finding.Pattern != genericLogPattern {
+			specific = true
+		}
+	}
+	var selected []containerFinding
+	for _, finding := range findings {
+		if specific && finding.Pattern == genericLogPattern {
+			continue
+		}
+		selected = append(selected, finding)
+	}
+	if max <= 0 || len(selected) <= max {
+		return selected, 0
+	}
+	return selected[:max], len(selected) - max
+}
+
+func podLogOptions(config LogConfig, container string, previous bool) *v1.PodLogOptions {
+	options := &v1.PodLogOptions{
+		Container: container,
+		TailLines: &config.TailLines,
+		Previous:  previous,
+	}
+	if config.since > 0 {
+		seconds := int64(config.since.Seconds())
+		options.SinceSeconds = &seconds
+	}
+	return options
+}
+
 func (LogAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
 
 	kind := "Log"
@@ -40,6 +223,9 @@ func (LogAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
 		"analyzer_name": kind,
 	})
 
+	config := getLogConfig()
+	patterns := compileLogPatterns(config.Patterns)
+
 	// search all namespaces for pods that are not running
 	list, err := a.Client.GetClient().CoreV1().Pods(a.Namespace).List(a.Context, metav1.ListOptions{})
 	if err != nil {
@@ -50,35 +236,58 @@ func (LogAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
 
 	for _, pod := range list.Items {
 		var failures []common.Failure
-		podName := pod.Name
-		podLogOptions := v1.PodLogOptions{
-			TailLines: &tailLines,
+		sensitive := []common.Sensitive{
+			{
+				Unmasked: pod.Name,
+				Masked:   util.MaskString(pod.Name),
+			},
+		}
+
+		restarts := map[string]int32{}
+		for _, status := range pod.Status.ContainerStatuses {
+			restarts[status.Name] = status.RestartCount
 		}
 
-		podLogs, err := a.Client.Client.CoreV1().Pods(pod.Namespace).GetLogs(podName, &podLogOptions).DoRaw(a.Context)
-
-		if err != nil {
-			failures = append(failures, common.Failure{
-				Text: fmt.Sprintf("Error %s from Pod %s", err.Error(), pod.Name),
-				Sensitive: []common.Sensitive{
-					{
-						Unmasked: pod.Name,
-						Masked:   util.MaskString(pod.Name),
-					},
-				},
-			})
-
-		} else {
-			rawlogs := string(podLogs)
-			if errorPattern.MatchString(strings.ToLower(rawlogs)) {
+		for _, container := range pod.Spec.Containers {
+			sources := []bool{false}
+			// the previous instance logged why it stopped, the current one may not have failed yet
+			if config.Previous && restarts[container.Name] > 0 {
+				sources = append(sources, true)
+			}
+			var findings []containerFinding
+			for _, previous := range sources {
+				instance := "container"
+				if previous {
+					instance = "previous container"
+				}
+				podLogs, err := a.Client.GetClient().CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, podLogOptions(config, container.Name, previous)).DoRaw(a.Context)
+				if err != nil {
+					failures = append(failures, common.Failure{
+						Text:      fmt.Sprintf("Error %s from the %s %s of Pod %s", err.Error(), instance, container.Name, pod.Name),
+						Sensitive: sensitive,
+					})
+					continue
+				}
+				for _, finding := range analyzeLogs(string(podLogs), patterns) {
+					findings = append(findings, containerFinding{Instance: instance, logFinding: finding})
+				}
+			}
+
+			findings, omitted := selectFindings(findings, config.MaxFindings)
+			for _, finding := range findings {
+				text := fmt.Sprintf("The %s %s of Pod %s logged", finding.Instance, container.Name, pod.Name)
+				if finding.Count > 1 {
+					text += fmt.Sprintf(" %d times", finding.Count)
+				}
 				failures = append(failures, common.Failure{
-					Text: printErrorLines(pod.Name, pod.Namespace, rawlogs, errorPattern),
-					Sensitive: []common.Sensitive{
-						{
-							Unmasked: pod.Name,
-							Masked:   util.MaskString(pod.Name),
-						},
-					},
+					Text:      fmt.Sprintf("%s (%s): %s", text, finding.Pattern, strings.Join(finding.Lines, "\n")),
+					Sensitive: sensitive,
+				})
+			}
+			if omitted > 0 {
+				failures = append(failures, common.Failure{
+					Text:      fmt.Sprintf("The container %s of Pod %s logged %d more problems that are not shown.", container.Name, pod.Name, omitted),
+					Sensitive: sensitive,
 				})
 			}
 		}
@@ -103,15 +312,3 @@ func (LogAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
 
 	return a.Results, nil
 }
-func printErrorLines(podName, namespace, logs string, errorPattern *regexp.Regexp) string {
-	// Split the logs into lines
-	logLines := strings.Split(logs, "\n")
-
-	// Check each line for errors and print the lines containing errors
-	for _, line := range logLines {
-		if errorPattern.MatchString(strings.ToLower(line)) {
-			return line
-		}
-	}
-	return ""
-}
@@ -0,0 +1,101 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyzer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+)
+
+func TestAnalyzeLogs(t *testing.T) {
+	logs := strings.Join([]string{
+		"2023-10-19T10:00:00Z INFO starting server on :8080",
+		"2023-10-19T10:00:01Z WARN dial tcp 10.0.0.12:5432: connect: connection refused",
+		"2023-10-19T10:00:02Z WARN dial tcp 10.0.0.12:5432: connect: connection refused",
+		"2023-10-19T10:00:03Z WARN dial tcp 10.0.0.12:5432: connect: connection refused",
+		"E1019 10:00:04.123456       1 client.go:42] lookup db.test.svc on 10.96.0.10:53: no such host",
+		`Exception in thread "main" java.lang.IllegalStateException: cache not ready`,
+		"	at com.example.Cache.get(Cache.java:42)",
+		"	at com.example.Main.main(Main.java:12)",
+		"Caused by: java.net.SocketTimeoutException: Read timed out",
+		"	... 2 more",
+		"Traceback (most recent call last):",
+		`  File "/app/main.py", line 3, in <module>`,
+		"    import missing",
+		"ModuleNotFoundError: No module named 'missing'",
+		"panic: runtime error: invalid memory address or nil pointer dereference",
+		"[signal SIGSEGV: segmentation violation code=0x1 addr=0x0 pc=0x4553d1]",
+		"",
+		"goroutine 1 [running]:",
+		"main.main()",
+		"	/app/main.go:9 +0x11",
+		"exit status 2",
+		"2023-10-19T10:00:05Z INFO shutting down",
+		"java.lang.OutOfMemoryError: Java heap space",
+		"2023-10-19T10:00:06Z ERROR request failed",
+	}, "\n")
+
+	var findings []string
+	for _, finding := range analyzeLogs(logs, compileLogPatterns(nil)) {
+		findings = append(findings, finding.Pattern)
+		switch finding.Pattern {
+		case "Connection refused":
+			assert.Equal(t, finding.Count, 3)
+		case "Java exception":
+			assert.Equal(t, len(finding.Lines), 5)
+		case "Python traceback":
+			assert.Equal(t, len(finding.Lines), 4)
+		case "Go panic":
+			assert.Equal(t, len(finding.Lines), 7)
+		}
+	}
+	assert.Equal(t, findings, []string{"Connection refused", "DNS failure", "Java exception", "Python traceback", "Go panic", "Out of memory", "Error"})
+}
+
+func TestCompileLogPatterns(t *testing.T) {
+	patterns := compileLogPatterns([]LogPattern{
+		{Name: "Deadlock", Start: "deadlock detected"},
+		{Name: "Invalid", Start: "("},
+	})
+	assert.Equal(t, len(patterns), len(logPatterns)+1)
+	assert.Equal(t, patterns[0].Name, "Deadlock")
+
+	findings := analyzeLogs("ERROR: deadlock detected", patterns)
+	assert.Equal(t, len(findings), 1)
+	assert.Equal(t, findings[0].Pattern, "Deadlock")
+}
+
+func TestSelectFindings(t *testing.T) {
+	generic := containerFinding{Instance: "container", logFinding: logFinding{Pattern: genericLogPattern}}
+	refused := containerFinding{Instance: "previous container", logFinding: logFinding{Pattern: "Connection refused"}}
+
+	selected, omitted := selectFindings([]containerFinding{generic, generic}, 10)
+	assert.Equal(t, len(selected), 2)
+	assert.Equal(t, omitted, 0)
+
+	// the generic findings are left out as soon as a more specific pattern matched, in either instance
+	selected, omitted = selectFindings([]containerFinding{generic, refused, generic}, 10)
+	assert.Equal(t, selected, []containerFinding{refused})
+	assert.Equal(t, omitted, 0)
+
+	selected, omitted = selectFindings([]containerFinding{generic, generic, generic}, 2)
+	assert.Equal(t, len(selected), 2)
+	assert.Equal(t, omitted, 1)
+
+	selected, omitted = selectFindings([]containerFinding{generic, generic, generic}, 0)
+	assert.Equal(t, len(selected), 3)
+	assert.Equal(t, omitted, 0)
+}
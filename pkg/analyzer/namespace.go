@@ -0,0 +1,241 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
+	"github.com/spf13/viper"
+	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// apiServiceGroupVersion is the API of the APIService objects, read as unstructured objects to avoid depending
+// on the aggregator
+var apiServiceGroupVersion = schema.GroupVersion{Group: "apiregistration.k8s.io", Version: "v1"}
+
+// NamespaceAnalyzer reports the namespaces stuck terminating, the unavailable APIServices blocking their
+// deletion and the namespaces missing the labels required by the policy
+type NamespaceAnalyzer struct{}
+
+// NamespaceConfig is read from the analyzers.namespace key of the config file
+type NamespaceConfig struct {
+	// TerminatingTimeout is how long a namespace can stay Terminating before it is reported
+	TerminatingTimeout string `mapstructure:"terminatingtimeout"`
+	// RequiredLabels are the label keys every namespace must have, the system namespaces are exempted
+	RequiredLabels []string `mapstructure:"requiredlabels"`
+
+	terminatingTimeout time.Duration
+}
+
+func getNamespaceConfig() NamespaceConfig {
+	config := NamespaceConfig{
+		TerminatingTimeout: "5m",
+	}
+	_ = viper.UnmarshalKey("analyzers.namespace", &config)
+	config.terminatingTimeout = configDuration("analyzers.namespace.terminatingtimeout", config.TerminatingTimeout)
+	return config
+}
+
+// namespaceDeletionConditions are the conditions set by the namespace controller on what blocks a deletion,
+// with a hint when the message is not explicit
+var namespaceDeletionConditions = map[v1.NamespaceConditionType]string{
+	v1.NamespaceDeletionDiscoveryFailure: "the discovery of some APIs fails, usually because an APIService is unavailable",
+	v1.NamespaceDeletionGVParsingFailure: "some API group versions cannot be parsed",
+	v1.NamespaceDeletionContentFailure:   "some resources of the namespace cannot be deleted",
+	v1.NamespaceContentRemaining:         "resources of the namespace are still being deleted",
+	v1.NamespaceFinalizersRemaining:      "the controllers owning these finalizers have not removed them, check that they are running",
+}
+
+// apiService is an APIService whose Available condition is not True
+type apiService struct {
+	Name    string
+	Service string
+	Reason  string
+	Message string
+}
+
+func (NamespaceAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
+
+	kind := "Namespace"
+	apiDoc := kubernetes.K8sApiReference{
+		Kind: kind,
+		ApiVersion: schema.GroupVersion{
+			Group:   "",
+			Version: "v1",
+		},
+		OpenapiSchema: a.OpenapiSchema,
+	}
+
+	AnalyzerErrorsMetric.DeletePartialMatch(map[string]string{
+		"analyzer_name": kind,
+	})
+
+	config := getNamespaceConfig()
+
+	var namespaces []v1.Namespace
+	if a.Namespace != "" {
+		namespace, err := a.Client.GetClient().CoreV1().Namespaces().Get(a.Context, a.Namespace, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		namespaces = append(namespaces, *namespace)
+	} else {
+		list, err := a.Client.GetClient().CoreV1().Namespaces().List(a.Context, metav1.ListOptions{})
+		if err != nil {
+			return nil, err
+		}
+		namespaces = list.Items
+	}
+	apiServices, err := unavailableAPIServices(a)
+	if err != nil {
+		return nil, err
+	}
+
+	report := func(resourceKind string, name string, failures []common.Failure) {
+		if len(failures) == 0 {
+			return
+		}
+		AnalyzerErrorsMetric.WithLabelValues(kind, name, "").Set(float64(len(failures)))
+		a.Results = append(a.Results, common.Result{
+			ResourceName: name,
+			Kind:         resourceKind,
+			Name:         name,
+			Error:        failures,
+			ParentObject: fmt.Sprintf("%s/%s", resourceKind, name),
+		})
+	}
+
+	// the APIServices are cluster scoped, they are reported in a cluster wide analysis only
+	if a.Namespace == "" {
+		for _, service := range apiServices {
+			text := fmt.Sprintf("APIService %s is unavailable (%s): %s. The discovery of its API fails, which blocks the deletion of namespaces and the garbage collection.",
+				service.Name, service.Reason, service.Message)
+			if service.Service != "" {
+				text += fmt.Sprintf(" Check the pods behind the Service %s.", service.Service)
+			}
+			report("APIService", service.Name, []common.Failure{{
+				Text:      text,
+				Sensitive: []common.Sensitive{},
+			}})
+		}
+	}
+
+	for _, namespace := range namespaces {
+		var failures []common.Failure
+		fail := func(path string, text string) {
+			failures = append(failures, common.Failure{
+				Text:          text,
+				KubernetesDoc: apiDoc.GetApiDocV2(path),
+				Sensitive:     sensitiveValues(namespace.Name),
+			})
+		}
+
+		if namespace.Status.Phase == v1.NamespaceTerminating && namespace.DeletionTimestamp != nil && config.terminatingTimeout > 0 {
+			if terminating := time.Since(namespace.DeletionTimestamp.Time); terminating > config.terminatingTimeout {
+				text := fmt.Sprintf("Namespace %s has been terminating for %s.", namespace.Name, terminating.Round(time.Second))
+				if len(namespace.Spec.Finalizers) > 0 {
+					var finalizers []string
+					for _, finalizer := range namespace.Spec.Finalizers {
+						finalizers = append(finalizers, string(finalizer))
+					}
+					text += fmt.Sprintf(" The finalizers [%s] of the namespace are pending.", strings.Join(finalizers, ", "))
+				}
+				if len(namespace.Finalizers) > 0 {
+					text += fmt.Sprintf(" The finalizers [%s] of the namespace object are pending.", strings.Join(namespace.Finalizers, ", "))
+				}
+				fail("spec.finalizers", text)
+
+				for _, condition := range namespace.Status.Conditions {
+					hint, ok := namespaceDeletionConditions[condition.Type]
+					if !ok || condition.Status != v1.ConditionTrue {
+						continue
+					}
+					text := fmt.Sprintf("Namespace %s deletion is blocked, %s: %s", namespace.Name, hint, condition.Message)
+					if condition.Type == v1.NamespaceDeletionDiscoveryFailure && len(apiServices) > 0 {
+						var names []string
+						for _, service := range apiServices {
+							names = append(names, service.Name)
+						}
+						text += fmt.Sprintf(". The APIServices [%s] are unavailable.", strings.Join(names, ", "))
+					}
+					fail("status.conditions", text)
+				}
+			}
+		}
+
+		if !isSystemNamespace(namespace.Name) && namespace.Status.Phase != v1.NamespaceTerminating {
+			var missing []string
+			for _, label := range config.RequiredLabels {
+				if _, ok := namespace.Labels[label]; !ok {
+					missing = append(missing, label)
+				}
+			}
+			if len(missing) > 0 {
+				fail("metadata.labels", fmt.Sprintf("Namespace %s is missing the labels [%s] required by the namespace policy.", namespace.Name, strings.Join(missing, ", ")))
+			}
+		}
+
+		report(kind, namespace.Name, failures)
+	}
+
+	return a.Results, nil
+}
+
+// unavailableAPIServices lists the APIServices whose Available condition is not True, none are returned when
+// the cluster has no APIService API or the APIServices may not be listed
+func unavailableAPIServices(a common.Analyzer) ([]apiService, error) {
+	if a.Client.GetCtrlClient() == nil {
+		return nil, nil
+	}
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(apiServiceGroupVersion.WithKind("APIServiceList"))
+	if err := a.Client.GetCtrlClient().List(a.Context, list); err != nil {
+		if meta.IsNoMatchError(err) || k8serrors.IsForbidden(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var services []apiService
+	for _, item := range list.Items {
+		conditions, _, _ := unstructured.NestedSlice(item.Object, "status", "conditions")
+		for _, c := range conditions {
+			fields, ok := c.(map[string]interface{})
+			if !ok || fields["type"] != "Available" || fields["status"] == string(metav1.ConditionTrue) {
+				continue
+			}
+			service := apiService{Name: item.GetName()}
+			service.Reason, _, _ = unstructured.NestedString(fields, "reason")
+			service.Message, _, _ = unstructured.NestedString(fields, "message")
+			namespace, _, _ := unstructured.NestedString(item.Object, "spec", "service", "namespace")
+			name, _, _ := unstructured.NestedString(item.Object, "spec", "service", "name")
+			if name != "" {
+				service.Service = namespace + "/" + name
+			}
+			services = append(services, service)
+		}
+	}
+	sort.Slice(services, func(i, j int) bool { return services[i].Name < services[j].Name })
+	return services, nil
+}
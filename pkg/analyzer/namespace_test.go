@@ -0,0 +1,160 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyzer
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
+	"github.com/magiconair/properties/assert"
+	"github.com/spf13/viper"
+	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrl "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func TestNamespaceAnalyzer(t *testing.T) {
+	viper.Set("analyzers.namespace.requiredlabels", []string{"team"})
+	defer viper.Set("analyzers.namespace", nil)
+
+	deleted := metav1.NewTime(time.Now().Add(-time.Hour))
+	stuck := &v1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "old", DeletionTimestamp: &deleted},
+		Spec:       v1.NamespaceSpec{Finalizers: []v1.FinalizerName{v1.FinalizerKubernetes}},
+		Status: v1.NamespaceStatus{
+			Phase: v1.NamespaceTerminating,
+			Conditions: []v1.NamespaceCondition{
+				{
+					Type:    v1.NamespaceDeletionDiscoveryFailure,
+					Status:  v1.ConditionTrue,
+					Message: "Discovery failed for some groups, 1 failing: unable to retrieve the complete list of server APIs: metrics.k8s.io/v1beta1: the server is currently unable to handle the request",
+				},
+				{
+					Type:    v1.NamespaceFinalizersRemaining,
+					Status:  v1.ConditionTrue,
+					Message: "Some content in the namespace has finalizers remaining: example.com/cleanup in 1 resource instances",
+				},
+				{
+					Type:   v1.NamespaceContentRemaining,
+					Status: v1.ConditionFalse,
+				},
+			},
+		},
+	}
+	recent := metav1.NewTime(time.Now())
+	deleting := &v1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "deleting", DeletionTimestamp: &recent},
+		Status:     v1.NamespaceStatus{Phase: v1.NamespaceTerminating},
+	}
+	unlabeled := &v1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "test"},
+		Status:     v1.NamespaceStatus{Phase: v1.NamespaceActive},
+	}
+	labeled := &v1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Labels: map[string]string{"team": "web"}},
+		Status:     v1.NamespaceStatus{Phase: v1.NamespaceActive},
+	}
+	system := &v1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "kube-system"},
+		Status:     v1.NamespaceStatus{Phase: v1.NamespaceActive},
+	}
+
+	apiService := func(name string, status string) *unstructured.Unstructured {
+		service := &unstructured.Unstructured{Object: map[string]interface{}{
+			"spec": map[string]interface{}{
+				"service": map[string]interface{}{"namespace": "kube-system", "name": "metrics-server"},
+			},
+			"status": map[string]interface{}{
+				"conditions": []interface{}{map[string]interface{}{
+					"type":    "Available",
+					"status":  status,
+					"reason":  "FailedDiscoveryCheck",
+					"message": "failing or missing response from https://10.96.0.10:443",
+				}},
+			},
+		}}
+		service.SetGroupVersionKind(apiServiceGroupVersion.WithKind("APIService"))
+		service.SetName(name)
+		return service
+	}
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(apiServiceGroupVersion.WithKind("APIService"), meta.RESTScopeRoot)
+	scheme, err := kubernetes.NewScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctrlClient := fakectrl.NewClientBuilder().WithScheme(scheme).WithRESTMapper(mapper).
+		WithObjects(apiService("v1beta1.metrics.k8s.io", "False"), apiService("v1.example.com", "True")).Build()
+
+	analyzer := NamespaceAnalyzer{}
+	results, err := analyzer.Analyze(common.Analyzer{
+		Client: &kubernetes.Client{
+			Client:     fake.NewSimpleClientset(stuck, deleting, unlabeled, labeled, system),
+			CtrlClient: ctrlClient,
+		},
+		Context: context.Background(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+
+	var names []string
+	for _, result := range results {
+		names = append(names, result.Kind+" "+result.Name)
+	}
+	assert.Equal(t, names, []string{"Namespace old", "Namespace test", "APIService v1beta1.metrics.k8s.io"})
+
+	assert.Equal(t, results[0].Error[0].Text, "Namespace old has been terminating for 1h0m0s. The finalizers [kubernetes] of the namespace are pending.")
+	assert.Equal(t, results[0].Error[1].Text, "Namespace old deletion is blocked, the discovery of some APIs fails, usually because an APIService is unavailable: "+
+		stuck.Status.Conditions[0].Message+". The APIServices [v1beta1.metrics.k8s.io] are unavailable.")
+	assert.Equal(t, results[0].Error[2].Text, "Namespace old deletion is blocked, the controllers owning these finalizers have not removed them, check that they are running: "+
+		stuck.Status.Conditions[1].Message)
+	assert.Equal(t, len(results[0].Error), 3)
+	assert.Equal(t, results[1].Error[0].Text, "Namespace test is missing the labels [team] required by the namespace policy.")
+	assert.Equal(t, results[2].Error[0].Text, "APIService v1beta1.metrics.k8s.io is unavailable (FailedDiscoveryCheck): failing or missing response from https://10.96.0.10:443. "+
+		"The discovery of its API fails, which blocks the deletion of namespaces and the garbage collection. Check the pods behind the Service kube-system/metrics-server.")
+}
+
+func TestUnavailableAPIServicesForbidden(t *testing.T) {
+	scheme, err := kubernetes.NewScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctrlClient := fakectrl.NewClientBuilder().WithScheme(scheme).WithInterceptorFuncs(interceptor.Funcs{
+		List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+			return k8serrors.NewForbidden(schema.GroupResource{Group: apiServiceGroupVersion.Group, Resource: "apiservices"}, "", nil)
+		},
+	}).Build()
+
+	services, err := unavailableAPIServices(common.Analyzer{
+		Client:  &kubernetes.Client{CtrlClient: ctrlClient},
+		Context: context.Background(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, len(services), 0)
+}
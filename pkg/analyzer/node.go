@@ -15,31 +15,101 @@ package analyzer
 
 import (
 	"fmt"
+	"strings"
+	"time"
 
 	v1 "k8s.io/api/core/v1"
 
 	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
 	"github.com/k8sgpt-ai/k8sgpt/pkg/util"
+	"github.com/spf13/viper"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilversion "k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/apimachinery/pkg/version"
 )
 
 type NodeAnalyzer struct{}
 
-func (NodeAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
+// NodeConfig is read from the analyzers.node key of the config file
+type NodeConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// NotReadyThreshold is how long a node can stay NotReady before it is reported
+	NotReadyThreshold string `mapstructure:"notreadythreshold"`
+	// PendingThreshold is how long a pod can stay unschedulable before the cordoned nodes are reported
+	PendingThreshold string `mapstructure:"pendingthreshold"`
+	// MaxVersionSkew is the number of minor versions a kubelet may be behind the control plane,
+	// 0 follows the Kubernetes version skew policy
+	MaxVersionSkew int `mapstructure:"maxversionskew"`
+
+	notReadyThreshold time.Duration
+	pendingThreshold  time.Duration
+}
+
+func getNodeConfig() NodeConfig {
+	config := NodeConfig{
+		Enabled:           true,
+		NotReadyThreshold: "5m",
+		PendingThreshold:  "10m",
+	}
+	_ = viper.UnmarshalKey("analyzers.node", &config)
+	config.notReadyThreshold = configDuration("analyzers.node.notreadythreshold", config.NotReadyThreshold)
+	config.pendingThreshold = configDuration("analyzers.node.pendingthreshold", config.PendingThreshold)
+	return config
+}
 
-	return nil, nil
+func (NodeAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
 
 	kind := "Node"
+	apiDoc := kubernetes.K8sApiReference{
+		Kind: kind,
+		ApiVersion: schema.GroupVersion{
+			Group:   "",
+			Version: "v1",
+		},
+		OpenapiSchema: a.OpenapiSchema,
+	}
 
 	AnalyzerErrorsMetric.DeletePartialMatch(map[string]string{
 		"analyzer_name": kind,
 	})
 
+	config := getNodeConfig()
+	if !config.Enabled {
+		return nil, nil
+	}
+
 	list, err := a.Client.GetClient().CoreV1().Nodes().List(a.Context, metav1.ListOptions{})
 	if err != nil {
 		return nil, err
 	}
 
+	// the pods of every namespace are needed to compute the node usage, whatever namespace is analyzed
+	pods, err := a.Client.GetClient().CoreV1().Pods("").List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	podsByNode := map[string][]v1.Pod{}
+	var longPending []v1.Pod
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName != "" {
+			podsByNode[pod.Spec.NodeName] = append(podsByNode[pod.Spec.NodeName], pod)
+		}
+		// an unset threshold disables the report of cordoned nodes
+		if config.pendingThreshold > 0 && isUnschedulableSince(pod, config.pendingThreshold) {
+			longPending = append(longPending, pod)
+		}
+	}
+	// the volumes and namespaces are only read when a pod is pending for long, the volume zones and the
+	// namespace selectors of its affinity decide whether a cordoned node would accept it
+	cluster := newSchedulingCluster(list.Items, pods.Items)
+	if len(longPending) > 0 {
+		if err := cluster.loadVolumesAndNamespaces(a); err != nil {
+			return nil, err
+		}
+	}
+
 	var preAnalysis = map[string]common.PreAnalysis{}
 
 	for _, node := range list.Items {
@@ -51,6 +121,10 @@ func (NodeAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
 				if nodeCondition.Status == v1.ConditionTrue {
 					break
 				}
+				// a node flapping for a few seconds is not worth a report
+				if !nodeCondition.LastTransitionTime.IsZero() && time.Since(nodeCondition.LastTransitionTime.Time) < config.notReadyThreshold {
+					break
+				}
 				failures = addNodeConditionFailure(failures, node.Name, nodeCondition)
 			default:
 				if nodeCondition.Status != v1.ConditionFalse {
@@ -59,6 +133,98 @@ func (NodeAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
 			}
 		}
 
+		// only the pods that the node would accept once uncordoned are counted
+		blocked := 0
+		if node.Spec.Unschedulable {
+			for _, pod := range longPending {
+				if cluster.onlyCordoned(pod, node) {
+					blocked++
+				}
+			}
+		}
+		if blocked > 0 {
+			doc := apiDoc.GetApiDocV2("spec.unschedulable")
+
+			failures = append(failures, common.Failure{
+				Text:          fmt.Sprintf("%s is cordoned while %d pods that would fit on it have been unschedulable for more than %s.", node.Name, blocked, config.pendingThreshold),
+				KubernetesDoc: doc,
+				Sensitive: []common.Sensitive{
+					{
+						Unmasked: node.Name,
+						Masked:   util.MaskString(node.Name),
+					},
+				},
+			})
+		}
+
+		requests := v1.ResourceList{}
+		for _, pod := range podsByNode[node.Name] {
+			if pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+				continue
+			}
+			for name, quantity := range podRequests(pod) {
+				total := requests[name]
+				total.Add(quantity)
+				requests[name] = total
+			}
+		}
+		for _, name := range []v1.ResourceName{v1.ResourceCPU, v1.ResourceMemory} {
+			allocatable, ok := node.Status.Allocatable[name]
+			requested := requests[name]
+			if !ok || requested.Cmp(allocatable) <= 0 {
+				continue
+			}
+			doc := apiDoc.GetApiDocV2("status.allocatable")
+
+			failures = append(failures, common.Failure{
+				Text:          fmt.Sprintf("%s is overcommitted: its pods request %s of %s but only %s is allocatable.", node.Name, requested.String(), name, allocatable.String()),
+				KubernetesDoc: doc,
+				Sensitive: []common.Sensitive{
+					{
+						Unmasked: node.Name,
+						Masked:   util.MaskString(node.Name),
+					},
+				},
+			})
+		}
+
+		for _, taint := range node.Spec.Taints {
+			// the node lifecycle taints mirror the conditions and the cordon that are already reported
+			if taint.Effect == v1.TaintEffectPreferNoSchedule || strings.HasPrefix(taint.Key, "node.kubernetes.io/") {
+				continue
+			}
+			if isTaintTolerated(taint, pods.Items) {
+				continue
+			}
+			doc := apiDoc.GetApiDocV2("spec.taints")
+
+			failures = append(failures, common.Failure{
+				Text:          fmt.Sprintf("%s has the taint %s that no pod tolerates, no workload can run on it.", node.Name, taint.ToString()),
+				KubernetesDoc: doc,
+				Sensitive: []common.Sensitive{
+					{
+						Unmasked: node.Name,
+						Masked:   util.MaskString(node.Name),
+					},
+				},
+			})
+		}
+
+		if skew, ok := kubeletVersionSkew(a.Client.ServerVersion, node.Status.NodeInfo.KubeletVersion, config.MaxVersionSkew); !ok {
+			doc := apiDoc.GetApiDocV2("status.nodeInfo.kubeletVersion")
+
+			failures = append(failures, common.Failure{
+				Text:          fmt.Sprintf("%s runs kubelet %s which is %s the control plane %s.", node.Name, node.Status.NodeInfo.KubeletVersion, skew, a.Client.ServerVersion.GitVersion),
+				KubernetesDoc: doc,
+				Sensitive: []common.Sensitive{
+					{
+						Unmasked: node.Name,
+						Masked:   util.MaskString(node.Name),
+					},
+				},
+			})
+		}
+
 		if len(failures) > 0 {
 			preAnalysis[node.Name] = common.PreAnalysis{
 				Namespace:      "",
@@ -100,3 +266,87 @@ func addNodeConditionFailure(failures []common.Failure, nodeName string, nodeCon
 	})
 	return failures
 }
+
+// isUnschedulableSince reports whether the scheduler has been failing to place the pod for longer than threshold
+func isUnschedulableSince(pod v1.Pod, threshold time.Duration) bool {
+	if pod.Status.Phase != v1.PodPending {
+		return false
+	}
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodScheduled && condition.Status == v1.ConditionFalse && condition.Reason == v1.PodReasonUnschedulable {
+			return time.Since(condition.LastTransitionTime.Time) > threshold
+		}
+	}
+	return false
+}
+
+// podRequests returns the resources reserved by the scheduler for a pod: the sum of the containers,
+// or the largest init container when it is bigger, plus the pod overhead
+func podRequests(pod v1.Pod) v1.ResourceList {
+	requests := v1.ResourceList{}
+	for _, container := range pod.Spec.Containers {
+		for name, quantity := range container.Resources.Requests {
+			total := requests[name]
+			total.Add(quantity)
+			requests[name] = total
+		}
+	}
+	for _, container := range pod.Spec.InitContainers {
+		for name, quantity := range container.Resources.Requests {
+			if current, ok := requests[name]; !ok || quantity.Cmp(current) > 0 {
+				requests[name] = quantity.DeepCopy()
+			}
+		}
+	}
+	for name, quantity := range pod.Spec.Overhead {
+		total := requests[name]
+		total.Add(quantity)
+		requests[name] = total
+	}
+	return requests
+}
+
+func isTaintTolerated(taint v1.Taint, pods []v1.Pod) bool {
+	for _, pod := range pods {
+		for _, toleration := range pod.Spec.Tolerations {
+			if toleration.ToleratesTaint(&taint) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// kubeletVersionSkew checks the kubelet against the version skew policy: it must not be newer than the
+// control plane, nor older than maxSkew minor versions. It returns how the versions differ when they do not fit.
+func kubeletVersionSkew(server *version.Info, kubeletVersion string, maxSkew int) (string, bool) {
+	if server == nil || kubeletVersion == "" {
+		return "", true
+	}
+	serverVersion, err := utilversion.ParseGeneric(server.GitVersion)
+	if err != nil {
+		return "", true
+	}
+	kubelet, err := utilversion.ParseGeneric(kubeletVersion)
+	if err != nil {
+		return "", true
+	}
+	if maxSkew == 0 {
+		// the supported skew went from two to three minor versions in 1.28
+		maxSkew = 2
+		if serverVersion.AtLeast(utilversion.MajorMinor(1, 28)) {
+			maxSkew = 3
+		}
+	}
+	if kubelet.Major() != serverVersion.Major() {
+		return "a different major version than", false
+	}
+	minorDiff := int(serverVersion.Minor()) - int(kubelet.Minor())
+	if minorDiff < 0 {
+		return "newer than", false
+	}
+	if minorDiff > maxSkew {
+		return fmt.Sprintf("%d minor versions behind", minorDiff), false
+	}
+	return "", true
+}
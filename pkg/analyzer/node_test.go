@@ -15,13 +15,18 @@ package analyzer
 
 import (
 	"context"
+	"sort"
 	"testing"
+	"time"
 
 	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
 	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
 	"github.com/magiconair/properties/assert"
+	"github.com/spf13/viper"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/version"
 	"k8s.io/client-go/kubernetes/fake"
 )
 
@@ -122,3 +127,215 @@ func TestNodeAnalyzerNodeUnknownType(t *testing.T) {
 	}
 	assert.Equal(t, len(analysisResults), 1)
 }
+
+func TestNodeAnalyzerNotReadyThreshold(t *testing.T) {
+	clientset := fake.NewSimpleClientset(
+		&v1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "flapping",
+			},
+			Status: v1.NodeStatus{
+				Conditions: []v1.NodeCondition{
+					{
+						Type:               v1.NodeReady,
+						Status:             v1.ConditionFalse,
+						Reason:             "KubeletNotReady",
+						LastTransitionTime: metav1.NewTime(time.Now().Add(-time.Minute)),
+					},
+				},
+			},
+		},
+		&v1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "down",
+			},
+			Status: v1.NodeStatus{
+				Conditions: []v1.NodeCondition{
+					{
+						Type:               v1.NodeReady,
+						Status:             v1.ConditionUnknown,
+						Reason:             "NodeStatusUnknown",
+						LastTransitionTime: metav1.NewTime(time.Now().Add(-time.Hour)),
+					},
+				},
+			},
+		})
+
+	config := common.Analyzer{
+		Client: &kubernetes.Client{
+			Client: clientset,
+		},
+		Context: context.Background(),
+	}
+	analysisResults, err := NodeAnalyzer{}.Analyze(config)
+	if err != nil {
+		t.Error(err)
+	}
+	assert.Equal(t, len(analysisResults), 1)
+	assert.Equal(t, analysisResults[0].Name, "down")
+}
+
+func TestNodeAnalyzerCapacity(t *testing.T) {
+	clientset := fake.NewSimpleClientset(
+		&v1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "node1",
+			},
+			Spec: v1.NodeSpec{
+				Unschedulable: true,
+				Taints: []v1.Taint{
+					{Key: "dedicated", Value: "gpu", Effect: v1.TaintEffectNoSchedule},
+					{Key: "node.kubernetes.io/unschedulable", Effect: v1.TaintEffectNoSchedule},
+				},
+			},
+			Status: v1.NodeStatus{
+				Allocatable: v1.ResourceList{
+					v1.ResourceCPU:    resource.MustParse("1"),
+					v1.ResourceMemory: resource.MustParse("1Gi"),
+				},
+				NodeInfo: v1.NodeSystemInfo{
+					KubeletVersion: "v1.24.3",
+				},
+			},
+		},
+		&v1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "node2",
+			},
+			Spec: v1.NodeSpec{
+				Unschedulable: true,
+				Taints: []v1.Taint{
+					{Key: "node.kubernetes.io/unschedulable", Effect: v1.TaintEffectNoSchedule},
+				},
+			},
+			Status: v1.NodeStatus{
+				NodeInfo: v1.NodeSystemInfo{
+					KubeletVersion: "v1.28.2",
+				},
+			},
+		},
+		&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "running",
+				Namespace: "test",
+			},
+			Spec: v1.PodSpec{
+				NodeName: "node1",
+				Containers: []v1.Container{
+					{
+						Name: "app",
+						Resources: v1.ResourceRequirements{
+							Requests: v1.ResourceList{
+								v1.ResourceCPU:    resource.MustParse("1500m"),
+								v1.ResourceMemory: resource.MustParse("512Mi"),
+							},
+						},
+					},
+				},
+			},
+			Status: v1.PodStatus{
+				Phase: v1.PodRunning,
+			},
+		},
+		&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "pending",
+				Namespace: "test",
+			},
+			Status: v1.PodStatus{
+				Phase: v1.PodPending,
+				Conditions: []v1.PodCondition{
+					{
+						Type:               v1.PodScheduled,
+						Status:             v1.ConditionFalse,
+						Reason:             v1.PodReasonUnschedulable,
+						LastTransitionTime: metav1.NewTime(time.Now().Add(-time.Hour)),
+					},
+				},
+			},
+		},
+		&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "pending-data",
+				Namespace: "test",
+			},
+			Spec: v1.PodSpec{
+				Volumes: []v1.Volume{{Name: "data", VolumeSource: v1.VolumeSource{
+					PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: "data"},
+				}}},
+			},
+			Status: v1.PodStatus{
+				Phase: v1.PodPending,
+				Conditions: []v1.PodCondition{
+					{
+						Type:               v1.PodScheduled,
+						Status:             v1.ConditionFalse,
+						Reason:             v1.PodReasonUnschedulable,
+						LastTransitionTime: metav1.NewTime(time.Now().Add(-time.Hour)),
+					},
+				},
+			},
+		},
+		&v1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: "test"},
+			Spec:       v1.PersistentVolumeClaimSpec{VolumeName: "pv-data"},
+		},
+		&v1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: "pv-data"},
+			Spec: v1.PersistentVolumeSpec{NodeAffinity: &v1.VolumeNodeAffinity{Required: &v1.NodeSelector{
+				NodeSelectorTerms: []v1.NodeSelectorTerm{{MatchExpressions: []v1.NodeSelectorRequirement{{
+					Key: "topology.kubernetes.io/zone", Operator: v1.NodeSelectorOpIn, Values: []string{"b"},
+				}}}},
+			}}},
+		})
+
+	config := common.Analyzer{
+		Client: &kubernetes.Client{
+			Client:        clientset,
+			ServerVersion: &version.Info{GitVersion: "v1.28.2"},
+		},
+		Context: context.Background(),
+	}
+	analysisResults, err := NodeAnalyzer{}.Analyze(config)
+	if err != nil {
+		t.Error(err)
+	}
+	assert.Equal(t, len(analysisResults), 2)
+	sort.Slice(analysisResults, func(i, j int) bool { return analysisResults[i].Name < analysisResults[j].Name })
+	// the pending pod does not tolerate the taint of node1, its cordon is not reported, and the volume of
+	// pending-data is not reachable from node2
+	// cpu overcommitted, untolerated taint and version skew
+	assert.Equal(t, len(analysisResults[0].Error), 3)
+	assert.Equal(t, analysisResults[1].Error[0].Text, "node2 is cordoned while 1 pods that would fit on it have been unschedulable for more than 10m0s.")
+}
+
+func TestNodeAnalyzerDisabled(t *testing.T) {
+	viper.Set("analyzers.node.enabled", false)
+	defer viper.Set("analyzers.node.enabled", true)
+
+	clientset := fake.NewSimpleClientset(&v1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "node1",
+		},
+		Status: v1.NodeStatus{
+			Conditions: []v1.NodeCondition{
+				{
+					Type:   v1.NodeDiskPressure,
+					Status: v1.ConditionTrue,
+				},
+			},
+		},
+	})
+
+	config := common.Analyzer{
+		Client: &kubernetes.Client{
+			Client: clientset,
+		},
+		Context: context.Background(),
+	}
+	analysisResults, err := NodeAnalyzer{}.Analyze(config)
+	if err != nil {
+		t.Error(err)
+	}
+	assert.Equal(t, len(analysisResults), 0)
+}
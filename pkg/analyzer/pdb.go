@@ -14,17 +14,34 @@ limitations under the License.
 package analyzer
 
 import (
+	"context"
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
 	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
 	"github.com/k8sgpt-ai/k8sgpt/pkg/util"
+	v1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	k8s "k8s.io/client-go/kubernetes"
 )
 
 type PdbAnalyzer struct{}
 
+// DrainBlocker is a pod preventing a node from being drained
+type DrainBlocker struct {
+	// Pod is the namespace/name of the pod
+	Pod string
+	// PodDisruptionBudgets are the namespace/name of the budgets refusing the eviction, if any
+	PodDisruptionBudgets []string
+	Reason               string
+}
+
 func (PdbAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
 
 	kind := "PodDisruptionBudget"
@@ -45,6 +62,10 @@ func (PdbAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
 	if err != nil {
 		return nil, err
 	}
+	pods, err := a.Client.GetClient().CoreV1().Pods(a.Namespace).List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
 
 	var preAnalysis = map[string]common.PreAnalysis{}
 
@@ -53,29 +74,70 @@ func (PdbAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
 			continue
 		}
 		var failures []common.Failure
-		if pdb.Status.Conditions[0].Type == "DisruptionAllowed" && pdb.Status.Conditions[0].Status == "False" {
-			var doc string
-			if pdb.Spec.MaxUnavailable != nil {
-				doc = apiDoc.GetApiDocV2("spec.maxUnavailable")
+		report := func(path string, format string, args ...interface{}) {
+			failures = append(failures, common.Failure{
+				Text:          fmt.Sprintf("PodDisruptionBudget %s ", pdb.Name) + fmt.Sprintf(format, args...),
+				KubernetesDoc: apiDoc.GetApiDocV2(path),
+				Sensitive:     sensitiveValues(pdb.Name),
+			})
+		}
+
+		var selected []v1.Pod
+		nodes := map[string]bool{}
+		for _, pod := range pods.Items {
+			if pdbSelects(pdb, pod) {
+				selected = append(selected, pod)
+				if pod.Spec.NodeName != "" {
+					nodes[pod.Spec.NodeName] = true
+				}
 			}
-			if pdb.Spec.MinAvailable != nil {
-				doc = apiDoc.GetApiDocV2("spec.minAvailable")
+		}
+
+		if len(selected) == 0 {
+			report("spec.selector", "selects no pod, its selector %s matches nothing in the namespace %s.", selectorString(pdb.Spec.Selector), pdb.Namespace)
+		}
+
+		// a budget that can never be met is reported rather than its current state
+		expected := pdb.Status.ExpectedPods
+		if expected == 0 {
+			expected = int32(len(selected))
+		}
+		structural := false
+		if pdb.Spec.MinAvailable != nil && expected > 0 {
+			if minAvailable, err := intstr.GetScaledValueFromIntOrPercent(pdb.Spec.MinAvailable, int(expected), true); err == nil && int32(minAvailable) >= expected {
+				structural = true
+				report("spec.minAvailable", "requires %s available pods out of %d, no pod can ever be evicted and the drain of their nodes is blocked.",
+					pdb.Spec.MinAvailable.String(), expected)
 			}
-			for k, v := range pdb.Spec.Selector.MatchLabels {
-				failures = append(failures, common.Failure{
-					Text:          fmt.Sprintf("%s, expected pdb pod label %s=%s", pdb.Status.Conditions[0].Reason, k, v),
-					KubernetesDoc: doc,
-					Sensitive: []common.Sensitive{
-						{
-							Unmasked: k,
-							Masked:   util.MaskString(k),
-						},
-						{
-							Unmasked: v,
-							Masked:   util.MaskString(v),
-						},
-					},
-				})
+		}
+		if maxUnavailable := pdb.Spec.MaxUnavailable; maxUnavailable != nil && (maxUnavailable.String() == "0" || maxUnavailable.String() == "0%") {
+			structural = true
+			report("spec.maxUnavailable", "has a maxUnavailable of %s, no pod can ever be evicted and the drain of their nodes is blocked.", maxUnavailable.String())
+		}
+		if !structural && len(selected) > 0 && pdb.Status.DisruptionsAllowed == 0 {
+			reason := ""
+			for _, condition := range pdb.Status.Conditions {
+				if condition.Type == policyv1.DisruptionAllowedCondition && condition.Status == metav1.ConditionFalse {
+					reason = fmt.Sprintf(" (%s)", condition.Reason)
+				}
+			}
+			report("status.disruptionsAllowed", "allows no disruption%s with %d/%d healthy pods for %d desired, it blocks the drain of the nodes %s.",
+				reason, pdb.Status.CurrentHealthy, expected, pdb.Status.DesiredHealthy, sortedKeys(nodes))
+		}
+
+		for _, other := range list.Items {
+			if other.Namespace != pdb.Namespace || other.Name == pdb.Name {
+				continue
+			}
+			var shared []string
+			for _, pod := range selected {
+				if pdbSelects(other, pod) {
+					shared = append(shared, pod.Name)
+				}
+			}
+			if len(shared) > 0 {
+				report("spec.selector", "overlaps the PodDisruptionBudget %s on the pods %s, the eviction API refuses the pods covered by more than one budget.",
+					other.Name, strings.Join(shared, ", "))
 			}
 		}
 
@@ -106,3 +168,116 @@ func (PdbAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
 
 	return a.Results, err
 }
+
+// DrainBlockers lists the pods of a node that a drain cannot evict, with the budgets refusing them. The
+// DaemonSet and static pods are left out, a drain ignores them
+func DrainBlockers(ctx context.Context, client k8s.Interface, nodeName string) ([]DrainBlocker, error) {
+	if _, err := client.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{}); err != nil {
+		return nil, err
+	}
+	pods, err := client.CoreV1().Pods("").List(ctx, metav1.ListOptions{FieldSelector: "spec.nodeName=" + nodeName})
+	if err != nil {
+		return nil, err
+	}
+	pdbs, err := client.PolicyV1().PodDisruptionBudgets("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	var evicted []v1.Pod
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName != nodeName || pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+			continue
+		}
+		if _, mirror := pod.Annotations[v1.MirrorPodAnnotationKey]; mirror {
+			continue
+		}
+		controller := metav1.GetControllerOf(&pod)
+		if controller != nil && controller.Kind == "DaemonSet" {
+			continue
+		}
+		evicted = append(evicted, pod)
+	}
+
+	// the pods of a budget on the node are evicted one after the other, each one uses a disruption
+	onNode := map[string]int32{}
+	for _, pod := range evicted {
+		for _, pdb := range pdbs.Items {
+			if pdbSelects(pdb, pod) {
+				onNode[pdb.Namespace+"/"+pdb.Name]++
+			}
+		}
+	}
+
+	var blockers []DrainBlocker
+	for _, pod := range evicted {
+		key := pod.Namespace + "/" + pod.Name
+		if metav1.GetControllerOf(&pod) == nil {
+			blockers = append(blockers, DrainBlocker{Pod: key, Reason: "it is not managed by a controller, kubectl drain refuses to delete it without --force and it would not be recreated"})
+		}
+		for _, volume := range pod.Spec.Volumes {
+			if volume.EmptyDir != nil {
+				blockers = append(blockers, DrainBlocker{Pod: key, Reason: fmt.Sprintf("it uses the emptyDir volume %s, kubectl drain needs --delete-emptydir-data and its data is lost", volume.Name)})
+				break
+			}
+		}
+
+		var covering []policyv1.PodDisruptionBudget
+		for _, pdb := range pdbs.Items {
+			if pdbSelects(pdb, pod) {
+				covering = append(covering, pdb)
+			}
+		}
+		switch {
+		case len(covering) > 1:
+			var names []string
+			for _, pdb := range covering {
+				names = append(names, pdb.Namespace+"/"+pdb.Name)
+			}
+			blockers = append(blockers, DrainBlocker{Pod: key, PodDisruptionBudgets: names, Reason: "it is covered by more than one PodDisruptionBudget, the eviction API refuses it"})
+		case len(covering) == 1:
+			pdb := covering[0]
+			name := pdb.Namespace + "/" + pdb.Name
+			if pdb.Status.DisruptionsAllowed == 0 {
+				blockers = append(blockers, DrainBlocker{Pod: key, PodDisruptionBudgets: []string{name}, Reason: fmt.Sprintf(
+					"the PodDisruptionBudget allows no disruption with %d/%d healthy pods for %d desired", pdb.Status.CurrentHealthy, pdb.Status.ExpectedPods, pdb.Status.DesiredHealthy)})
+			} else if pdb.Status.DisruptionsAllowed < onNode[name] {
+				blockers = append(blockers, DrainBlocker{Pod: key, PodDisruptionBudgets: []string{name}, Reason: fmt.Sprintf(
+					"the PodDisruptionBudget allows %d disruptions for %d of its pods on the node, the drain waits for their replacements to be healthy", pdb.Status.DisruptionsAllowed, onNode[name])})
+			}
+		}
+	}
+	return blockers, nil
+}
+
+// pdbSelects tells whether a PodDisruptionBudget covers a pod, a budget without selector covers nothing
+func pdbSelects(pdb policyv1.PodDisruptionBudget, pod v1.Pod) bool {
+	if pdb.Namespace != pod.Namespace || pdb.Spec.Selector == nil {
+		return false
+	}
+	selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+	if err != nil {
+		return false
+	}
+	return selector.Matches(labels.Set(pod.Labels))
+}
+
+func selectorString(selector *metav1.LabelSelector) string {
+	if selector == nil {
+		return "<none>"
+	}
+	s, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return "<invalid>"
+	}
+	return "[" + s.String() + "]"
+}
+
+func sortedKeys(set map[string]bool) string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, ", ")
+}
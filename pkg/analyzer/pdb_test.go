@@ -0,0 +1,168 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyzer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
+	"github.com/magiconair/properties/assert"
+	v1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func pdbTestObjects() []runtime.Object {
+	controller := true
+	pod := func(name string, node string, labels map[string]string, owner string) *v1.Pod {
+		pod := &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test", Labels: labels},
+			Spec:       v1.PodSpec{NodeName: node},
+			Status:     v1.PodStatus{Phase: v1.PodRunning},
+		}
+		if owner != "" {
+			pod.OwnerReferences = []metav1.OwnerReference{{Kind: owner, Name: name, Controller: &controller}}
+		}
+		return pod
+	}
+	two := intstr.FromInt32(2)
+	one := intstr.FromInt32(1)
+	debug := pod("debug", "node1", nil, "")
+	debug.Spec.Volumes = []v1.Volume{{Name: "scratch", VolumeSource: v1.VolumeSource{EmptyDir: &v1.EmptyDirVolumeSource{}}}}
+
+	return []runtime.Object{
+		&v1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}},
+		pod("web-1", "node1", map[string]string{"app": "web"}, "ReplicaSet"),
+		pod("web-2", "node2", map[string]string{"app": "web"}, "ReplicaSet"),
+		pod("api-1", "node1", map[string]string{"app": "api", "tier": "backend"}, "ReplicaSet"),
+		pod("api-2", "node2", map[string]string{"app": "api"}, "ReplicaSet"),
+		pod("agent", "node1", map[string]string{"app": "agent"}, "DaemonSet"),
+		debug,
+		// every pod of web must stay available
+		&policyv1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "test"},
+			Spec: policyv1.PodDisruptionBudgetSpec{
+				MinAvailable: &two,
+				Selector:     &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+			},
+			Status: policyv1.PodDisruptionBudgetStatus{CurrentHealthy: 2, DesiredHealthy: 2, ExpectedPods: 2},
+		},
+		// an api pod is unhealthy, no other one can be evicted
+		&policyv1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "test"},
+			Spec: policyv1.PodDisruptionBudgetSpec{
+				MaxUnavailable: &one,
+				Selector:       &metav1.LabelSelector{MatchLabels: map[string]string{"app": "api"}},
+			},
+			Status: policyv1.PodDisruptionBudgetStatus{
+				CurrentHealthy: 1,
+				DesiredHealthy: 1,
+				ExpectedPods:   2,
+				Conditions: []metav1.Condition{
+					{Type: policyv1.DisruptionAllowedCondition, Status: metav1.ConditionFalse, Reason: policyv1.InsufficientPodsReason},
+				},
+			},
+		},
+		&policyv1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: "backend", Namespace: "test"},
+			Spec: policyv1.PodDisruptionBudgetSpec{
+				MaxUnavailable: &one,
+				Selector:       &metav1.LabelSelector{MatchLabels: map[string]string{"tier": "backend"}},
+			},
+			Status: policyv1.PodDisruptionBudgetStatus{DisruptionsAllowed: 1, CurrentHealthy: 1, DesiredHealthy: 0, ExpectedPods: 1},
+		},
+		&policyv1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: "ghost", Namespace: "test"},
+			Spec: policyv1.PodDisruptionBudgetSpec{
+				MaxUnavailable: &one,
+				Selector:       &metav1.LabelSelector{MatchLabels: map[string]string{"app": "ghost"}},
+			},
+		},
+	}
+}
+
+func TestPdbAnalyzer(t *testing.T) {
+	clientset := fake.NewSimpleClientset(pdbTestObjects()...)
+	config := common.Analyzer{
+		Client: &kubernetes.Client{
+			Client: clientset,
+		},
+		Context:   context.Background(),
+		Namespace: "test",
+	}
+	analysisResults, err := PdbAnalyzer{}.Analyze(config)
+	if err != nil {
+		t.Error(err)
+	}
+
+	texts := map[string][]string{}
+	for _, result := range analysisResults {
+		for _, failure := range result.Error {
+			texts[result.Name] = append(texts[result.Name], failure.Text)
+		}
+	}
+	assert.Equal(t, texts, map[string][]string{
+		"test/web": {
+			"PodDisruptionBudget web requires 2 available pods out of 2, no pod can ever be evicted and the drain of their nodes is blocked.",
+		},
+		"test/api": {
+			"PodDisruptionBudget api allows no disruption (InsufficientPods) with 1/2 healthy pods for 1 desired, it blocks the drain of the nodes node1, node2.",
+			"PodDisruptionBudget api overlaps the PodDisruptionBudget backend on the pods api-1, the eviction API refuses the pods covered by more than one budget.",
+		},
+		"test/backend": {
+			"PodDisruptionBudget backend overlaps the PodDisruptionBudget api on the pods api-1, the eviction API refuses the pods covered by more than one budget.",
+		},
+		"test/ghost": {
+			"PodDisruptionBudget ghost selects no pod, its selector [app=ghost] matches nothing in the namespace test.",
+		},
+	})
+}
+
+func TestDrainBlockers(t *testing.T) {
+	clientset := fake.NewSimpleClientset(pdbTestObjects()...)
+	blockers, err := DrainBlockers(context.Background(), clientset, "node1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, blockers, []DrainBlocker{
+		{
+			Pod:                  "test/api-1",
+			PodDisruptionBudgets: []string{"test/api", "test/backend"},
+			Reason:               "it is covered by more than one PodDisruptionBudget, the eviction API refuses it",
+		},
+		{
+			Pod:    "test/debug",
+			Reason: "it is not managed by a controller, kubectl drain refuses to delete it without --force and it would not be recreated",
+		},
+		{
+			Pod:    "test/debug",
+			Reason: "it uses the emptyDir volume scratch, kubectl drain needs --delete-emptydir-data and its data is lost",
+		},
+		{
+			Pod:                  "test/web-1",
+			PodDisruptionBudgets: []string{"test/web"},
+			Reason:               "the PodDisruptionBudget allows no disruption with 2/2 healthy pods for 2 desired",
+		},
+	})
+
+	_, err = DrainBlockers(context.Background(), clientset, "missing")
+	if err == nil {
+		t.Error("expected an error for a missing node")
+	}
+}
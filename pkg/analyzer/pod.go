@@ -15,9 +15,11 @@ package analyzer
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
 	"github.com/k8sgpt-ai/k8sgpt/pkg/util"
+	"github.com/spf13/viper"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -25,6 +27,50 @@ import (
 type PodAnalyzer struct {
 }
 
+// PodConfig is read from the analyzers.pod key of the config file
+type PodConfig struct {
+	// RestartThreshold is the number of restarts from which a container is reported
+	RestartThreshold int32 `mapstructure:"restartthreshold"`
+	// InitTimeout is how long an init container can run before it is considered stuck
+	InitTimeout string `mapstructure:"inittimeout"`
+	// TerminatingTimeout is how long a pod can stay Terminating after its grace period
+	TerminatingTimeout string `mapstructure:"terminatingtimeout"`
+	// TerminationWindow is how long the last termination of a container that runs and is ready again is reported
+	TerminationWindow string `mapstructure:"terminationwindow"`
+	// ExplainScheduling evaluates the Unschedulable pods against every node
+	ExplainScheduling bool `mapstructure:"explainscheduling"`
+
+	initTimeout        time.Duration
+	terminatingTimeout time.Duration
+	terminationWindow  time.Duration
+}
+
+func getPodConfig() PodConfig {
+	config := PodConfig{
+		RestartThreshold:   5,
+		InitTimeout:        "10m",
+		TerminatingTimeout: "1m",
+		TerminationWindow:  "1h",
+		ExplainScheduling:  true,
+	}
+	_ = viper.UnmarshalKey("analyzers.pod", &config)
+	config.initTimeout = configDuration("analyzers.pod.inittimeout", config.InitTimeout)
+	config.terminatingTimeout = configDuration("analyzers.pod.terminatingtimeout", config.TerminatingTimeout)
+	config.terminationWindow = configDuration("analyzers.pod.terminationwindow", config.TerminationWindow)
+	return config
+}
+
+// containerWaitingReasons are the waiting reasons that need an action, with a hint when the message is not explicit
+var containerWaitingReasons = map[string]string{
+	"CrashLoopBackOff":           "the container keeps exiting, check its logs and its last termination",
+	"ImagePullBackOff":           "the image cannot be pulled, check its name, tag and the image pull secrets",
+	"ErrImagePull":               "the image cannot be pulled, check its name, tag and the image pull secrets",
+	"CreateContainerConfigError": "a ConfigMap or Secret, or one of its keys, referenced by the container does not exist",
+	"CreateContainerError":       "the container runtime cannot create the container",
+	"ErrImageNeverPull":          "the image is not present on the node and the imagePullPolicy is Never",
+	"InvalidImageName":           "the image reference cannot be parsed",
+}
+
 func isSystemNamespace(ns string) bool {
 	if ns == "default" || ns == "kube-node-lease" || ns == "kube-public" || ns == "kube-system" || ns == "platform-load-balancer" ||
 		ns == "rdei-system" {
@@ -58,31 +104,6 @@ func VolumeWithoutGreenSelector(pod corev1.Pod) bool {
 	return false
 }
 
-func HasPBSVolume(a common.Analyzer, pod corev1.Pod, pvcList *corev1.PersistentVolumeClaimList) string {
-
-	if pod.Spec.Volumes != nil {
-		for _, v := range pod.Spec.Volumes {
-			if v.PersistentVolumeClaim != nil {
-				for _, pvc := range pvcList.Items {
-					if pvc.Name == v.PersistentVolumeClaim.ClaimName {
-						if pvc.Spec.StorageClassName != nil {
-							pv, err := a.Client.GetClient().CoreV1().PersistentVolumes().Get(a.Context, pvc.Spec.VolumeName, metav1.GetOptions{})
-							if err != nil {
-								fmt.Printf("Error reading PersistentVolume %s - %v \n ", pvc.Spec.VolumeName, err)
-								return ""
-							}
-							if pv.Spec.PortworxVolume != nil {
-								return pvc.Spec.VolumeName
-							}
-						}
-					}
-				}
-			}
-		}
-	}
-	return ""
-}
-
 func (PodAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
 
 	kind := "Pod"
@@ -90,6 +111,11 @@ func (PodAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
 	AnalyzerErrorsMetric.DeletePartialMatch(map[string]string{
 		"analyzer_name": kind,
 	})
+	// the events are listed once for all the lookups of the analysis
+	a.Events = eventIndex(a)
+
+	config := getPodConfig()
+	storageConfig := getStorageConfig()
 
 	pvcList, err := a.Client.GetClient().CoreV1().PersistentVolumeClaims(a.Namespace).List(a.Context, metav1.ListOptions{})
 	if err != nil {
@@ -102,6 +128,8 @@ func (PodAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
 		return nil, err
 	}
 	var preAnalysis = map[string]common.PreAnalysis{}
+	// the cluster is only read when a pod cannot be scheduled
+	var cluster *schedulingCluster
 
 	nodeSelectorMissing := 0
 	for _, pod := range list.Items {
@@ -112,18 +140,15 @@ func (PodAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
 		// Check for pending pods
 
 		if !isSystemNamespace(pod.Namespace) {
-			if pod.Spec.SchedulerName != "stork" {
-				vol := HasPBSVolume(a, pod, pvcList)
-				if vol != "" {
-					failures = append(failures, common.Failure{
-						Text: fmt.Sprintf(`Pod %s is accessing PBS volume %s and need to run the stork scheduler. `, pod.Name, vol),
-						Sensitive: []common.Sensitive{
-							{
-								Unmasked: pod.Name, Masked: util.MaskString(pod.Name),
-							},
+			if vol, scheduler := requiredScheduler(a, pod, pvcList, storageConfig.Schedulers); vol != "" {
+				failures = append(failures, common.Failure{
+					Text: fmt.Sprintf(`Pod %s is accessing volume %s and need to run the %s scheduler. `, pod.Name, vol, scheduler),
+					Sensitive: []common.Sensitive{
+						{
+							Unmasked: pod.Name, Masked: util.MaskString(pod.Name),
 						},
-					})
-				}
+					},
+				})
 			}
 			if VolumeWithoutGreenSelector(pod) {
 				failures = append(failures, common.Failure{
@@ -160,6 +185,21 @@ func (PodAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
 							Sensitive: []common.Sensitive{},
 						})
 					}
+					if config.ExplainScheduling {
+						if cluster == nil {
+							cluster, err = loadSchedulingCluster(a)
+							if err != nil {
+								config.ExplainScheduling = false
+								continue
+							}
+						}
+						if explanation, names := cluster.explain(pod); explanation != "" {
+							failures = append(failures, common.Failure{
+								Text:      explanation,
+								Sensitive: append(podSensitive(pod), sensitiveValues(names...)...),
+							})
+						}
+					}
 				}
 			}
 		}
@@ -167,19 +207,14 @@ func (PodAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
 		// Check through container status to check for crashes or unready
 		for _, containerStatus := range pod.Status.ContainerStatuses {
 			if containerStatus.State.Waiting != nil {
-				if containerStatus.State.Waiting.Reason == "CrashLoopBackOff" || containerStatus.State.Waiting.Reason == "ImagePullBackOff" {
-					if containerStatus.State.Waiting.Message != "" {
-						failures = append(failures, common.Failure{
-							Text:      containerStatus.State.Waiting.Message,
-							Sensitive: []common.Sensitive{},
-						})
-					}
+				if hint, ok := containerWaitingReasons[containerStatus.State.Waiting.Reason]; ok {
+					failures = append(failures, containerWaitingFailure("Container", pod.Spec.Containers, containerStatus, hint))
 				}
 				// This represents a container that is still being created or blocked due to conditions such as OOMKilled
 				if containerStatus.State.Waiting.Reason == "ContainerCreating" && pod.Status.Phase == "Pending" {
 
 					// parse the event log and append details
-					evt, err := FetchLatestEvent(a.Context, a.Client, pod.Namespace, pod.Name)
+					evt, err := FetchLatestEvent(a, "Pod", pod.ObjectMeta)
 					if err != nil || evt == nil {
 						continue
 					}
@@ -194,7 +229,7 @@ func (PodAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
 				// when pod is Running but its ReadinessProbe fails
 				if !containerStatus.Ready && pod.Status.Phase == "Running" {
 					// parse the event log and append details
-					evt, err := FetchLatestEvent(a.Context, a.Client, pod.Namespace, pod.Name)
+					evt, err := FetchLatestEvent(a, "Pod", pod.ObjectMeta)
 					if err != nil || evt == nil {
 						continue
 					}
@@ -209,6 +244,8 @@ func (PodAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
 				}
 			}
 		}
+		failures = append(failures, analyzeContainers(pod, config)...)
+
 		if len(failures) > 0 {
 			preAnalysis[fmt.Sprintf("%s/%s", pod.Namespace, pod.Name)] = common.PreAnalysis{
 				Namespace:      pod.Namespace,
@@ -236,3 +273,143 @@ func (PodAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
 
 	return a.Results, nil
 }
+
+// containerWaitingFailure reports a waiting container, its restarts and its last termination explain the waiting
+// reason and are reported with it
+func containerWaitingFailure(containerKind string, containers []corev1.Container, status corev1.ContainerStatus, hint string) common.Failure {
+	text := fmt.Sprintf("%s %s is waiting with reason %s: %s", containerKind, status.Name, status.State.Waiting.Reason, hint)
+	if status.State.Waiting.Message != "" {
+		text = fmt.Sprintf("%s %s is waiting with reason %s: %s", containerKind, status.Name, status.State.Waiting.Reason, status.State.Waiting.Message)
+	}
+	if status.RestartCount > 0 {
+		text += fmt.Sprintf(". It has restarted %d times", status.RestartCount)
+	}
+	if terminated := status.LastTerminationState.Terminated; terminated != nil && terminated.ExitCode != 0 {
+		text += ". Its last run " + terminationText(containers, status.Name, terminated)
+	}
+	return common.Failure{
+		Text:      text,
+		Sensitive: []common.Sensitive{},
+	}
+}
+
+// analyzeContainers looks at the pod lifecycle and at the state of the containers beyond the waiting reasons
+func analyzeContainers(pod corev1.Pod, config PodConfig) []common.Failure {
+	var failures []common.Failure
+
+	if pod.Status.Phase == corev1.PodFailed && pod.Status.Reason == "Evicted" {
+		failures = append(failures, common.Failure{
+			Text:      fmt.Sprintf("Pod %s was evicted: %s", pod.Name, pod.Status.Message),
+			Sensitive: podSensitive(pod),
+		})
+	}
+
+	// the deletion timestamp already includes the grace period
+	if pod.DeletionTimestamp != nil && config.terminatingTimeout > 0 && time.Since(pod.DeletionTimestamp.Time) > config.terminatingTimeout {
+		failures = append(failures, common.Failure{
+			Text: fmt.Sprintf("Pod %s has been terminating for %s past its grace period, a finalizer or an unreachable node may be blocking the deletion.",
+				pod.Name, time.Since(pod.DeletionTimestamp.Time).Round(time.Second)),
+			Sensitive: podSensitive(pod),
+		})
+	}
+
+	for _, status := range pod.Status.InitContainerStatuses {
+		if status.State.Waiting != nil {
+			if hint, ok := containerWaitingReasons[status.State.Waiting.Reason]; ok {
+				failures = append(failures, containerWaitingFailure("Init container", pod.Spec.InitContainers, status, hint))
+			}
+		}
+		if running := status.State.Running; running != nil && config.initTimeout > 0 && time.Since(running.StartedAt.Time) > config.initTimeout {
+			failures = append(failures, common.Failure{
+				Text: fmt.Sprintf("Init container %s has been running for %s, the pod cannot start until it completes.",
+					status.Name, time.Since(running.StartedAt.Time).Round(time.Second)),
+				Sensitive: []common.Sensitive{},
+			})
+		}
+		if terminated := status.State.Terminated; terminated != nil && terminated.ExitCode != 0 {
+			failures = append(failures, terminationFailure("Init container", pod.Spec.InitContainers, status.Name, terminated))
+		} else if terminated := status.LastTerminationState.Terminated; terminated != nil && reportLastTermination(status, config) {
+			failures = append(failures, terminationFailure("Init container", pod.Spec.InitContainers, status.Name, terminated))
+		}
+	}
+
+	for _, status := range pod.Status.ContainerStatuses {
+		// the restarts and the last termination of a waiting container are reported with its waiting reason
+		if status.State.Waiting != nil {
+			if _, ok := containerWaitingReasons[status.State.Waiting.Reason]; ok {
+				continue
+			}
+		}
+		if terminated := status.State.Terminated; terminated != nil && terminated.ExitCode != 0 {
+			failures = append(failures, terminationFailure("Container", pod.Spec.Containers, status.Name, terminated))
+		} else if terminated := status.LastTerminationState.Terminated; terminated != nil && reportLastTermination(status, config) {
+			failures = append(failures, terminationFailure("Container", pod.Spec.Containers, status.Name, terminated))
+		}
+		if config.RestartThreshold > 0 && status.RestartCount >= config.RestartThreshold {
+			failures = append(failures, common.Failure{
+				Text:      fmt.Sprintf("Container %s has restarted %d times.", status.Name, status.RestartCount),
+				Sensitive: []common.Sensitive{},
+			})
+		}
+	}
+	return failures
+}
+
+// reportLastTermination tells whether the last failed run of a container is still relevant: the container is
+// not back to ready, or the run ended within the termination window
+func reportLastTermination(status corev1.ContainerStatus, config PodConfig) bool {
+	terminated := status.LastTerminationState.Terminated
+	if terminated == nil || terminated.ExitCode == 0 || status.State.Terminated != nil {
+		return false
+	}
+	if status.State.Waiting != nil {
+		// a waiting reason that needs an action already carries the last termination
+		_, reported := containerWaitingReasons[status.State.Waiting.Reason]
+		return !reported
+	}
+	if !status.Ready {
+		return true
+	}
+	return config.terminationWindow > 0 && !terminated.FinishedAt.IsZero() && time.Since(terminated.FinishedAt.Time) < config.terminationWindow
+}
+
+func terminationFailure(containerKind string, containers []corev1.Container, name string, terminated *corev1.ContainerStateTerminated) common.Failure {
+	return common.Failure{
+		Text:      fmt.Sprintf("%s %s %s", containerKind, name, terminationText(containers, name, terminated)),
+		Sensitive: []common.Sensitive{},
+	}
+}
+
+// terminationText describes how a container terminated, with its memory limit when it was OOMKilled
+func terminationText(containers []corev1.Container, name string, terminated *corev1.ContainerStateTerminated) string {
+	text := fmt.Sprintf("terminated with exit code %d, reason %s", terminated.ExitCode, terminated.Reason)
+	if terminated.Reason == "OOMKilled" {
+		limit := "no memory limit"
+		for _, container := range containers {
+			if container.Name != name {
+				continue
+			}
+			if memory, ok := container.Resources.Limits[corev1.ResourceMemory]; ok {
+				limit = "a memory limit of " + memory.String()
+			}
+		}
+		text = fmt.Sprintf("was OOMKilled (exit code %d) with %s", terminated.ExitCode, limit)
+	}
+	if terminated.Message != "" {
+		text += ": " + terminated.Message
+	}
+	return text
+}
+
+func podSensitive(pod corev1.Pod) []common.Sensitive {
+	return []common.Sensitive{
+		{
+			Unmasked: pod.Namespace,
+			Masked:   util.MaskString(pod.Namespace),
+		},
+		{
+			Unmasked: pod.Name,
+			Masked:   util.MaskString(pod.Name),
+		},
+	}
+}
@@ -16,11 +16,13 @@ package analyzer
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
 	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
 	"github.com/magiconair/properties/assert"
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/fake"
 )
@@ -156,3 +158,170 @@ func TestPodAnalyzerNamespaceFiltering(t *testing.T) {
 	}
 	assert.Equal(t, len(analysisResults), 1)
 }
+
+func TestPodAnalyzerContainerDiagnostics(t *testing.T) {
+	deletion := metav1.NewTime(time.Now().Add(-10 * time.Minute))
+	clientset := fake.NewSimpleClientset(
+		&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "oom",
+				Namespace: "test",
+			},
+			Spec: v1.PodSpec{
+				NodeSelector: map[string]string{"rdei.io/sec-zone-green": "true"},
+				Containers: []v1.Container{
+					{
+						Name: "app",
+						Resources: v1.ResourceRequirements{
+							Limits: v1.ResourceList{v1.ResourceMemory: resource.MustParse("128Mi")},
+						},
+					},
+				},
+			},
+			Status: v1.PodStatus{
+				Phase: v1.PodRunning,
+				ContainerStatuses: []v1.ContainerStatus{
+					{
+						Name:         "app",
+						Ready:        true,
+						RestartCount: 7,
+						State:        v1.ContainerState{Running: &v1.ContainerStateRunning{}},
+						LastTerminationState: v1.ContainerState{
+							Terminated: &v1.ContainerStateTerminated{ExitCode: 137, Reason: "OOMKilled", FinishedAt: metav1.NewTime(time.Now().Add(-10 * time.Minute))},
+						},
+					},
+				},
+			},
+		},
+		&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "recovered",
+				Namespace: "test",
+			},
+			Spec: v1.PodSpec{
+				NodeSelector: map[string]string{"rdei.io/sec-zone-green": "true"},
+			},
+			Status: v1.PodStatus{
+				Phase: v1.PodRunning,
+				ContainerStatuses: []v1.ContainerStatus{
+					{
+						Name:         "app",
+						Ready:        true,
+						RestartCount: 1,
+						State:        v1.ContainerState{Running: &v1.ContainerStateRunning{}},
+						LastTerminationState: v1.ContainerState{
+							Terminated: &v1.ContainerStateTerminated{ExitCode: 1, Reason: "Error", FinishedAt: metav1.NewTime(time.Now().Add(-48 * time.Hour))},
+						},
+					},
+				},
+			},
+		},
+		&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "crashing",
+				Namespace: "test",
+			},
+			Spec: v1.PodSpec{
+				NodeSelector: map[string]string{"rdei.io/sec-zone-green": "true"},
+			},
+			Status: v1.PodStatus{
+				Phase: v1.PodRunning,
+				ContainerStatuses: []v1.ContainerStatus{
+					{
+						Name:         "app",
+						RestartCount: 7,
+						State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{
+							Reason:  "CrashLoopBackOff",
+							Message: "back-off 5m0s restarting failed container=app",
+						}},
+						LastTerminationState: v1.ContainerState{
+							Terminated: &v1.ContainerStateTerminated{ExitCode: 1, Reason: "Error", FinishedAt: metav1.NewTime(time.Now().Add(-48 * time.Hour))},
+						},
+					},
+				},
+			},
+		},
+		&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "config",
+				Namespace: "test",
+			},
+			Spec: v1.PodSpec{
+				NodeSelector: map[string]string{"rdei.io/sec-zone-green": "true"},
+			},
+			Status: v1.PodStatus{
+				Phase: v1.PodPending,
+				InitContainerStatuses: []v1.ContainerStatus{
+					{
+						Name: "init",
+						State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{
+							Reason:  "CreateContainerConfigError",
+							Message: "couldn't find key password in Secret test/db",
+						}},
+					},
+				},
+			},
+		},
+		&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "evicted",
+				Namespace: "test",
+			},
+			Spec: v1.PodSpec{
+				NodeSelector: map[string]string{"rdei.io/sec-zone-green": "true"},
+			},
+			Status: v1.PodStatus{
+				Phase:   v1.PodFailed,
+				Reason:  "Evicted",
+				Message: "The node was low on resource: ephemeral-storage.",
+			},
+		},
+		&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "terminating",
+				Namespace:         "test",
+				DeletionTimestamp: &deletion,
+				Finalizers:        []string{"example.com/cleanup"},
+			},
+			Spec: v1.PodSpec{
+				NodeSelector: map[string]string{"rdei.io/sec-zone-green": "true"},
+			},
+			Status: v1.PodStatus{
+				Phase: v1.PodRunning,
+			},
+		})
+
+	config := common.Analyzer{
+		Client: &kubernetes.Client{
+			Client: clientset,
+		},
+		Context:   context.Background(),
+		Namespace: "test",
+	}
+	analysisResults, err := PodAnalyzer{}.Analyze(config)
+	if err != nil {
+		t.Error(err)
+	}
+	failures := map[string][]string{}
+	for _, result := range analysisResults {
+		for _, failure := range result.Error {
+			failures[result.Name] = append(failures[result.Name], failure.Text)
+		}
+	}
+	assert.Equal(t, len(analysisResults), 5)
+	assert.Equal(t, failures["test/oom"], []string{
+		"Container app was OOMKilled (exit code 137) with a memory limit of 128Mi",
+		"Container app has restarted 7 times.",
+	})
+	assert.Equal(t, failures["test/config"], []string{
+		"Init container init is waiting with reason CreateContainerConfigError: couldn't find key password in Secret test/db",
+	})
+	assert.Equal(t, failures["test/evicted"], []string{
+		"Pod evicted was evicted: The node was low on resource: ephemeral-storage.",
+	})
+	assert.Equal(t, len(failures["test/terminating"]), 1)
+	assert.Equal(t, failures["test/crashing"], []string{
+		"Container app is waiting with reason CrashLoopBackOff: back-off 5m0s restarting failed container=app. It has restarted 7 times. Its last run terminated with exit code 1, reason Error",
+	})
+	assert.Equal(t, len(failures["test/recovered"]), 0)
+}
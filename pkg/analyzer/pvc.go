@@ -30,6 +30,8 @@ func (PvcAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
 	AnalyzerErrorsMetric.DeletePartialMatch(map[string]string{
 		"analyzer_name": kind,
 	})
+	// the events are listed once for all the lookups of the analysis
+	a.Events = eventIndex(a)
 
 	// search all namespaces for pods that are not running
 	list, err := a.Client.GetClient().CoreV1().PersistentVolumeClaims(a.Namespace).List(a.Context, metav1.ListOptions{})
@@ -49,7 +51,7 @@ func (PvcAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
 		if pvc.Status.Phase == "Pending" {
 
 			// parse the event log and append details
-			evt, err := FetchLatestEvent(a.Context, a.Client, pvc.Namespace, pvc.Name)
+			evt, err := FetchLatestEvent(a, "PersistentVolumeClaim", pvc.ObjectMeta)
 			if err != nil || evt == nil {
 				continue
 			}
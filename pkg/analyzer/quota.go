@@ -0,0 +1,325 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyzer
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/util"
+	"github.com/spf13/viper"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// QuotaAnalyzer reports the ResourceQuotas close to exhaustion and the workloads the quotas or LimitRanges
+// of their namespace will reject
+type QuotaAnalyzer struct{}
+
+// QuotaConfig is read from the analyzers.quota key of the config file
+type QuotaConfig struct {
+	// UsageThreshold is the percentage of a hard limit from which a quota is reported
+	UsageThreshold float64 `mapstructure:"usagethreshold"`
+}
+
+func getQuotaConfig() QuotaConfig {
+	config := QuotaConfig{
+		UsageThreshold: 90,
+	}
+	_ = viper.UnmarshalKey("analyzers.quota", &config)
+	return config
+}
+
+// quotaMessage matches the name of the quota in the errors of the ResourceQuota admission plugin
+var quotaMessage = regexp.MustCompile(`(?:exceeded|failed) quota: ([a-z0-9.-]+)`)
+
+func (QuotaAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
+
+	kind := "Quota"
+	apiDoc := kubernetes.K8sApiReference{
+		Kind: "ResourceQuota",
+		ApiVersion: schema.GroupVersion{
+			Group:   "",
+			Version: "v1",
+		},
+		OpenapiSchema: a.OpenapiSchema,
+	}
+
+	AnalyzerErrorsMetric.DeletePartialMatch(map[string]string{
+		"analyzer_name": kind,
+	})
+
+	config := getQuotaConfig()
+
+	quotas, err := a.Client.GetClient().CoreV1().ResourceQuotas(a.Namespace).List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	limitRanges, err := a.Client.GetClient().CoreV1().LimitRanges(a.Namespace).List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	workloads, err := listWorkloads(a)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, quota := range quotas.Items {
+		if SkipNamespace(quota.Namespace) {
+			continue
+		}
+		var failures []common.Failure
+		for _, name := range sortedResourceNames(quota.Status.Hard) {
+			hard := quota.Status.Hard[name]
+			used, ok := quota.Status.Used[name]
+			if !ok || hard.IsZero() {
+				continue
+			}
+			percent := used.AsApproximateFloat64() / hard.AsApproximateFloat64() * 100
+			if percent < config.UsageThreshold {
+				continue
+			}
+			failures = append(failures, common.Failure{
+				Text: fmt.Sprintf("ResourceQuota %s/%s uses %s of %s %s (%.0f%%), new pods requesting it will be rejected.",
+					quota.Namespace, quota.Name, used.String(), hard.String(), name, percent),
+				KubernetesDoc: apiDoc.GetApiDocV2("status.used"),
+				Sensitive: []common.Sensitive{
+					{
+						Unmasked: quota.Namespace,
+						Masked:   util.MaskString(quota.Namespace),
+					},
+					{
+						Unmasked: quota.Name,
+						Masked:   util.MaskString(quota.Name),
+					},
+				},
+			})
+		}
+		if len(failures) == 0 {
+			continue
+		}
+		AnalyzerErrorsMetric.WithLabelValues(kind, quota.Name, quota.Namespace).Set(float64(len(failures)))
+
+		a.Results = append(a.Results, common.Result{
+			Namespace:    quota.Namespace,
+			ResourceName: quota.Name,
+			Kind:         "ResourceQuota",
+			Name:         fmt.Sprintf("%s/%s", quota.Namespace, quota.Name),
+			Error:        failures,
+			ParentObject: fmt.Sprintf("ResourceQuota/%s", quota.Name),
+		})
+	}
+
+	for _, w := range workloads {
+		if SkipNamespace(w.ObjectMeta.Namespace) {
+			continue
+		}
+		var failures []common.Failure
+		namespace := w.ObjectMeta.Namespace
+		sensitive := []common.Sensitive{
+			{
+				Unmasked: namespace,
+				Masked:   util.MaskString(namespace),
+			},
+			{
+				Unmasked: w.ObjectMeta.Name,
+				Masked:   util.MaskString(w.ObjectMeta.Name),
+			},
+		}
+
+		// the pods still to be created have to fit in what is left of every quota of the namespace
+		if w.Replicas != nil && *w.Replicas > w.CurrentReplicas {
+			missing := int64(*w.Replicas - w.CurrentReplicas)
+			perPod := podQuotaUsage(w.Template.Spec)
+			for _, quota := range quotas.Items {
+				// the scoped quotas only count some of the pods, they are left to the admission plugin
+				if quota.Namespace != namespace || len(quota.Spec.Scopes) > 0 || quota.Spec.ScopeSelector != nil {
+					continue
+				}
+				for _, name := range sortedResourceNames(quota.Status.Hard) {
+					request, ok := perPod[name]
+					if !ok {
+						continue
+					}
+					hard := quota.Status.Hard[name]
+					remaining := hard.DeepCopy()
+					if used, ok := quota.Status.Used[name]; ok {
+						remaining.Sub(used)
+					}
+					if remaining.Sign() < 0 {
+						remaining = resource.Quantity{Format: hard.Format}
+					}
+					needed := resource.NewMilliQuantity(request.MilliValue()*missing, request.Format)
+					if needed.Cmp(remaining) <= 0 {
+						continue
+					}
+					failures = append(failures, common.Failure{
+						Text: fmt.Sprintf("%s %s/%s needs %d more pods using %s of %s, the ResourceQuota %s only has %s left.",
+							w.Kind, namespace, w.ObjectMeta.Name, missing, needed.String(), name, quota.Name, remaining.String()),
+						KubernetesDoc: apiDoc.GetApiDocV2("spec.hard"),
+						Sensitive:     sensitive,
+					})
+				}
+			}
+		}
+
+		for _, limitRange := range limitRanges.Items {
+			if limitRange.Namespace != namespace {
+				continue
+			}
+			for _, item := range limitRange.Spec.Limits {
+				if item.Type != v1.LimitTypeContainer {
+					continue
+				}
+				for _, container := range allContainers(w.Template.Spec) {
+					for _, text := range limitRangeConflicts(container, item) {
+						failures = append(failures, common.Failure{
+							Text: fmt.Sprintf("%s %s/%s container %s %s, the LimitRange %s will reject its pods.",
+								w.Kind, namespace, w.ObjectMeta.Name, container.Name, text, limitRange.Name),
+							Sensitive: sensitive,
+						})
+					}
+				}
+			}
+		}
+
+		if len(failures) == 0 {
+			continue
+		}
+		AnalyzerErrorsMetric.WithLabelValues(kind, w.ObjectMeta.Name, namespace).Set(float64(len(failures)))
+
+		a.Results = append(a.Results, common.Result{
+			Namespace:    namespace,
+			ResourceName: w.ObjectMeta.Name,
+			Kind:         w.Kind,
+			Name:         fmt.Sprintf("%s/%s", namespace, w.ObjectMeta.Name),
+			Error:        failures,
+			ParentObject: fmt.Sprintf("%s/%s", w.Kind, w.ObjectMeta.Name),
+		})
+	}
+
+	return a.Results, nil
+}
+
+// podQuotaUsage returns what a pod of the spec counts against a quota, under the names used by the quotas
+func podQuotaUsage(spec v1.PodSpec) v1.ResourceList {
+	usage := v1.ResourceList{
+		v1.ResourcePods: *resource.NewQuantity(1, resource.DecimalSI),
+	}
+	for name, quantity := range podRequests(v1.Pod{Spec: spec}) {
+		usage[name] = quantity
+		usage[v1.ResourceName("requests."+string(name))] = quantity
+	}
+	for _, container := range spec.Containers {
+		for name, quantity := range container.Resources.Limits {
+			limitName := v1.ResourceName("limits." + string(name))
+			total := usage[limitName]
+			total.Add(quantity)
+			usage[limitName] = total
+		}
+	}
+	return usage
+}
+
+// limitRangeConflicts returns why a container breaks a Container item of a LimitRange, once the defaults
+// of the item are applied the way the LimitRanger admission plugin does
+func limitRangeConflicts(container v1.Container, item v1.LimitRangeItem) []string {
+	var conflicts []string
+	request := func(name v1.ResourceName) (resource.Quantity, bool) {
+		if quantity, ok := container.Resources.Requests[name]; ok {
+			return quantity, true
+		}
+		// the API server copies an explicit limit into a missing request before the defaults are applied
+		if quantity, ok := container.Resources.Limits[name]; ok {
+			return quantity, true
+		}
+		quantity, ok := item.DefaultRequest[name]
+		return quantity, ok
+	}
+	limit := func(name v1.ResourceName) (resource.Quantity, bool) {
+		if quantity, ok := container.Resources.Limits[name]; ok {
+			return quantity, true
+		}
+		quantity, ok := item.Default[name]
+		return quantity, ok
+	}
+
+	for _, name := range sortedResourceNames(item.Max) {
+		maximum := item.Max[name]
+		if value, ok := limit(name); !ok {
+			conflicts = append(conflicts, fmt.Sprintf("has no %s limit while a maximum of %s is enforced", name, maximum.String()))
+		} else if value.Cmp(maximum) > 0 {
+			conflicts = append(conflicts, fmt.Sprintf("has a %s limit of %s above the maximum of %s", name, value.String(), maximum.String()))
+		}
+	}
+	for _, name := range sortedResourceNames(item.Min) {
+		minimum := item.Min[name]
+		if value, ok := request(name); !ok {
+			conflicts = append(conflicts, fmt.Sprintf("has no %s request while a minimum of %s is enforced", name, minimum.String()))
+		} else if value.Cmp(minimum) < 0 {
+			conflicts = append(conflicts, fmt.Sprintf("has a %s request of %s below the minimum of %s", name, value.String(), minimum.String()))
+		}
+	}
+	for _, name := range []v1.ResourceName{v1.ResourceCPU, v1.ResourceMemory} {
+		requested, hasRequest := request(name)
+		limited, hasLimit := limit(name)
+		if !hasRequest || !hasLimit || requested.Cmp(limited) <= 0 {
+			continue
+		}
+		if _, ok := container.Resources.Limits[name]; !ok {
+			conflicts = append(conflicts, fmt.Sprintf("has a %s request of %s above the default limit of %s", name, requested.String(), limited.String()))
+		} else if _, ok := container.Resources.Requests[name]; !ok {
+			conflicts = append(conflicts, fmt.Sprintf("has a %s limit of %s below the default request of %s", name, limited.String(), requested.String()))
+		}
+	}
+	return conflicts
+}
+
+// quotaFromMessage returns the name of the ResourceQuota an admission error refers to
+func quotaFromMessage(message string) string {
+	match := quotaMessage.FindStringSubmatch(message)
+	if match == nil {
+		return ""
+	}
+	return match[1]
+}
+
+// exhaustedResources describes the resources of a quota whose usage reached the hard limit
+func exhaustedResources(quota v1.ResourceQuota) string {
+	var exhausted []string
+	for _, name := range sortedResourceNames(quota.Status.Hard) {
+		hard := quota.Status.Hard[name]
+		if used, ok := quota.Status.Used[name]; ok && used.Cmp(hard) >= 0 {
+			exhausted = append(exhausted, fmt.Sprintf("%s %s/%s", name, used.String(), hard.String()))
+		}
+	}
+	return strings.Join(exhausted, ", ")
+}
+
+func sortedResourceNames(list v1.ResourceList) []v1.ResourceName {
+	names := make([]v1.ResourceName, 0, len(list))
+	for name := range list {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return names[i] < names[j]
+	})
+	return names
+}
@@ -0,0 +1,166 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyzer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
+	"github.com/magiconair/properties/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func quotaTestObjects() []runtime.Object {
+	replicas := int32(4)
+	quota := &v1.ResourceQuota{
+		ObjectMeta: metav1.ObjectMeta{Name: "compute", Namespace: "test"},
+		Status: v1.ResourceQuotaStatus{
+			Hard: v1.ResourceList{"requests.cpu": resource.MustParse("2"), "pods": resource.MustParse("10")},
+			Used: v1.ResourceList{"requests.cpu": resource.MustParse("1900m"), "pods": resource.MustParse("3")},
+		},
+	}
+	limitRange := &v1.LimitRange{
+		ObjectMeta: metav1.ObjectMeta{Name: "limits", Namespace: "test"},
+		Spec: v1.LimitRangeSpec{
+			Limits: []v1.LimitRangeItem{
+				{
+					Type:    v1.LimitTypeContainer,
+					Max:     v1.ResourceList{v1.ResourceMemory: resource.MustParse("1Gi")},
+					Default: v1.ResourceList{v1.ResourceMemory: resource.MustParse("512Mi")},
+				},
+			},
+		},
+	}
+	return []runtime.Object{
+		quota,
+		limitRange,
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "test"},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: &replicas,
+				Template: v1.PodTemplateSpec{
+					Spec: v1.PodSpec{
+						Containers: []v1.Container{
+							{
+								Name: "app",
+								Resources: v1.ResourceRequirements{
+									Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse("250m"), v1.ResourceMemory: resource.MustParse("128Mi")},
+								},
+							},
+						},
+					},
+				},
+			},
+			Status: appsv1.DeploymentStatus{Replicas: 2},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "cache", Namespace: "test"},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: &replicas,
+				Template: v1.PodTemplateSpec{
+					Spec: v1.PodSpec{
+						Containers: []v1.Container{
+							{
+								Name: "redis",
+								Resources: v1.ResourceRequirements{
+									Requests: v1.ResourceList{v1.ResourceMemory: resource.MustParse("768Mi")},
+									Limits:   v1.ResourceList{v1.ResourceMemory: resource.MustParse("2Gi")},
+								},
+							},
+							{
+								Name: "exporter",
+								Resources: v1.ResourceRequirements{
+									Requests: v1.ResourceList{v1.ResourceMemory: resource.MustParse("640Mi")},
+								},
+							},
+						},
+					},
+				},
+			},
+			Status: appsv1.DeploymentStatus{Replicas: 4},
+		},
+	}
+}
+
+func TestQuotaAnalyzer(t *testing.T) {
+	config := common.Analyzer{
+		Client: &kubernetes.Client{
+			Client: fake.NewSimpleClientset(quotaTestObjects()...),
+		},
+		Context:   context.Background(),
+		Namespace: "test",
+	}
+	analysisResults, err := QuotaAnalyzer{}.Analyze(config)
+	if err != nil {
+		t.Error(err)
+	}
+
+	texts := map[string][]string{}
+	for _, result := range analysisResults {
+		for _, failure := range result.Error {
+			texts[result.Name] = append(texts[result.Name], failure.Text)
+		}
+	}
+	assert.Equal(t, texts, map[string][]string{
+		"test/compute": {
+			"ResourceQuota test/compute uses 1900m of 2 requests.cpu (95%), new pods requesting it will be rejected.",
+		},
+		"test/web": {
+			"Deployment test/web needs 2 more pods using 500m of requests.cpu, the ResourceQuota compute only has 100m left.",
+		},
+		"test/cache": {
+			"Deployment test/cache container redis has a memory limit of 2Gi above the maximum of 1Gi, the LimitRange limits will reject its pods.",
+			"Deployment test/cache container exporter has a memory request of 640Mi above the default limit of 512Mi, the LimitRange limits will reject its pods.",
+		},
+	})
+}
+
+func TestReplicaSetAnalyzerQuotaLink(t *testing.T) {
+	objects := append(quotaTestObjects(), &appsv1.ReplicaSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-5d4f8", Namespace: "test"},
+		Status: appsv1.ReplicaSetStatus{
+			Conditions: []appsv1.ReplicaSetCondition{
+				{
+					Type:    appsv1.ReplicaSetReplicaFailure,
+					Reason:  "FailedCreate",
+					Message: `pods "web-5d4f8-x2c9k" is forbidden: exceeded quota: compute, requested: requests.cpu=250m, used: requests.cpu=2, limited: requests.cpu=2`,
+				},
+			},
+		},
+	})
+	quota := objects[0].(*v1.ResourceQuota)
+	quota.Status.Used["requests.cpu"] = resource.MustParse("2")
+
+	config := common.Analyzer{
+		Client: &kubernetes.Client{
+			Client: fake.NewSimpleClientset(objects...),
+		},
+		Context:   context.Background(),
+		Namespace: "test",
+	}
+	analysisResults, err := ReplicaSetAnalyzer{}.Analyze(config)
+	if err != nil {
+		t.Error(err)
+	}
+	assert.Equal(t, len(analysisResults), 1)
+	assert.Equal(t, len(analysisResults[0].Error), 2)
+	assert.Equal(t, analysisResults[0].Error[1].Text, "The pods are rejected by the ResourceQuota test/compute, it is exhausted for requests.cpu 2/2.")
+}
@@ -0,0 +1,335 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyzer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/util"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// RBACAnalyzer reports the bindings that grant nothing or too much, the ServiceAccounts of the workloads
+// that are not bound to anything, and the permissions the pods are denied according to their logs
+type RBACAnalyzer struct{}
+
+// forbiddenPattern matches the authorization errors of the API server as the clients log them. Only the denials of
+// ServiceAccounts are matched: a pod denied as another user (User "jane" cannot ...) authenticates with its own
+// credentials, binding a role to its ServiceAccount would not fix it.
+var forbiddenPattern = regexp.MustCompile(`User "system:serviceaccount:([^:"]+):([^"]+)" cannot (\w+) resource "([^"]+)" in API group "([^"]*)"(?: in the namespace "([^"]+)")?`)
+
+// forbiddenRequest is a request of a ServiceAccount the API server denied
+type forbiddenRequest struct {
+	Namespace      string
+	ServiceAccount string
+	Verb           string
+	Resource       string
+	Subresource    string
+	Group          string
+	// TargetNamespace is empty for the requests at the cluster scope
+	TargetNamespace string
+}
+
+// parseForbidden returns the first denied request found in the logs
+func parseForbidden(logs string) *forbiddenRequest {
+	match := forbiddenPattern.FindStringSubmatch(logs)
+	if match == nil {
+		return nil
+	}
+	request := &forbiddenRequest{
+		Namespace:       match[1],
+		ServiceAccount:  match[2],
+		Verb:            match[3],
+		Resource:        match[4],
+		Group:           match[5],
+		TargetNamespace: match[6],
+	}
+	if resource, subresource, found := strings.Cut(request.Resource, "/"); found {
+		request.Resource = resource
+		request.Subresource = subresource
+	}
+	return request
+}
+
+// String describes the permission as a rule of a Role
+func (r forbiddenRequest) String() string {
+	resource := r.Resource
+	if r.Subresource != "" {
+		resource += "/" + r.Subresource
+	}
+	scope := "at the cluster scope"
+	if r.TargetNamespace != "" {
+		scope = fmt.Sprintf("in the namespace %s", r.TargetNamespace)
+	}
+	return fmt.Sprintf("verb %s on %s in the API group %q %s", r.Verb, resource, r.Group, scope)
+}
+
+// isAllowed checks the request again with a SubjectAccessReview, the permission may have been granted since
+func (r forbiddenRequest) isAllowed(a common.Analyzer) (bool, error) {
+	review := &authorizationv1.SubjectAccessReview{
+		Spec: authorizationv1.SubjectAccessReviewSpec{
+			User:   fmt.Sprintf("system:serviceaccount:%s:%s", r.Namespace, r.ServiceAccount),
+			Groups: []string{"system:serviceaccounts", "system:serviceaccounts:" + r.Namespace, "system:authenticated"},
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Namespace:   r.TargetNamespace,
+				Verb:        r.Verb,
+				Group:       r.Group,
+				Resource:    r.Resource,
+				Subresource: r.Subresource,
+			},
+		},
+	}
+	result, err := a.Client.GetClient().AuthorizationV1().SubjectAccessReviews().Create(a.Context, review, metav1.CreateOptions{})
+	if err != nil {
+		return false, err
+	}
+	return result.Status.Allowed, nil
+}
+
+func (RBACAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
+
+	kind := "RBAC"
+	apiDoc := kubernetes.K8sApiReference{
+		Kind: "RoleBinding",
+		ApiVersion: schema.GroupVersion{
+			Group:   "rbac.authorization.k8s.io",
+			Version: "v1",
+		},
+		OpenapiSchema: a.OpenapiSchema,
+	}
+
+	AnalyzerErrorsMetric.DeletePartialMatch(map[string]string{
+		"analyzer_name": kind,
+	})
+
+	report := func(resourceKind string, namespace string, name string, failures []common.Failure) {
+		if len(failures) == 0 {
+			return
+		}
+		AnalyzerErrorsMetric.WithLabelValues(kind, name, namespace).Set(float64(len(failures)))
+		key := name
+		if namespace != "" {
+			key = fmt.Sprintf("%s/%s", namespace, name)
+		}
+		a.Results = append(a.Results, common.Result{
+			Namespace:    namespace,
+			ResourceName: name,
+			Kind:         resourceKind,
+			Name:         key,
+			Error:        failures,
+			ParentObject: fmt.Sprintf("%s/%s", resourceKind, name),
+		})
+	}
+
+	roles, err := a.Client.GetClient().RbacV1().Roles(a.Namespace).List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	clusterRoles, err := a.Client.GetClient().RbacV1().ClusterRoles().List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	roleBindings, err := a.Client.GetClient().RbacV1().RoleBindings(a.Namespace).List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	clusterRoleBindings, err := a.Client.GetClient().RbacV1().ClusterRoleBindings().List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	rules := map[string][]rbacv1.PolicyRule{}
+	for _, role := range roles.Items {
+		rules["Role/"+role.Namespace+"/"+role.Name] = role.Rules
+	}
+	for _, role := range clusterRoles.Items {
+		rules["ClusterRole/"+role.Name] = role.Rules
+	}
+
+	// the ServiceAccounts and groups something is bound to
+	bound := map[string]bool{}
+	serviceAccounts := map[string]bool{}
+	serviceAccountExists := func(namespace string, name string) bool {
+		key := namespace + "/" + name
+		if exists, ok := serviceAccounts[key]; ok {
+			return exists
+		}
+		_, err := a.Client.GetClient().CoreV1().ServiceAccounts(namespace).Get(a.Context, name, metav1.GetOptions{})
+		// only a missing ServiceAccount is reported, not one we are not allowed to read
+		serviceAccounts[key] = err == nil || !errors.IsNotFound(err)
+		return serviceAccounts[key]
+	}
+
+	checkBinding := func(bindingKind string, meta metav1.ObjectMeta, roleRef rbacv1.RoleRef, subjects []rbacv1.Subject) []common.Failure {
+		var failures []common.Failure
+		name := meta.Name
+		if meta.Namespace != "" {
+			name = meta.Namespace + "/" + meta.Name
+		}
+
+		roleKey := "ClusterRole/" + roleRef.Name
+		if roleRef.Kind == "Role" {
+			roleKey = "Role/" + meta.Namespace + "/" + roleRef.Name
+		}
+		roleRules, roleExists := rules[roleKey]
+		if !roleExists {
+			failures = append(failures, common.Failure{
+				Text:          fmt.Sprintf("%s %s references the %s %s which does not exist, it grants nothing.", bindingKind, name, roleRef.Kind, roleRef.Name),
+				KubernetesDoc: apiDoc.GetApiDocV2("roleRef"),
+				Sensitive:     sensitiveValues(meta.Name, roleRef.Name),
+			})
+		}
+
+		for _, subject := range subjects {
+			switch subject.Kind {
+			case rbacv1.GroupKind:
+				bound["Group/"+subject.Name] = true
+			case rbacv1.ServiceAccountKind:
+				namespace := subject.Namespace
+				if namespace == "" {
+					namespace = meta.Namespace
+				}
+				bound["ServiceAccount/"+namespace+"/"+subject.Name] = true
+				if SkipNamespace(namespace) || (a.Namespace != "" && namespace != a.Namespace) {
+					continue
+				}
+				if !serviceAccountExists(namespace, subject.Name) {
+					failures = append(failures, common.Failure{
+						Text:          fmt.Sprintf("%s %s binds the ServiceAccount %s/%s which does not exist.", bindingKind, name, namespace, subject.Name),
+						KubernetesDoc: apiDoc.GetApiDocV2("subjects"),
+						Sensitive:     sensitiveValues(meta.Name, namespace, subject.Name),
+					})
+					continue
+				}
+				if isSystemNamespace(namespace) {
+					continue
+				}
+				if roleRef.Kind == "ClusterRole" && roleRef.Name == "cluster-admin" {
+					failures = append(failures, common.Failure{
+						Text: fmt.Sprintf("%s %s grants cluster-admin to the ServiceAccount %s/%s, every pod running as it controls the cluster.",
+							bindingKind, name, namespace, subject.Name),
+						Sensitive: sensitiveValues(meta.Name, namespace, subject.Name),
+					})
+				} else if hasWildcardRule(roleRules) {
+					failures = append(failures, common.Failure{
+						Text: fmt.Sprintf("%s %s grants every verb on every resource of the %s %s to the ServiceAccount %s/%s.",
+							bindingKind, name, roleRef.Kind, roleRef.Name, namespace, subject.Name),
+						Sensitive: sensitiveValues(meta.Name, roleRef.Name, namespace, subject.Name),
+					})
+				}
+			}
+		}
+		return failures
+	}
+
+	for _, binding := range roleBindings.Items {
+		if SkipNamespace(binding.Namespace) {
+			continue
+		}
+		report("RoleBinding", binding.Namespace, binding.Name, checkBinding("RoleBinding", binding.ObjectMeta, binding.RoleRef, binding.Subjects))
+	}
+	for _, binding := range clusterRoleBindings.Items {
+		report("ClusterRoleBinding", "", binding.Name, checkBinding("ClusterRoleBinding", binding.ObjectMeta, binding.RoleRef, binding.Subjects))
+	}
+
+	workloads, err := listWorkloads(a)
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range workloads {
+		namespace := w.ObjectMeta.Namespace
+		serviceAccount := w.Template.Spec.ServiceAccountName
+		// the default ServiceAccount is not expected to be bound, and the pods without a token do not call the API
+		if SkipNamespace(namespace) || serviceAccount == "" || serviceAccount == "default" ||
+			(w.Template.Spec.AutomountServiceAccountToken != nil && !*w.Template.Spec.AutomountServiceAccountToken) {
+			continue
+		}
+		if bound["ServiceAccount/"+namespace+"/"+serviceAccount] || bound["Group/system:serviceaccounts"] ||
+			bound["Group/system:serviceaccounts:"+namespace] || bound["Group/system:authenticated"] {
+			continue
+		}
+		// a missing ServiceAccount is reported by the References analyzer
+		if !serviceAccountExists(namespace, serviceAccount) {
+			continue
+		}
+		report(w.Kind, namespace, w.ObjectMeta.Name, []common.Failure{
+			{
+				Text: fmt.Sprintf("%s %s/%s runs as the ServiceAccount %s which is not bound to any Role or ClusterRole, its API requests will be forbidden.",
+					w.Kind, namespace, w.ObjectMeta.Name, serviceAccount),
+				Sensitive: sensitiveValues(namespace, w.ObjectMeta.Name, serviceAccount),
+			},
+		})
+	}
+
+	pods, err := a.Client.GetClient().CoreV1().Pods(a.Namespace).List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	logConfig := getLogConfig()
+	for _, pod := range pods.Items {
+		if SkipNamespace(pod.Namespace) || pod.Status.Phase != v1.PodRunning {
+			continue
+		}
+		// a denied client retries or exits, the containers that are ready and never restarted are not read
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.Ready && status.RestartCount == 0 {
+				continue
+			}
+			// a crashing container has no current logs, the denial is in the ones of its last run
+			previous := status.State.Running == nil && status.RestartCount > 0
+			logs, err := a.Client.GetClient().CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, podLogOptions(logConfig, status.Name, previous)).DoRaw(a.Context)
+			if err != nil {
+				continue
+			}
+			request := parseForbidden(string(logs))
+			if request == nil {
+				continue
+			}
+			allowed, err := request.isAllowed(a)
+			if err != nil || allowed {
+				continue
+			}
+			report("Pod", pod.Namespace, pod.Name, []common.Failure{
+				{
+					Text: fmt.Sprintf("Container %s of Pod %s/%s is denied the %s as the ServiceAccount %s/%s, a Role or ClusterRole granting it must be bound to the ServiceAccount.",
+						status.Name, pod.Namespace, pod.Name, request.String(), request.Namespace, request.ServiceAccount),
+					Sensitive: sensitiveValues(pod.Namespace, pod.Name, request.ServiceAccount),
+				},
+			})
+			// the containers of a pod share its ServiceAccount
+			break
+		}
+	}
+
+	return a.Results, nil
+}
+
+// hasWildcardRule reports whether a rule grants every verb on every resource
+func hasWildcardRule(rules []rbacv1.PolicyRule) bool {
+	for _, rule := range rules {
+		if util.SliceContainsString(rule.Verbs, rbacv1.VerbAll) && util.SliceContainsString(rule.Resources, rbacv1.ResourceAll) {
+			return true
+		}
+	}
+	return false
+}
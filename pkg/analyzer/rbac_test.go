@@ -0,0 +1,164 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyzer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
+	"github.com/magiconair/properties/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestRBACAnalyzer(t *testing.T) {
+	clientset := fake.NewSimpleClientset(
+		&v1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "app", Namespace: "test"}},
+		&v1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "worker", Namespace: "test"}},
+		&v1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Name: "operator", Namespace: "test"}},
+		&rbacv1.Role{
+			ObjectMeta: metav1.ObjectMeta{Name: "reader", Namespace: "test"},
+			Rules:      []rbacv1.PolicyRule{{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get", "list"}}},
+		},
+		&rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: "everything"},
+			Rules:      []rbacv1.PolicyRule{{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}}},
+		},
+		&rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "app-reader", Namespace: "test"},
+			RoleRef:    rbacv1.RoleRef{Kind: "Role", Name: "reader"},
+			Subjects: []rbacv1.Subject{
+				{Kind: rbacv1.ServiceAccountKind, Name: "app"},
+				{Kind: rbacv1.ServiceAccountKind, Name: "gone"},
+			},
+		},
+		&rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "writer", Namespace: "test"},
+			RoleRef:    rbacv1.RoleRef{Kind: "Role", Name: "writer"},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "app"}},
+		},
+		&rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{Name: "operator"},
+			RoleRef:    rbacv1.RoleRef{Kind: "ClusterRole", Name: "everything"},
+			Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Name: "operator", Namespace: "test"}},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "test"},
+			Spec: appsv1.DeploymentSpec{
+				Template: v1.PodTemplateSpec{Spec: v1.PodSpec{ServiceAccountName: "app"}},
+			},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "worker", Namespace: "test"},
+			Spec: appsv1.DeploymentSpec{
+				Template: v1.PodTemplateSpec{Spec: v1.PodSpec{ServiceAccountName: "worker"}},
+			},
+		},
+	)
+
+	config := common.Analyzer{
+		Client: &kubernetes.Client{
+			Client: clientset,
+		},
+		Context:   context.Background(),
+		Namespace: "test",
+	}
+	analysisResults, err := RBACAnalyzer{}.Analyze(config)
+	if err != nil {
+		t.Error(err)
+	}
+
+	texts := map[string][]string{}
+	for _, result := range analysisResults {
+		for _, failure := range result.Error {
+			texts[result.Kind+" "+result.Name] = append(texts[result.Kind+" "+result.Name], failure.Text)
+		}
+	}
+	assert.Equal(t, texts, map[string][]string{
+		"RoleBinding test/app-reader": {
+			"RoleBinding test/app-reader binds the ServiceAccount test/gone which does not exist.",
+		},
+		"RoleBinding test/writer": {
+			"RoleBinding test/writer references the Role writer which does not exist, it grants nothing.",
+		},
+		"ClusterRoleBinding operator": {
+			"ClusterRoleBinding operator grants every verb on every resource of the ClusterRole everything to the ServiceAccount test/operator.",
+		},
+		"Deployment test/worker": {
+			"Deployment test/worker runs as the ServiceAccount worker which is not bound to any Role or ClusterRole, its API requests will be forbidden.",
+		},
+	})
+}
+
+func TestParseForbidden(t *testing.T) {
+	logs := `E1019 10:01:02.123456 1 reflector.go:147] failed to list *v1.Secret: secrets is forbidden: ` +
+		`User "system:serviceaccount:test:app" cannot list resource "secrets" in API group "" in the namespace "test"`
+	request := parseForbidden(logs)
+	assert.Equal(t, *request, forbiddenRequest{
+		Namespace:       "test",
+		ServiceAccount:  "app",
+		Verb:            "list",
+		Resource:        "secrets",
+		Group:           "",
+		TargetNamespace: "test",
+	})
+	assert.Equal(t, request.String(), `verb list on secrets in the API group "" in the namespace test`)
+
+	request = parseForbidden(`nodes "node1" is forbidden: User "system:serviceaccount:test:app" cannot get resource "nodes/proxy" in API group "" at the cluster scope`)
+	assert.Equal(t, request.Resource, "nodes")
+	assert.Equal(t, request.Subresource, "proxy")
+	assert.Equal(t, request.TargetNamespace, "")
+
+	assert.Equal(t, parseForbidden("connection refused") == nil, true)
+}
+
+func TestRBACAnalyzerReadsFailingContainersOnly(t *testing.T) {
+	pod := func(name string, ready bool, restarts int32) *v1.Pod {
+		return &v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test"},
+			Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "app"}}},
+			Status: v1.PodStatus{
+				Phase: v1.PodRunning,
+				ContainerStatuses: []v1.ContainerStatus{
+					{Name: "app", Ready: ready, RestartCount: restarts, State: v1.ContainerState{Running: &v1.ContainerStateRunning{}}},
+				},
+			},
+		}
+	}
+	clientset := fake.NewSimpleClientset(pod("healthy", true, 0), pod("restarted", true, 3), pod("unready", false, 0))
+
+	_, err := RBACAnalyzer{}.Analyze(common.Analyzer{
+		Client: &kubernetes.Client{
+			Client: clientset,
+		},
+		Context:   context.Background(),
+		Namespace: "test",
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	// the logs of the healthy pod are not read
+	reads := 0
+	for _, action := range clientset.Actions() {
+		if action.GetSubresource() == "log" {
+			reads++
+		}
+	}
+	assert.Equal(t, reads, 2)
+}
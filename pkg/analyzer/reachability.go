@@ -0,0 +1,234 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/netpol"
+	"github.com/spf13/viper"
+	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ReachabilityAnalyzer evaluates the NetworkPolicies to report the Services their clients cannot reach
+type ReachabilityAnalyzer struct{}
+
+// ReachabilityConfig is read from the analyzers.reachability key of the config file
+type ReachabilityConfig struct {
+	// IngressControllers are the label selectors of the ingress controller pods, looked up in every namespace
+	IngressControllers []string `mapstructure:"ingresscontrollers"`
+}
+
+func getReachabilityConfig() ReachabilityConfig {
+	config := ReachabilityConfig{
+		IngressControllers: []string{
+			"app.kubernetes.io/name=ingress-nginx",
+			"app.kubernetes.io/name=traefik",
+			"app.kubernetes.io/name=haproxy-ingress",
+		},
+	}
+	_ = viper.UnmarshalKey("analyzers.reachability", &config)
+	return config
+}
+
+func (ReachabilityAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
+
+	kind := "Reachability"
+	apiDoc := kubernetes.K8sApiReference{
+		Kind: "NetworkPolicy",
+		ApiVersion: schema.GroupVersion{
+			Group:   "networking",
+			Version: "v1",
+		},
+		OpenapiSchema: a.OpenapiSchema,
+	}
+
+	AnalyzerErrorsMetric.DeletePartialMatch(map[string]string{
+		"analyzer_name": kind,
+	})
+
+	engine, err := netpol.NewEngine(a.Context, a.Client.GetClient())
+	if err != nil {
+		return nil, err
+	}
+	// without policies every pod reaches every other one
+	if engine.Empty() {
+		return a.Results, nil
+	}
+
+	services, err := a.Client.GetClient().CoreV1().Services(a.Namespace).List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	pods, err := a.Client.GetClient().CoreV1().Pods(a.Namespace).List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	ingresses, err := a.Client.GetClient().NetworkingV1().Ingresses(a.Namespace).List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	exposed := map[string]bool{}
+	for _, ing := range ingresses.Items {
+		for _, backend := range ingressBackends(ing) {
+			if backend.Service != nil {
+				exposed[ing.Namespace+"/"+backend.Service.Name] = true
+			}
+		}
+	}
+
+	// the ingress controllers usually run in the system namespaces, they are not skipped
+	var controllers []v1.Pod
+	for _, selector := range getReachabilityConfig().IngressControllers {
+		list, err := a.Client.GetClient().CoreV1().Pods("").List(a.Context, metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return nil, err
+		}
+		controllers = append(controllers, activePods(list.Items)...)
+	}
+
+	for _, svc := range services.Items {
+		if SkipNamespace(svc.Namespace) || len(svc.Spec.Selector) == 0 {
+			continue
+		}
+		// a Service without pods is reported by the Service analyzer
+		backends := selectedPods(pods.Items, svc)
+		if len(backends) == 0 {
+			continue
+		}
+		selected := map[string]bool{}
+		for _, pod := range backends {
+			selected[pod.Name] = true
+		}
+		var neighbours []v1.Pod
+		for _, pod := range activePods(pods.Items) {
+			if pod.Namespace == svc.Namespace && !selected[pod.Name] {
+				neighbours = append(neighbours, pod)
+			}
+		}
+
+		var failures []common.Failure
+		for _, port := range svc.Spec.Ports {
+			portName := port.Name
+			if portName == "" {
+				portName = fmt.Sprint(port.Port)
+			}
+			if exposed[svc.Namespace+"/"+svc.Name] && len(controllers) > 0 {
+				if denial, reachable := reachableFrom(engine, controllers, backends, port); !reachable {
+					failures = append(failures, common.Failure{
+						Text: fmt.Sprintf("Service %s port %s is exposed by an Ingress but none of its pods can be reached from the ingress controller pods %s: %s.",
+							svc.Name, portName, podNames(controllers), denial),
+						KubernetesDoc: apiDoc.GetApiDocV2("spec.ingress.from"),
+						Sensitive:     sensitiveValues(svc.Name),
+					})
+				}
+			}
+			if len(neighbours) > 0 {
+				if denial, reachable := reachableFrom(engine, neighbours, backends, port); !reachable {
+					failures = append(failures, common.Failure{
+						Text: fmt.Sprintf("Service %s port %s cannot be reached from any other pod of its namespace: %s.",
+							svc.Name, portName, denial),
+						KubernetesDoc: apiDoc.GetApiDocV2("spec.ingress.from"),
+						Sensitive:     sensitiveValues(svc.Name),
+					})
+				}
+			}
+		}
+
+		if len(failures) == 0 {
+			continue
+		}
+		AnalyzerErrorsMetric.WithLabelValues(kind, svc.Name, svc.Namespace).Set(float64(len(failures)))
+		a.Results = append(a.Results, common.Result{
+			Namespace:    svc.Namespace,
+			ResourceName: svc.Name,
+			Kind:         "Service",
+			Name:         fmt.Sprintf("%s/%s", svc.Namespace, svc.Name),
+			Error:        failures,
+			ParentObject: "Service/" + svc.Name,
+		})
+	}
+
+	return a.Results, nil
+}
+
+// reachableFrom tells whether one of the sources reaches one of the backends on a Service port, the first
+// denial explains why when none does
+func reachableFrom(engine *netpol.Engine, sources []v1.Pod, backends []v1.Pod, port v1.ServicePort) (string, bool) {
+	denial := ""
+	checked := false
+	for _, backend := range backends {
+		number, ok := netpol.TargetPort(backend, port)
+		// the pods not declaring a named targetPort are reported by the Service analyzer
+		if !ok {
+			continue
+		}
+		for _, source := range sources {
+			checked = true
+			verdict := engine.Check(source, backend, number, port.Protocol)
+			if verdict.Allowed {
+				return "", true
+			}
+			if denial == "" {
+				denial = verdict.Reason
+			}
+		}
+	}
+	return denial, !checked
+}
+
+// ingressBackends returns the default backend and the path backends of an Ingress
+func ingressBackends(ing networkingv1.Ingress) []networkingv1.IngressBackend {
+	var backends []networkingv1.IngressBackend
+	if ing.Spec.DefaultBackend != nil {
+		backends = append(backends, *ing.Spec.DefaultBackend)
+	}
+	for _, rule := range ing.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			backends = append(backends, path.Backend)
+		}
+	}
+	return backends
+}
+
+// activePods leaves out the completed pods and the ones using the network of their node
+func activePods(pods []v1.Pod) []v1.Pod {
+	var active []v1.Pod
+	for _, pod := range pods {
+		if pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed || pod.Spec.HostNetwork {
+			continue
+		}
+		active = append(active, pod)
+	}
+	return active
+}
+
+func podNames(pods []v1.Pod) string {
+	names := make([]string, 0, len(pods))
+	for _, pod := range pods {
+		names = append(names, pod.Namespace+"/"+pod.Name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,280 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyzer
+
+import (
+	"fmt"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/util"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ReferencesAnalyzer reports the pod templates referencing objects that do not exist
+type ReferencesAnalyzer struct{}
+
+// referenceInventory holds the names, and for ConfigMaps and Secrets the keys, of the objects a template can reference
+type referenceInventory struct {
+	configMaps      map[string]map[string]bool
+	secrets         map[string]map[string]bool
+	serviceAccounts map[string]bool
+	claims          map[string]bool
+	priorityClasses map[string]bool
+}
+
+func newReferenceInventory(a common.Analyzer) (*referenceInventory, error) {
+	inventory := &referenceInventory{
+		configMaps:      map[string]map[string]bool{},
+		secrets:         map[string]map[string]bool{},
+		serviceAccounts: map[string]bool{},
+		claims:          map[string]bool{},
+		priorityClasses: map[string]bool{},
+	}
+
+	configMaps, err := a.Client.GetClient().CoreV1().ConfigMaps(a.Namespace).List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, cm := range configMaps.Items {
+		keys := map[string]bool{}
+		for key := range cm.Data {
+			keys[key] = true
+		}
+		for key := range cm.BinaryData {
+			keys[key] = true
+		}
+		inventory.configMaps[cm.Namespace+"/"+cm.Name] = keys
+	}
+
+	secrets, err := a.Client.GetClient().CoreV1().Secrets(a.Namespace).List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, secret := range secrets.Items {
+		keys := map[string]bool{}
+		for key := range secret.Data {
+			keys[key] = true
+		}
+		for key := range secret.StringData {
+			keys[key] = true
+		}
+		inventory.secrets[secret.Namespace+"/"+secret.Name] = keys
+	}
+
+	serviceAccounts, err := a.Client.GetClient().CoreV1().ServiceAccounts(a.Namespace).List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, sa := range serviceAccounts.Items {
+		inventory.serviceAccounts[sa.Namespace+"/"+sa.Name] = true
+	}
+
+	claims, err := a.Client.GetClient().CoreV1().PersistentVolumeClaims(a.Namespace).List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, pvc := range claims.Items {
+		inventory.claims[pvc.Namespace+"/"+pvc.Name] = true
+	}
+
+	priorityClasses, err := a.Client.GetClient().SchedulingV1().PriorityClasses().List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, pc := range priorityClasses.Items {
+		inventory.priorityClasses[pc.Name] = true
+	}
+
+	return inventory, nil
+}
+
+func (ReferencesAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
+
+	kind := "References"
+	apiDoc := kubernetes.K8sApiReference{
+		Kind: "Pod",
+		ApiVersion: schema.GroupVersion{
+			Group:   "",
+			Version: "v1",
+		},
+		OpenapiSchema: a.OpenapiSchema,
+	}
+
+	AnalyzerErrorsMetric.DeletePartialMatch(map[string]string{
+		"analyzer_name": kind,
+	})
+
+	workloads, err := listWorkloads(a)
+	if err != nil {
+		return nil, err
+	}
+	inventory, err := newReferenceInventory(a)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, w := range workloads {
+		if SkipNamespace(w.ObjectMeta.Namespace) {
+			continue
+		}
+		failures := inventory.check(w, apiDoc)
+		if len(failures) == 0 {
+			continue
+		}
+		AnalyzerErrorsMetric.WithLabelValues(kind, w.ObjectMeta.Name, w.ObjectMeta.Namespace).Set(float64(len(failures)))
+
+		var currentAnalysis = common.Result{
+			Namespace:    w.ObjectMeta.Namespace,
+			ResourceName: w.ObjectMeta.Name,
+			Kind:         w.Kind,
+			Name:         fmt.Sprintf("%s/%s", w.ObjectMeta.Namespace, w.ObjectMeta.Name),
+			Error:        failures,
+			// the findings are about the template, so they belong to the workload rather than to its pods
+			ParentObject: fmt.Sprintf("%s/%s", w.Kind, w.ObjectMeta.Name),
+		}
+		a.Results = append(a.Results, currentAnalysis)
+	}
+
+	return a.Results, nil
+}
+
+// check returns a failure for every dangling reference of the pod template of a workload
+func (inv *referenceInventory) check(w workload, apiDoc kubernetes.K8sApiReference) []common.Failure {
+	var failures []common.Failure
+	namespace := w.ObjectMeta.Namespace
+	spec := w.Template.Spec
+
+	missing := func(path string, format string, args ...interface{}) {
+		var sensitive = []common.Sensitive{
+			{
+				Unmasked: namespace,
+				Masked:   util.MaskString(namespace),
+			},
+			{
+				Unmasked: w.ObjectMeta.Name,
+				Masked:   util.MaskString(w.ObjectMeta.Name),
+			},
+		}
+		failures = append(failures, common.Failure{
+			Text:          fmt.Sprintf("%s %s/%s ", w.Kind, namespace, w.ObjectMeta.Name) + fmt.Sprintf(format, args...),
+			KubernetesDoc: apiDoc.GetApiDocV2(path),
+			Sensitive:     sensitive,
+		})
+	}
+	checkKey := func(objects map[string]map[string]bool, objectKind string, name string, key string, optional *bool, path string, where string) {
+		if optional != nil && *optional {
+			return
+		}
+		keys, ok := objects[namespace+"/"+name]
+		if !ok {
+			missing(path, "references the %s %s which does not exist (%s).", objectKind, name, where)
+			return
+		}
+		if key != "" && !keys[key] {
+			missing(path, "references the key %s of %s %s which does not exist (%s).", key, objectKind, name, where)
+		}
+	}
+	// the items of a missing object are not checked, the object is reported once
+	checkItems := func(objects map[string]map[string]bool, objectKind string, name string, items []v1.KeyToPath, optional *bool, path string, itemsPath string, where string) {
+		checkKey(objects, objectKind, name, "", optional, path, where)
+		if _, ok := objects[namespace+"/"+name]; !ok {
+			return
+		}
+		for _, item := range items {
+			checkKey(objects, objectKind, name, item.Key, optional, itemsPath, where)
+		}
+	}
+
+	for _, container := range allContainers(spec) {
+		for _, env := range container.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+			where := fmt.Sprintf("env %s of container %s", env.Name, container.Name)
+			if ref := env.ValueFrom.ConfigMapKeyRef; ref != nil {
+				checkKey(inv.configMaps, "ConfigMap", ref.Name, ref.Key, ref.Optional, "spec.containers.env.valueFrom.configMapKeyRef", where)
+			}
+			if ref := env.ValueFrom.SecretKeyRef; ref != nil {
+				checkKey(inv.secrets, "Secret", ref.Name, ref.Key, ref.Optional, "spec.containers.env.valueFrom.secretKeyRef", where)
+			}
+		}
+		for _, envFrom := range container.EnvFrom {
+			where := fmt.Sprintf("envFrom of container %s", container.Name)
+			if ref := envFrom.ConfigMapRef; ref != nil {
+				checkKey(inv.configMaps, "ConfigMap", ref.Name, "", ref.Optional, "spec.containers.envFrom.configMapRef", where)
+			}
+			if ref := envFrom.SecretRef; ref != nil {
+				checkKey(inv.secrets, "Secret", ref.Name, "", ref.Optional, "spec.containers.envFrom.secretRef", where)
+			}
+		}
+	}
+
+	for _, volume := range spec.Volumes {
+		where := fmt.Sprintf("volume %s", volume.Name)
+		if cm := volume.ConfigMap; cm != nil {
+			checkItems(inv.configMaps, "ConfigMap", cm.Name, cm.Items, cm.Optional, "spec.volumes.configMap", "spec.volumes.configMap.items", where)
+		}
+		if secret := volume.Secret; secret != nil {
+			checkItems(inv.secrets, "Secret", secret.SecretName, secret.Items, secret.Optional, "spec.volumes.secret", "spec.volumes.secret.items", where)
+		}
+		if projected := volume.Projected; projected != nil {
+			for _, source := range projected.Sources {
+				if cm := source.ConfigMap; cm != nil {
+					checkItems(inv.configMaps, "ConfigMap", cm.Name, cm.Items, cm.Optional, "spec.volumes.projected.sources", "spec.volumes.projected.sources", where)
+				}
+				if secret := source.Secret; secret != nil {
+					checkItems(inv.secrets, "Secret", secret.Name, secret.Items, secret.Optional, "spec.volumes.projected.sources", "spec.volumes.projected.sources", where)
+				}
+			}
+		}
+		if pvc := volume.PersistentVolumeClaim; pvc != nil && !isClaimTemplate(w, pvc.ClaimName) && !inv.claims[namespace+"/"+pvc.ClaimName] {
+			missing("spec.volumes.persistentVolumeClaim", "references the PersistentVolumeClaim %s which does not exist (%s).", pvc.ClaimName, where)
+		}
+	}
+
+	// an empty service account name is the default service account, created with the namespace
+	if name := serviceAccountName(spec); name != "" && !inv.serviceAccounts[namespace+"/"+name] {
+		missing("spec.serviceAccountName", "references the ServiceAccount %s which does not exist.", name)
+	}
+	for _, secret := range spec.ImagePullSecrets {
+		if _, ok := inv.secrets[namespace+"/"+secret.Name]; !ok {
+			missing("spec.imagePullSecrets", "references the image pull Secret %s which does not exist.", secret.Name)
+		}
+	}
+	if spec.PriorityClassName != "" && !inv.priorityClasses[spec.PriorityClassName] {
+		missing("spec.priorityClassName", "references the PriorityClass %s which does not exist.", spec.PriorityClassName)
+	}
+
+	return failures
+}
+
+func serviceAccountName(spec v1.PodSpec) string {
+	if spec.ServiceAccountName != "" {
+		return spec.ServiceAccountName
+	}
+	// serviceAccount is the deprecated alias of serviceAccountName
+	return spec.DeprecatedServiceAccount
+}
+
+func isClaimTemplate(w workload, claimName string) bool {
+	for _, name := range w.ClaimTemplates {
+		if name == claimName {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyzer
+
+import (
+	"context"
+	"sort"
+	"testing"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
+	"github.com/magiconair/properties/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestReferencesAnalyzer(t *testing.T) {
+	optional := true
+	clientset := fake.NewSimpleClientset(
+		&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "app-config", Namespace: "test"},
+			Data:       map[string]string{"LOG_LEVEL": "debug"},
+		},
+		&v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "test"},
+			Data:       map[string][]byte{"username": []byte("app")},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "test"},
+			Spec: appsv1.DeploymentSpec{
+				Template: v1.PodTemplateSpec{
+					Spec: v1.PodSpec{
+						ServiceAccountName: "web",
+						ImagePullSecrets:   []v1.LocalObjectReference{{Name: "registry"}},
+						PriorityClassName:  "high",
+						Containers: []v1.Container{
+							{
+								Name: "app",
+								Env: []v1.EnvVar{
+									{Name: "LOG_LEVEL", ValueFrom: &v1.EnvVarSource{ConfigMapKeyRef: &v1.ConfigMapKeySelector{
+										LocalObjectReference: v1.LocalObjectReference{Name: "app-config"}, Key: "LOG_LEVEL"}}},
+									{Name: "DB_PASSWORD", ValueFrom: &v1.EnvVarSource{SecretKeyRef: &v1.SecretKeySelector{
+										LocalObjectReference: v1.LocalObjectReference{Name: "db"}, Key: "password"}}},
+								},
+								EnvFrom: []v1.EnvFromSource{
+									{ConfigMapRef: &v1.ConfigMapEnvSource{LocalObjectReference: v1.LocalObjectReference{Name: "feature-flags"}, Optional: &optional}},
+								},
+							},
+						},
+						Volumes: []v1.Volume{
+							{Name: "data", VolumeSource: v1.VolumeSource{PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: "web-data"}}},
+							{Name: "settings", VolumeSource: v1.VolumeSource{ConfigMap: &v1.ConfigMapVolumeSource{
+								LocalObjectReference: v1.LocalObjectReference{Name: "web-settings"},
+								Items:                []v1.KeyToPath{{Key: "a", Path: "a"}, {Key: "b", Path: "b"}, {Key: "c", Path: "c"}}}}},
+							{Name: "config", VolumeSource: v1.VolumeSource{ConfigMap: &v1.ConfigMapVolumeSource{
+								LocalObjectReference: v1.LocalObjectReference{Name: "app-config"},
+								Items:                []v1.KeyToPath{{Key: "LOG_LEVEL", Path: "level"}, {Key: "TIMEOUT", Path: "timeout"}}}}},
+						},
+					},
+				},
+			},
+		},
+		&batchv1.CronJob{
+			ObjectMeta: metav1.ObjectMeta{Name: "report", Namespace: "test"},
+			Spec: batchv1.CronJobSpec{
+				JobTemplate: batchv1.JobTemplateSpec{
+					Spec: batchv1.JobSpec{
+						Template: v1.PodTemplateSpec{
+							Spec: v1.PodSpec{
+								Containers: []v1.Container{
+									{
+										Name: "report",
+										EnvFrom: []v1.EnvFromSource{
+											{SecretRef: &v1.SecretEnvSource{LocalObjectReference: v1.LocalObjectReference{Name: "smtp"}}},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		})
+
+	config := common.Analyzer{
+		Client: &kubernetes.Client{
+			Client: clientset,
+		},
+		Context:   context.Background(),
+		Namespace: "test",
+	}
+	analysisResults, err := ReferencesAnalyzer{}.Analyze(config)
+	if err != nil {
+		t.Error(err)
+	}
+	sort.Slice(analysisResults, func(i, j int) bool {
+		return analysisResults[i].Name < analysisResults[j].Name
+	})
+	assert.Equal(t, len(analysisResults), 2)
+
+	assert.Equal(t, analysisResults[0].Kind, "CronJob")
+	assert.Equal(t, analysisResults[0].Error[0].Text, "CronJob test/report references the Secret smtp which does not exist (envFrom of container report).")
+
+	assert.Equal(t, analysisResults[1].Kind, "Deployment")
+	assert.Equal(t, analysisResults[1].ParentObject, "Deployment/web")
+	var texts []string
+	for _, failure := range analysisResults[1].Error {
+		texts = append(texts, failure.Text)
+	}
+	assert.Equal(t, texts, []string{
+		"Deployment test/web references the key password of Secret db which does not exist (env DB_PASSWORD of container app).",
+		"Deployment test/web references the PersistentVolumeClaim web-data which does not exist (volume data).",
+		// a missing ConfigMap is reported once, not once more per item
+		"Deployment test/web references the ConfigMap web-settings which does not exist (volume settings).",
+		"Deployment test/web references the key TIMEOUT of ConfigMap app-config which does not exist (volume config).",
+		"Deployment test/web references the ServiceAccount web which does not exist.",
+		"Deployment test/web references the image pull Secret registry which does not exist.",
+		"Deployment test/web references the PriorityClass high which does not exist.",
+	})
+}
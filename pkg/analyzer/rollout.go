@@ -0,0 +1,404 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/util"
+	"github.com/spf13/viper"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// revisionAnnotation holds the revision of a Deployment and of its ReplicaSets
+const revisionAnnotation = "deployment.kubernetes.io/revision"
+
+// RolloutAnalyzer reports the Deployments and StatefulSets whose rollout is stuck, and the pod blocking it
+type RolloutAnalyzer struct{}
+
+// RolloutConfig is read from the analyzers.rollout key of the config file
+type RolloutConfig struct {
+	// StuckTimeout is how long a rollout can wait on a pod before it is reported
+	StuckTimeout string `mapstructure:"stucktimeout"`
+
+	stuckTimeout time.Duration
+}
+
+func getRolloutConfig() RolloutConfig {
+	config := RolloutConfig{
+		StuckTimeout: "15m",
+	}
+	_ = viper.UnmarshalKey("analyzers.rollout", &config)
+	config.stuckTimeout = configDuration("analyzers.rollout.stucktimeout", config.StuckTimeout)
+	return config
+}
+
+func (RolloutAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
+
+	kind := "Rollout"
+	apiDoc := kubernetes.K8sApiReference{
+		Kind: "Deployment",
+		ApiVersion: schema.GroupVersion{
+			Group:   "apps",
+			Version: "v1",
+		},
+		OpenapiSchema: a.OpenapiSchema,
+	}
+
+	AnalyzerErrorsMetric.DeletePartialMatch(map[string]string{
+		"analyzer_name": kind,
+	})
+	// the events are listed once for all the lookups of the analysis
+	a.Events = eventIndex(a)
+
+	config := getRolloutConfig()
+	podConfig := getPodConfig()
+
+	deployments, err := a.Client.GetClient().AppsV1().Deployments(a.Namespace).List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	statefulSets, err := a.Client.GetClient().AppsV1().StatefulSets(a.Namespace).List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	replicaSets, err := a.Client.GetClient().AppsV1().ReplicaSets(a.Namespace).List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	pods, err := a.Client.GetClient().CoreV1().Pods(a.Namespace).List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	pdbs, err := a.Client.GetClient().PolicyV1().PodDisruptionBudgets(a.Namespace).List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	report := func(resourceKind string, meta metav1.ObjectMeta, failures []common.Failure) {
+		if len(failures) == 0 {
+			return
+		}
+		AnalyzerErrorsMetric.WithLabelValues(kind, meta.Name, meta.Namespace).Set(float64(len(failures)))
+		parent, _ := util.GetParent(a.Client, meta)
+		a.Results = append(a.Results, common.Result{
+			Namespace:    meta.Namespace,
+			ResourceName: meta.Name,
+			Kind:         resourceKind,
+			Name:         fmt.Sprintf("%s/%s", meta.Namespace, meta.Name),
+			Error:        failures,
+			ParentObject: parent,
+		})
+	}
+
+	for _, deployment := range deployments.Items {
+		if SkipNamespace(deployment.Namespace) {
+			continue
+		}
+		var failures []common.Failure
+		fail := func(path string, text string) {
+			failures = append(failures, common.Failure{
+				Text:          text,
+				KubernetesDoc: apiDoc.GetApiDocV2(path),
+				Sensitive:     sensitiveValues(deployment.Namespace, deployment.Name),
+			})
+		}
+
+		var newRS *appsv1.ReplicaSet
+		var oldRSs []appsv1.ReplicaSet
+		for i, rs := range replicaSets.Items {
+			if !ownedBy(rs.ObjectMeta, deployment.UID) {
+				continue
+			}
+			if rs.Annotations[revisionAnnotation] == deployment.Annotations[revisionAnnotation] {
+				newRS = &replicaSets.Items[i]
+			} else if rs.Status.Replicas > 0 {
+				oldRSs = append(oldRSs, rs)
+			}
+		}
+
+		stuck := false
+		for _, condition := range deployment.Status.Conditions {
+			if condition.Type == appsv1.DeploymentProgressing && condition.Status == v1.ConditionFalse && condition.Reason == "ProgressDeadlineExceeded" {
+				stuck = true
+				fail("spec.progressDeadlineSeconds", fmt.Sprintf("Deployment %s exceeded its progress deadline: %s", deployment.Name, condition.Message))
+			}
+		}
+		if !stuck && newRS != nil && len(oldRSs) > 0 && newRS.Status.AvailableReplicas < newRS.Status.Replicas && config.stuckTimeout > 0 {
+			if rolling := time.Since(rolloutProgressTime(deployment, newRS)); rolling > config.stuckTimeout {
+				stuck = true
+				var names []string
+				var oldAvailable int32
+				for _, rs := range oldRSs {
+					names = append(names, rs.Name)
+					oldAvailable += rs.Status.AvailableReplicas
+				}
+				fail("status.conditions", fmt.Sprintf("Deployment %s has not progressed for %s, the new ReplicaSet %s has %d/%d pods available while the old ReplicaSets %s still run %d.",
+					deployment.Name, rolling.Round(time.Minute), newRS.Name, newRS.Status.AvailableReplicas, newRS.Status.Replicas, strings.Join(names, ", "), oldAvailable))
+			}
+		}
+		if stuck && newRS != nil {
+			var candidates []v1.Pod
+			for _, pod := range pods.Items {
+				if ownedBy(pod.ObjectMeta, newRS.UID) {
+					candidates = append(candidates, pod)
+				}
+			}
+			sort.Slice(candidates, func(i, j int) bool { return candidates[i].Name < candidates[j].Name })
+			for _, pod := range candidates {
+				if !podReady(pod) {
+					failures = append(failures, blockingPodFailure(a, pod, podConfig))
+					break
+				}
+			}
+		}
+
+		for _, pdb := range pdbs.Items {
+			if text := rolloutBudgetConflict(deployment, pdb); text != "" {
+				fail("spec.strategy.rollingUpdate", text)
+			}
+		}
+
+		report("Deployment", deployment.ObjectMeta, failures)
+	}
+
+	apiDoc.Kind = "StatefulSet"
+	for _, sts := range statefulSets.Items {
+		if SkipNamespace(sts.Namespace) || sts.Status.UpdateRevision == "" || sts.Status.UpdateRevision == sts.Status.CurrentRevision {
+			continue
+		}
+		// the pods of an OnDelete StatefulSet are only updated when they are deleted
+		if sts.Spec.UpdateStrategy.Type == appsv1.OnDeleteStatefulSetStrategyType {
+			continue
+		}
+		pod, ordinal, updated := statefulSetBlockingPod(sts, pods.Items)
+		if pod == nil || config.stuckTimeout <= 0 {
+			continue
+		}
+		waiting := time.Since(notReadySince(*pod))
+		if waiting <= config.stuckTimeout {
+			continue
+		}
+		failures := []common.Failure{
+			{
+				Text: fmt.Sprintf("StatefulSet %s has been updating from revision %s to %s with %d/%d pods updated, the rollout has been stuck on ordinal %d for %s.",
+					sts.Name, sts.Status.CurrentRevision, sts.Status.UpdateRevision, updated, statefulSetReplicas(sts), ordinal, waiting.Round(time.Minute)),
+				KubernetesDoc: apiDoc.GetApiDocV2("spec.updateStrategy"),
+				Sensitive:     sensitiveValues(sts.Namespace, sts.Name),
+			},
+			blockingPodFailure(a, *pod, podConfig),
+		}
+		// a pod created from a broken revision is not replaced when the template is fixed
+		if pod.Labels[appsv1.ControllerRevisionHashLabelKey] == sts.Status.UpdateRevision && sts.Spec.PodManagementPolicy != appsv1.ParallelPodManagement {
+			failures[1].Text += " The StatefulSet controller does not recreate it, it has to be deleted once the template is fixed."
+		}
+		report("StatefulSet", sts.ObjectMeta, failures)
+	}
+
+	return a.Results, nil
+}
+
+// ownedBy tells whether an object is controlled by the object with the uid
+func ownedBy(meta metav1.ObjectMeta, uid types.UID) bool {
+	for _, owner := range meta.OwnerReferences {
+		if owner.UID == uid {
+			return true
+		}
+	}
+	return false
+}
+
+func podReady(pod v1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodReady {
+			return condition.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// notReadySince returns when a pod stopped being ready, or its creation when it never was
+func notReadySince(pod v1.Pod) time.Time {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodReady && !condition.LastTransitionTime.IsZero() {
+			return condition.LastTransitionTime.Time
+		}
+	}
+	return pod.CreationTimestamp.Time
+}
+
+// rolloutProgressTime returns when the rollout of a Deployment last progressed. The Progressing condition is
+// updated when a rollout starts, a rollback to an old ReplicaSet included, and whenever more pods are available.
+func rolloutProgressTime(deployment appsv1.Deployment, newRS *appsv1.ReplicaSet) time.Time {
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type == appsv1.DeploymentProgressing && !condition.LastUpdateTime.IsZero() {
+			return condition.LastUpdateTime.Time
+		}
+	}
+	return newRS.CreationTimestamp.Time
+}
+
+// blockingPodFailure explains why a pod holds a rollout back, its own failures are reported by the Pod analyzer
+func blockingPodFailure(a common.Analyzer, pod v1.Pod, config PodConfig) common.Failure {
+	reason := "it is not ready"
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == v1.PodScheduled && condition.Status == v1.ConditionFalse && condition.Message != "" {
+			reason = "it cannot be scheduled: " + condition.Message
+		}
+	}
+	if reason == "it is not ready" {
+		for _, status := range append(append([]v1.ContainerStatus{}, pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...) {
+			if status.State.Waiting != nil {
+				if hint, ok := containerWaitingReasons[status.State.Waiting.Reason]; ok {
+					reason = containerWaitingFailure("Container", append(append([]v1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...), status, hint).Text
+					break
+				}
+			}
+		}
+	}
+	if reason == "it is not ready" {
+		if failures := analyzeContainers(pod, config); len(failures) > 0 {
+			reason = failures[0].Text
+		} else if event, err := FetchLatestEvent(a, "Pod", pod.ObjectMeta); err == nil && event != nil && event.Reason == "Unhealthy" {
+			reason = "its probe fails: " + event.Message
+		}
+	}
+	return common.Failure{
+		Text: fmt.Sprintf("The rollout is blocked by Pod %s/%s: %s. See the Pod %s/%s for its failures.",
+			pod.Namespace, pod.Name, strings.TrimSuffix(reason, "."), pod.Namespace, pod.Name),
+		Sensitive: podSensitive(pod),
+	}
+}
+
+// rolloutBudgetConflict describes how the rolling update of a Deployment breaks a PodDisruptionBudget selecting
+// its pods. The Deployment controller does not evict, it deletes the pods whatever the budget
+func rolloutBudgetConflict(deployment appsv1.Deployment, pdb policyv1.PodDisruptionBudget) string {
+	if deployment.Spec.Strategy.Type == appsv1.RecreateDeploymentStrategyType || pdb.Namespace != deployment.Namespace {
+		return ""
+	}
+	selector, err := metav1.LabelSelectorAsSelector(pdb.Spec.Selector)
+	if err != nil || selector.Empty() || !selector.Matches(labels.Set(deployment.Spec.Template.Labels)) {
+		return ""
+	}
+	replicas := int32(1)
+	if deployment.Spec.Replicas != nil {
+		replicas = *deployment.Spec.Replicas
+	}
+	if replicas == 0 {
+		return ""
+	}
+	maxUnavailable := intstr.FromString("25%")
+	maxSurge := intstr.FromString("25%")
+	if rollingUpdate := deployment.Spec.Strategy.RollingUpdate; rollingUpdate != nil {
+		if rollingUpdate.MaxUnavailable != nil {
+			maxUnavailable = *rollingUpdate.MaxUnavailable
+		}
+		if rollingUpdate.MaxSurge != nil {
+			maxSurge = *rollingUpdate.MaxSurge
+		}
+	}
+	unavailable, err := intstr.GetScaledValueFromIntOrPercent(&maxUnavailable, int(replicas), false)
+	if err != nil {
+		return ""
+	}
+	surge, err := intstr.GetScaledValueFromIntOrPercent(&maxSurge, int(replicas), true)
+	if err != nil {
+		return ""
+	}
+	allowed, ok := pdbAllowedUnavailable(pdb, replicas)
+	if !ok {
+		return ""
+	}
+
+	switch {
+	case surge == 0 && allowed == 0:
+		return fmt.Sprintf("Deployment %s rolls out without surge but the PodDisruptionBudget %s allows no unavailable pod, no rollout can keep the budget. Set maxSurge to start the new pods before the old ones are removed.",
+			deployment.Name, pdb.Name)
+	case int32(unavailable) > allowed:
+		return fmt.Sprintf("Deployment %s can take down %d of its %d pods during a rollout (maxUnavailable %s) but the PodDisruptionBudget %s allows %d, the rollout breaks the budget and blocks the node drains meanwhile.",
+			deployment.Name, unavailable, replicas, maxUnavailable.String(), pdb.Name, allowed)
+	}
+	return ""
+}
+
+// pdbAllowedUnavailable returns how many of the replicas a PodDisruptionBudget allows to be unavailable
+func pdbAllowedUnavailable(pdb policyv1.PodDisruptionBudget, replicas int32) (int32, bool) {
+	var allowed int
+	switch {
+	case pdb.Spec.MinAvailable != nil:
+		minAvailable, err := intstr.GetScaledValueFromIntOrPercent(pdb.Spec.MinAvailable, int(replicas), true)
+		if err != nil {
+			return 0, false
+		}
+		allowed = int(replicas) - minAvailable
+	case pdb.Spec.MaxUnavailable != nil:
+		maxUnavailable, err := intstr.GetScaledValueFromIntOrPercent(pdb.Spec.MaxUnavailable, int(replicas), true)
+		if err != nil {
+			return 0, false
+		}
+		allowed = maxUnavailable
+	default:
+		return 0, false
+	}
+	if allowed < 0 {
+		allowed = 0
+	}
+	return int32(allowed), true
+}
+
+func statefulSetReplicas(sts appsv1.StatefulSet) int32 {
+	if sts.Spec.Replicas == nil {
+		return 1
+	}
+	return *sts.Spec.Replicas
+}
+
+// statefulSetBlockingPod returns the pod a rolling update waits for, with its ordinal and the number of updated
+// pods. The controller updates the ordinals from the highest down and waits for every pod to be ready
+func statefulSetBlockingPod(sts appsv1.StatefulSet, pods []v1.Pod) (*v1.Pod, int, int) {
+	byOrdinal := map[int]v1.Pod{}
+	updated := 0
+	for _, pod := range pods {
+		if pod.Namespace != sts.Namespace || !ownedBy(pod.ObjectMeta, sts.UID) || !strings.HasPrefix(pod.Name, sts.Name+"-") {
+			continue
+		}
+		ordinal, err := strconv.Atoi(strings.TrimPrefix(pod.Name, sts.Name+"-"))
+		if err != nil {
+			continue
+		}
+		byOrdinal[ordinal] = pod
+		if pod.Labels[appsv1.ControllerRevisionHashLabelKey] == sts.Status.UpdateRevision {
+			updated++
+		}
+	}
+	for ordinal := int(statefulSetReplicas(sts)) - 1; ordinal >= 0; ordinal-- {
+		if pod, ok := byOrdinal[ordinal]; ok && !podReady(pod) {
+			return &pod, ordinal, updated
+		}
+	}
+	return nil, 0, updated
+}
@@ -0,0 +1,190 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyzer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
+	"github.com/magiconair/properties/assert"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestRolloutAnalyzer(t *testing.T) {
+	owner := func(kind string, name string, uid types.UID) []metav1.OwnerReference {
+		return []metav1.OwnerReference{{Kind: kind, Name: name, UID: uid}}
+	}
+	replicas := func(n int32) *int32 { return &n }
+	zero := intstr.FromInt32(0)
+	one := intstr.FromInt32(1)
+	all := intstr.FromInt32(3)
+	hourAgo := metav1.NewTime(time.Now().Add(-time.Hour))
+
+	clientset := fake.NewSimpleClientset(
+		// the new pod of web crashes and the rollout passed its deadline
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "test", UID: "web", Annotations: map[string]string{revisionAnnotation: "2"}},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: replicas(3),
+				Strategy: appsv1.DeploymentStrategy{
+					Type:          appsv1.RollingUpdateDeploymentStrategyType,
+					RollingUpdate: &appsv1.RollingUpdateDeployment{MaxSurge: &zero, MaxUnavailable: &one},
+				},
+				Template: v1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "web"}}},
+			},
+			Status: appsv1.DeploymentStatus{
+				Conditions: []appsv1.DeploymentCondition{
+					{
+						Type:    appsv1.DeploymentProgressing,
+						Status:  v1.ConditionFalse,
+						Reason:  "ProgressDeadlineExceeded",
+						Message: `ReplicaSet "web-2" has timed out progressing.`,
+					},
+				},
+			},
+		},
+		&appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-2", Namespace: "test", UID: "web-2", OwnerReferences: owner("Deployment", "web", "web"),
+				Annotations: map[string]string{revisionAnnotation: "2"}},
+			Status: appsv1.ReplicaSetStatus{Replicas: 1},
+		},
+		&appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "test", UID: "web-1", OwnerReferences: owner("Deployment", "web", "web"),
+				Annotations: map[string]string{revisionAnnotation: "1"}},
+			Status: appsv1.ReplicaSetStatus{Replicas: 2, AvailableReplicas: 2},
+		},
+		&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-2-abc", Namespace: "test", OwnerReferences: owner("ReplicaSet", "web-2", "web-2")},
+			Status: v1.PodStatus{
+				ContainerStatuses: []v1.ContainerStatus{
+					{
+						Name:  "web",
+						State: v1.ContainerState{Waiting: &v1.ContainerStateWaiting{Reason: "CrashLoopBackOff", Message: "back-off 5m0s restarting failed container"}},
+					},
+				},
+			},
+		},
+		&policyv1.PodDisruptionBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "test"},
+			Spec: policyv1.PodDisruptionBudgetSpec{
+				MinAvailable: &all,
+				Selector:     &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+			},
+		},
+		// the new pod of api cannot be scheduled, the rollout is within its deadline but older than the timeout,
+		// it rolls back to a ReplicaSet created long before
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "test", UID: "api", Annotations: map[string]string{revisionAnnotation: "2"}},
+			Spec:       appsv1.DeploymentSpec{Replicas: replicas(2)},
+			Status: appsv1.DeploymentStatus{
+				Conditions: []appsv1.DeploymentCondition{
+					{Type: appsv1.DeploymentProgressing, Status: v1.ConditionTrue, Reason: "ReplicaSetUpdated", LastUpdateTime: hourAgo},
+				},
+			},
+		},
+		&appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "api-2", Namespace: "test", UID: "api-2", OwnerReferences: owner("Deployment", "api", "api"),
+				Annotations: map[string]string{revisionAnnotation: "2"}, CreationTimestamp: metav1.NewTime(time.Now().Add(-30 * 24 * time.Hour))},
+			Status: appsv1.ReplicaSetStatus{Replicas: 2, AvailableReplicas: 1},
+		},
+		&appsv1.ReplicaSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "api-1", Namespace: "test", UID: "api-1", OwnerReferences: owner("Deployment", "api", "api"),
+				Annotations: map[string]string{revisionAnnotation: "1"}},
+			Status: appsv1.ReplicaSetStatus{Replicas: 1, AvailableReplicas: 1},
+		},
+		&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "api-2-abc", Namespace: "test", OwnerReferences: owner("ReplicaSet", "api-2", "api-2")},
+			Status:     v1.PodStatus{Conditions: []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionTrue}}},
+		},
+		&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "api-2-def", Namespace: "test", OwnerReferences: owner("ReplicaSet", "api-2", "api-2")},
+			Status: v1.PodStatus{
+				Conditions: []v1.PodCondition{{Type: v1.PodScheduled, Status: v1.ConditionFalse, Message: "0/3 nodes are available: 3 Insufficient cpu."}},
+			},
+		},
+		// the updated ordinal 1 of db keeps failing its readiness
+		&appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "test", UID: "db"},
+			Spec:       appsv1.StatefulSetSpec{Replicas: replicas(3)},
+			Status:     appsv1.StatefulSetStatus{CurrentRevision: "db-1", UpdateRevision: "db-2"},
+		},
+		&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "db-2", Namespace: "test", OwnerReferences: owner("StatefulSet", "db", "db"),
+				Labels: map[string]string{appsv1.ControllerRevisionHashLabelKey: "db-2"}},
+			Status: v1.PodStatus{Conditions: []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionTrue}}},
+		},
+		&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "db-1", Namespace: "test", OwnerReferences: owner("StatefulSet", "db", "db"),
+				Labels: map[string]string{appsv1.ControllerRevisionHashLabelKey: "db-2"}},
+			Status: v1.PodStatus{
+				Conditions: []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionFalse, LastTransitionTime: hourAgo}},
+				ContainerStatuses: []v1.ContainerStatus{
+					{
+						Name:                 "db",
+						State:                v1.ContainerState{Running: &v1.ContainerStateRunning{}},
+						LastTerminationState: v1.ContainerState{Terminated: &v1.ContainerStateTerminated{ExitCode: 1, Reason: "Error"}},
+					},
+				},
+			},
+		},
+		&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "db-0", Namespace: "test", OwnerReferences: owner("StatefulSet", "db", "db"),
+				Labels: map[string]string{appsv1.ControllerRevisionHashLabelKey: "db-1"}},
+			Status: v1.PodStatus{Conditions: []v1.PodCondition{{Type: v1.PodReady, Status: v1.ConditionTrue}}},
+		},
+	)
+
+	config := common.Analyzer{
+		Client: &kubernetes.Client{
+			Client: clientset,
+		},
+		Context:   context.Background(),
+		Namespace: "test",
+	}
+	analysisResults, err := RolloutAnalyzer{}.Analyze(config)
+	if err != nil {
+		t.Error(err)
+	}
+
+	texts := map[string][]string{}
+	for _, result := range analysisResults {
+		for _, failure := range result.Error {
+			texts[result.Kind+" "+result.Name] = append(texts[result.Kind+" "+result.Name], failure.Text)
+		}
+	}
+	assert.Equal(t, texts, map[string][]string{
+		"Deployment test/web": {
+			`Deployment web exceeded its progress deadline: ReplicaSet "web-2" has timed out progressing.`,
+			"The rollout is blocked by Pod test/web-2-abc: Container web is waiting with reason CrashLoopBackOff: back-off 5m0s restarting failed container. See the Pod test/web-2-abc for its failures.",
+			"Deployment web rolls out without surge but the PodDisruptionBudget web allows no unavailable pod, no rollout can keep the budget. Set maxSurge to start the new pods before the old ones are removed.",
+		},
+		"Deployment test/api": {
+			"Deployment api has not progressed for 1h0m0s, the new ReplicaSet api-2 has 1/2 pods available while the old ReplicaSets api-1 still run 1.",
+			"The rollout is blocked by Pod test/api-2-def: it cannot be scheduled: 0/3 nodes are available: 3 Insufficient cpu. See the Pod test/api-2-def for its failures.",
+		},
+		"StatefulSet test/db": {
+			"StatefulSet db has been updating from revision db-1 to db-2 with 2/3 pods updated, the rollout has been stuck on ordinal 1 for 1h0m0s.",
+			"The rollout is blocked by Pod test/db-1: Container db terminated with exit code 1, reason Error. See the Pod test/db-1 for its failures. The StatefulSet controller does not recreate it, it has to be deleted once the template is fixed.",
+		},
+	})
+}
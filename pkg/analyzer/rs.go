@@ -56,6 +56,11 @@ func (ReplicaSetAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
 						Sensitive: []common.Sensitive{},
 					})
 
+					// point to the quota rejecting the pods, the message only has its name
+					if name := quotaFromMessage(rsStatus.Message); name != "" {
+						failures = append(failures, quotaFailure(a, rs.Namespace, name))
+					}
+
 				}
 			}
 		}
@@ -85,3 +90,26 @@ func (ReplicaSetAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
 	}
 	return a.Results, nil
 }
+
+func quotaFailure(a common.Analyzer, namespace string, name string) common.Failure {
+	text := fmt.Sprintf("The pods are rejected by the ResourceQuota %s/%s.", namespace, name)
+	quota, err := a.Client.GetClient().CoreV1().ResourceQuotas(namespace).Get(a.Context, name, metav1.GetOptions{})
+	if err == nil {
+		if exhausted := exhaustedResources(*quota); exhausted != "" {
+			text = fmt.Sprintf("The pods are rejected by the ResourceQuota %s/%s, it is exhausted for %s.", namespace, name, exhausted)
+		}
+	}
+	return common.Failure{
+		Text: text,
+		Sensitive: []common.Sensitive{
+			{
+				Unmasked: namespace,
+				Masked:   util.MaskString(namespace),
+			},
+			{
+				Unmasked: name,
+				Masked:   util.MaskString(name),
+			},
+		},
+	}
+}
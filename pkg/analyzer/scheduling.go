@@ -0,0 +1,642 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyzer
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/util"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// secZonePrefix is the prefix of the labels placing the nodes in a security zone
+const secZonePrefix = "rdei.io/sec-zone-"
+
+// schedulingCluster is the state of the cluster a pod is scheduled against
+type schedulingCluster struct {
+	nodes      []v1.Node
+	nodeByName map[string]v1.Node
+	// pods are the pods bound to a node and not completed
+	pods       []v1.Pod
+	pvcs       map[string]v1.PersistentVolumeClaim
+	pvs        map[string]v1.PersistentVolume
+	namespaces map[string]labels.Set
+}
+
+// predicateFailure is a scheduling predicate a node does not pass, with the smallest change of the pod fixing it
+type predicateFailure struct {
+	Predicate  string
+	Reason     string
+	Suggestion string
+	// Names are the objects named by the reason, they are masked when the text is anonymized
+	Names []string
+}
+
+// loadSchedulingCluster reads the nodes, the bound pods and the volumes of the whole cluster
+func loadSchedulingCluster(a common.Analyzer) (*schedulingCluster, error) {
+	nodes, err := a.Client.GetClient().CoreV1().Nodes().List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	pods, err := a.Client.GetClient().CoreV1().Pods("").List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	c := newSchedulingCluster(nodes.Items, pods.Items)
+	if err := c.loadVolumesAndNamespaces(a); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// loadVolumesAndNamespaces reads the claims, the volumes and the namespace labels the volume zone and the pod
+// affinity predicates need
+func (c *schedulingCluster) loadVolumesAndNamespaces(a common.Analyzer) error {
+	pvcs, err := a.Client.GetClient().CoreV1().PersistentVolumeClaims("").List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	pvs, err := a.Client.GetClient().CoreV1().PersistentVolumes().List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+	namespaces, err := a.Client.GetClient().CoreV1().Namespaces().List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	for _, pvc := range pvcs.Items {
+		c.pvcs[pvc.Namespace+"/"+pvc.Name] = pvc
+	}
+	for _, pv := range pvs.Items {
+		c.pvs[pv.Name] = pv
+	}
+	for _, ns := range namespaces.Items {
+		c.namespaces[ns.Name] = labels.Set(ns.Labels)
+	}
+	return nil
+}
+
+// newSchedulingCluster indexes the nodes and the pods bound to them, the volumes and namespaces are loaded apart
+func newSchedulingCluster(nodes []v1.Node, pods []v1.Pod) *schedulingCluster {
+	c := &schedulingCluster{
+		nodes:      nodes,
+		nodeByName: map[string]v1.Node{},
+		pvcs:       map[string]v1.PersistentVolumeClaim{},
+		pvs:        map[string]v1.PersistentVolume{},
+		namespaces: map[string]labels.Set{},
+	}
+	for _, node := range nodes {
+		c.nodeByName[node.Name] = node
+	}
+	for _, pod := range pods {
+		if pod.Spec.NodeName != "" && pod.Status.Phase != v1.PodSucceeded && pod.Status.Phase != v1.PodFailed {
+			c.pods = append(c.pods, pod)
+		}
+	}
+	return c
+}
+
+// onlyCordoned tells whether the cordon is the only predicate of the node the pod fails
+func (c *schedulingCluster) onlyCordoned(pod v1.Pod, node v1.Node) bool {
+	failures := c.fit(pod, node)
+	for _, failure := range failures {
+		if failure.Predicate != "NodeUnschedulable" {
+			return false
+		}
+	}
+	return len(failures) > 0
+}
+
+// maxExplainGroups is the number of groups of nodes failing alike listed by explain, the others are summarized
+const maxExplainGroups = 10
+
+// explain evaluates a pod against every node, it returns a table of the failed predicates by group of nodes failing
+// alike and the change of the pod spec letting it run on the node closest to fit, with the names of the nodes and
+// of the other objects the text mentions
+func (c *schedulingCluster) explain(pod v1.Pod) (string, []string) {
+	if len(c.nodes) == 0 {
+		return "", nil
+	}
+	nodes := append([]v1.Node{}, c.nodes...)
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+
+	// the nodes are grouped by their rows, in the order of their first node
+	type nodeGroup struct {
+		nodes []string
+		rows  []string
+	}
+	var groups []*nodeGroup
+	groupByRows := map[string]*nodeGroup{}
+	closest := ""
+	var closestFailures []predicateFailure
+	var names []string
+	named := map[string]bool{}
+	name := func(values ...string) {
+		for _, value := range values {
+			if !named[value] {
+				named[value] = true
+				names = append(names, value)
+			}
+		}
+	}
+	for _, node := range nodes {
+		name(node.Name)
+		failures := c.fit(pod, node)
+		rows := []string{"-\tfits, the scheduler has not retried the pod since the node changed"}
+		if len(failures) > 0 {
+			rows = nil
+			for _, failure := range failures {
+				rows = append(rows, fmt.Sprintf("%s\t%s", failure.Predicate, failure.Reason))
+				name(failure.Names...)
+			}
+			if closest == "" || len(failures) < len(closestFailures) {
+				closest, closestFailures = node.Name, failures
+			}
+		}
+		key := strings.Join(rows, "\n")
+		group, ok := groupByRows[key]
+		if !ok {
+			group = &nodeGroup{rows: rows}
+			groupByRows[key] = group
+			groups = append(groups, group)
+		}
+		group.nodes = append(group.nodes, node.Name)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Pod %s was evaluated against the %d nodes:\n", pod.Name, len(nodes))
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NODES\tPREDICATE\tREASON")
+	for i, group := range groups {
+		if i == maxExplainGroups {
+			omitted := 0
+			for _, group := range groups[i:] {
+				omitted += len(group.nodes)
+			}
+			fmt.Fprintf(w, "%d more nodes\t-\tfail in %d other ways\n", omitted, len(groups)-i)
+			break
+		}
+		for _, row := range group.rows {
+			fmt.Fprintf(w, "%s\t%s\n", nodeGroupString(group.nodes), row)
+		}
+	}
+	_ = w.Flush()
+
+	if closest != "" {
+		var suggestions []string
+		for _, failure := range closestFailures {
+			suggestions = append(suggestions, failure.Suggestion)
+		}
+		fmt.Fprintf(&b, "Suggested change to run on %s, the closest node: %s.", closest, strings.Join(suggestions, "; "))
+	}
+	return b.String(), names
+}
+
+// nodeGroupString names the nodes of a group, the large groups by their count and first nodes
+func nodeGroupString(nodes []string) string {
+	if len(nodes) <= 3 {
+		return strings.Join(nodes, ", ")
+	}
+	return fmt.Sprintf("%d nodes (%s, ...)", len(nodes), strings.Join(nodes[:2], ", "))
+}
+
+// fit returns the predicates of the scheduler a node does not pass for a pod
+func (c *schedulingCluster) fit(pod v1.Pod, node v1.Node) []predicateFailure {
+	var failures []predicateFailure
+	for _, predicate := range []func(v1.Pod, v1.Node) []predicateFailure{
+		c.fitUnschedulable,
+		c.fitNodeSelector,
+		c.fitNodeAffinity,
+		c.fitTaints,
+		c.fitResources,
+		c.fitPodAffinity,
+		c.fitTopologySpread,
+		c.fitVolumeZone,
+	} {
+		failures = append(failures, predicate(pod, node)...)
+	}
+	return failures
+}
+
+func (c *schedulingCluster) fitUnschedulable(pod v1.Pod, node v1.Node) []predicateFailure {
+	taint := v1.Taint{Key: v1.TaintNodeUnschedulable, Effect: v1.TaintEffectNoSchedule}
+	if !node.Spec.Unschedulable || isTaintTolerated(taint, []v1.Pod{pod}) {
+		return nil
+	}
+	return []predicateFailure{{
+		Predicate:  "NodeUnschedulable",
+		Reason:     "the node is cordoned",
+		Suggestion: "uncordon the node, the pod spec cannot change it",
+	}}
+}
+
+func (c *schedulingCluster) fitNodeSelector(pod v1.Pod, node v1.Node) []predicateFailure {
+	keys := make([]string, 0, len(pod.Spec.NodeSelector))
+	for key := range pod.Spec.NodeSelector {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var failures []predicateFailure
+	for _, key := range keys {
+		value := pod.Spec.NodeSelector[key]
+		actual, ok := node.Labels[key]
+		if ok && actual == value {
+			continue
+		}
+		reason := fmt.Sprintf("the node has no label %s=%s", key, value)
+		if ok {
+			reason = fmt.Sprintf("the node has %s=%s instead of %s", key, actual, value)
+		}
+		suggestion := fmt.Sprintf("remove %s from spec.nodeSelector", key)
+		// the security zone of a pod is a requirement, not a preference to drop
+		if strings.HasPrefix(key, secZonePrefix) {
+			zone := strings.TrimPrefix(key, secZonePrefix)
+			reason = fmt.Sprintf("the node is not in the security zone %s", zone)
+			suggestion = fmt.Sprintf("none, add capacity to the security zone %s where the pod has to run", zone)
+		}
+		failures = append(failures, predicateFailure{
+			Predicate:  "NodeSelector",
+			Reason:     reason,
+			Suggestion: suggestion,
+		})
+	}
+	return failures
+}
+
+func (c *schedulingCluster) fitNodeAffinity(pod v1.Pod, node v1.Node) []predicateFailure {
+	affinity := pod.Spec.Affinity
+	if affinity == nil || affinity.NodeAffinity == nil || affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		return nil
+	}
+	required := affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution
+	if nodeSelectorMatches(required, node) {
+		return nil
+	}
+	return []predicateFailure{{
+		Predicate:  "NodeAffinity",
+		Reason:     fmt.Sprintf("the node matches none of the required terms: %s", nodeSelectorString(required)),
+		Suggestion: "move the required node affinity to preferredDuringSchedulingIgnoredDuringExecution",
+	}}
+}
+
+func (c *schedulingCluster) fitTaints(pod v1.Pod, node v1.Node) []predicateFailure {
+	var failures []predicateFailure
+	for _, taint := range node.Spec.Taints {
+		// the cordon is reported by NodeUnschedulable, the preferences do not prevent the scheduling
+		if taint.Key == v1.TaintNodeUnschedulable || taint.Effect == v1.TaintEffectPreferNoSchedule {
+			continue
+		}
+		if isTaintTolerated(taint, []v1.Pod{pod}) {
+			continue
+		}
+		toleration := fmt.Sprintf("{key: %s, operator: Equal, value: %s, effect: %s}", taint.Key, taint.Value, taint.Effect)
+		if taint.Value == "" {
+			toleration = fmt.Sprintf("{key: %s, operator: Exists, effect: %s}", taint.Key, taint.Effect)
+		}
+		failures = append(failures, predicateFailure{
+			Predicate:  "TaintToleration",
+			Reason:     fmt.Sprintf("the taint %s is not tolerated", taint.ToString()),
+			Suggestion: "add the toleration " + toleration,
+		})
+	}
+	return failures
+}
+
+func (c *schedulingCluster) fitResources(pod v1.Pod, node v1.Node) []predicateFailure {
+	requests := podRequests(pod)
+	used := v1.ResourceList{}
+	count := int64(0)
+	for _, other := range c.pods {
+		if other.Spec.NodeName != node.Name {
+			continue
+		}
+		count++
+		for name, quantity := range podRequests(other) {
+			total := used[name]
+			total.Add(quantity)
+			used[name] = total
+		}
+	}
+
+	var failures []predicateFailure
+	if allocatable, ok := node.Status.Allocatable[v1.ResourcePods]; ok && count+1 > allocatable.Value() {
+		failures = append(failures, predicateFailure{
+			Predicate:  "NodeResourcesFit",
+			Reason:     fmt.Sprintf("the node already runs its maximum of %d pods", allocatable.Value()),
+			Suggestion: "none, the node is full",
+		})
+	}
+	names := make([]string, 0, len(requests))
+	for name := range requests {
+		names = append(names, string(name))
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		request := requests[v1.ResourceName(name)]
+		if request.IsZero() {
+			continue
+		}
+		allocatable, ok := node.Status.Allocatable[v1.ResourceName(name)]
+		if !ok {
+			failures = append(failures, predicateFailure{
+				Predicate:  "NodeResourcesFit",
+				Reason:     fmt.Sprintf("the node has no %s", name),
+				Suggestion: fmt.Sprintf("remove the %s request", name),
+			})
+			continue
+		}
+		free := allocatable.DeepCopy()
+		free.Sub(used[v1.ResourceName(name)])
+		if request.Cmp(free) <= 0 {
+			continue
+		}
+		suggestion := fmt.Sprintf("lower the %s request to %s", name, free.String())
+		if free.Sign() <= 0 {
+			free = resource.Quantity{}
+			suggestion = fmt.Sprintf("none, all the %s of the node is requested", name)
+		}
+		failures = append(failures, predicateFailure{
+			Predicate:  "NodeResourcesFit",
+			Reason:     fmt.Sprintf("insufficient %s, the pod requests %s and %s of %s are free", name, request.String(), free.String(), allocatable.String()),
+			Suggestion: suggestion,
+		})
+	}
+	return failures
+}
+
+func (c *schedulingCluster) fitPodAffinity(pod v1.Pod, node v1.Node) []predicateFailure {
+	var failures []predicateFailure
+	if affinity := pod.Spec.Affinity; affinity != nil && affinity.PodAffinity != nil {
+		for _, term := range affinity.PodAffinity.RequiredDuringSchedulingIgnoredDuringExecution {
+			domain, ok := node.Labels[term.TopologyKey]
+			if !ok {
+				failures = append(failures, predicateFailure{
+					Predicate:  "InterPodAffinity",
+					Reason:     fmt.Sprintf("the node has no %s label for the pod affinity", term.TopologyKey),
+					Suggestion: "move the pod affinity to preferredDuringSchedulingIgnoredDuringExecution",
+				})
+				continue
+			}
+			matching := c.matchingPods(pod, term)
+			// the first pod of a group matching its own affinity can go anywhere
+			if len(matching) == 0 && c.termMatches(pod, term, pod) {
+				continue
+			}
+			if len(c.inDomain(matching, term.TopologyKey, domain)) == 0 {
+				failures = append(failures, predicateFailure{
+					Predicate:  "InterPodAffinity",
+					Reason:     fmt.Sprintf("no pod matching %s runs in its %s %s", metav1.FormatLabelSelector(term.LabelSelector), term.TopologyKey, domain),
+					Suggestion: "move the pod affinity to preferredDuringSchedulingIgnoredDuringExecution",
+				})
+			}
+		}
+	}
+	if affinity := pod.Spec.Affinity; affinity != nil && affinity.PodAntiAffinity != nil {
+		for _, term := range affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution {
+			domain, ok := node.Labels[term.TopologyKey]
+			if !ok {
+				continue
+			}
+			if conflicting := c.inDomain(c.matchingPods(pod, term), term.TopologyKey, domain); len(conflicting) > 0 {
+				failures = append(failures, predicateFailure{
+					Predicate: "InterPodAffinity",
+					Reason: fmt.Sprintf("the pod %s/%s matching the anti-affinity %s runs in its %s %s",
+						conflicting[0].Namespace, conflicting[0].Name, metav1.FormatLabelSelector(term.LabelSelector), term.TopologyKey, domain),
+					Suggestion: "move the pod anti-affinity to preferredDuringSchedulingIgnoredDuringExecution",
+					Names:      []string{conflicting[0].Namespace, conflicting[0].Name},
+				})
+			}
+		}
+	}
+	// the anti-affinity of the running pods also keeps the pod away
+	for _, other := range c.pods {
+		if other.Spec.Affinity == nil || other.Spec.Affinity.PodAntiAffinity == nil {
+			continue
+		}
+		for _, term := range other.Spec.Affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution {
+			domain, ok := node.Labels[term.TopologyKey]
+			if !ok || c.nodeByName[other.Spec.NodeName].Labels[term.TopologyKey] != domain || !c.termMatches(other, term, pod) {
+				continue
+			}
+			failures = append(failures, predicateFailure{
+				Predicate:  "InterPodAffinity",
+				Reason:     fmt.Sprintf("the anti-affinity of the pod %s/%s running in its %s %s excludes the pod", other.Namespace, other.Name, term.TopologyKey, domain),
+				Suggestion: "change the pod labels matched by the anti-affinity of " + other.Name,
+				Names:      []string{other.Namespace, other.Name},
+			})
+		}
+	}
+	return failures
+}
+
+func (c *schedulingCluster) fitTopologySpread(pod v1.Pod, node v1.Node) []predicateFailure {
+	var failures []predicateFailure
+	for _, constraint := range pod.Spec.TopologySpreadConstraints {
+		if constraint.WhenUnsatisfiable != v1.DoNotSchedule {
+			continue
+		}
+		domain, ok := node.Labels[constraint.TopologyKey]
+		if !ok {
+			failures = append(failures, predicateFailure{
+				Predicate:  "PodTopologySpread",
+				Reason:     fmt.Sprintf("the node has no %s label", constraint.TopologyKey),
+				Suggestion: fmt.Sprintf("set whenUnsatisfiable to ScheduleAnyway for the %s constraint", constraint.TopologyKey),
+			})
+			continue
+		}
+		selector, err := metav1.LabelSelectorAsSelector(constraint.LabelSelector)
+		if err != nil || constraint.LabelSelector == nil {
+			continue
+		}
+		// the domains are the ones of the nodes the pod could be placed on
+		counts := map[string]int32{}
+		for _, candidate := range c.nodes {
+			value, ok := candidate.Labels[constraint.TopologyKey]
+			if !ok || len(c.fitNodeSelector(pod, candidate)) > 0 || len(c.fitNodeAffinity(pod, candidate)) > 0 {
+				continue
+			}
+			counts[value] += 0
+		}
+		for _, other := range c.pods {
+			value, ok := c.nodeByName[other.Spec.NodeName].Labels[constraint.TopologyKey]
+			if _, eligible := counts[value]; ok && eligible && other.Namespace == pod.Namespace && selector.Matches(labels.Set(other.Labels)) {
+				counts[value]++
+			}
+		}
+		minimum := int32(-1)
+		for _, count := range counts {
+			if minimum < 0 || count < minimum {
+				minimum = count
+			}
+		}
+		if minimum < 0 {
+			continue
+		}
+		if skew := counts[domain] + 1 - minimum; skew > constraint.MaxSkew {
+			failures = append(failures, predicateFailure{
+				Predicate:  "PodTopologySpread",
+				Reason:     fmt.Sprintf("placing the pod in the %s %s makes a skew of %d for a maxSkew of %d", constraint.TopologyKey, domain, skew, constraint.MaxSkew),
+				Suggestion: fmt.Sprintf("raise maxSkew to %d or set whenUnsatisfiable to ScheduleAnyway for the %s constraint", skew, constraint.TopologyKey),
+			})
+		}
+	}
+	return failures
+}
+
+func (c *schedulingCluster) fitVolumeZone(pod v1.Pod, node v1.Node) []predicateFailure {
+	var failures []predicateFailure
+	for _, volume := range pod.Spec.Volumes {
+		if volume.PersistentVolumeClaim == nil {
+			continue
+		}
+		pvc, ok := c.pvcs[pod.Namespace+"/"+volume.PersistentVolumeClaim.ClaimName]
+		if !ok || pvc.Spec.VolumeName == "" {
+			continue
+		}
+		pv, ok := c.pvs[pvc.Spec.VolumeName]
+		if !ok || pv.Spec.NodeAffinity == nil || pv.Spec.NodeAffinity.Required == nil {
+			continue
+		}
+		if !nodeSelectorMatches(pv.Spec.NodeAffinity.Required, node) {
+			failures = append(failures, predicateFailure{
+				Predicate:  "VolumeZone",
+				Reason:     fmt.Sprintf("the PersistentVolume %s of the volume %s is only reachable from the nodes matching %s", pv.Name, volume.Name, nodeSelectorString(pv.Spec.NodeAffinity.Required)),
+				Suggestion: fmt.Sprintf("none, the pod has to run where the PersistentVolume %s is", pv.Name),
+				Names:      []string{pv.Name},
+			})
+		}
+	}
+	return failures
+}
+
+// matchingPods returns the running pods matched by a pod affinity term of a pod
+func (c *schedulingCluster) matchingPods(pod v1.Pod, term v1.PodAffinityTerm) []v1.Pod {
+	var matching []v1.Pod
+	for _, other := range c.pods {
+		if c.termMatches(pod, term, other) {
+			matching = append(matching, other)
+		}
+	}
+	return matching
+}
+
+// termMatches tells whether a pod affinity term of owner matches a pod, the term applies to the namespace of
+// its owner unless it lists namespaces or has a namespaceSelector
+func (c *schedulingCluster) termMatches(owner v1.Pod, term v1.PodAffinityTerm, pod v1.Pod) bool {
+	inNamespace := false
+	switch {
+	case len(term.Namespaces) > 0 || term.NamespaceSelector != nil:
+		for _, ns := range term.Namespaces {
+			if ns == pod.Namespace {
+				inNamespace = true
+			}
+		}
+		if term.NamespaceSelector != nil && selectorMatches(term.NamespaceSelector, c.namespaces[pod.Namespace]) {
+			inNamespace = true
+		}
+	default:
+		inNamespace = pod.Namespace == owner.Namespace
+	}
+	return inNamespace && term.LabelSelector != nil && selectorMatches(term.LabelSelector, labels.Set(pod.Labels))
+}
+
+// inDomain returns the pods running on the nodes of a topology domain
+func (c *schedulingCluster) inDomain(pods []v1.Pod, topologyKey string, domain string) []v1.Pod {
+	var result []v1.Pod
+	for _, pod := range pods {
+		if value, ok := c.nodeByName[pod.Spec.NodeName].Labels[topologyKey]; ok && value == domain {
+			result = append(result, pod)
+		}
+	}
+	return result
+}
+
+func selectorMatches(selector *metav1.LabelSelector, set labels.Set) bool {
+	s, err := metav1.LabelSelectorAsSelector(selector)
+	return err == nil && s.Matches(set)
+}
+
+// nodeSelectorMatches tells whether a node matches one of the terms of a node selector
+func nodeSelectorMatches(selector *v1.NodeSelector, node v1.Node) bool {
+	for _, term := range selector.NodeSelectorTerms {
+		if len(term.MatchExpressions) == 0 && len(term.MatchFields) == 0 {
+			continue
+		}
+		matches := true
+		for _, requirement := range term.MatchExpressions {
+			value, ok := node.Labels[requirement.Key]
+			matches = matches && requirementMatches(requirement, value, ok)
+		}
+		for _, requirement := range term.MatchFields {
+			matches = matches && requirement.Key == "metadata.name" && requirementMatches(requirement, node.Name, true)
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
+
+func requirementMatches(requirement v1.NodeSelectorRequirement, value string, exists bool) bool {
+	switch requirement.Operator {
+	case v1.NodeSelectorOpIn:
+		return exists && util.SliceContainsString(requirement.Values, value)
+	case v1.NodeSelectorOpNotIn:
+		return !exists || !util.SliceContainsString(requirement.Values, value)
+	case v1.NodeSelectorOpExists:
+		return exists
+	case v1.NodeSelectorOpDoesNotExist:
+		return !exists
+	case v1.NodeSelectorOpGt, v1.NodeSelectorOpLt:
+		if !exists || len(requirement.Values) != 1 {
+			return false
+		}
+		actual, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return false
+		}
+		bound, err := strconv.ParseInt(requirement.Values[0], 10, 64)
+		if err != nil {
+			return false
+		}
+		if requirement.Operator == v1.NodeSelectorOpGt {
+			return actual > bound
+		}
+		return actual < bound
+	}
+	return false
+}
+
+// nodeSelectorString describes the terms of a node selector, the terms are ORed
+func nodeSelectorString(selector *v1.NodeSelector) string {
+	var terms []string
+	for _, term := range selector.NodeSelectorTerms {
+		var requirements []string
+		for _, requirement := range append(append([]v1.NodeSelectorRequirement{}, term.MatchExpressions...), term.MatchFields...) {
+			requirements = append(requirements, fmt.Sprintf("%s %s %s", requirement.Key, requirement.Operator, strings.Join(requirement.Values, ",")))
+		}
+		terms = append(terms, "["+strings.Join(requirements, ", ")+"]")
+	}
+	return strings.Join(terms, " or ")
+}
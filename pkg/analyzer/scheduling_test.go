@@ -0,0 +1,158 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
+	"github.com/magiconair/properties/assert"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestSchedulingExplain(t *testing.T) {
+	node := func(name string, zone string, labels map[string]string, cpu string) *v1.Node {
+		n := &v1.Node{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"topology.kubernetes.io/zone": zone}},
+			Status: v1.NodeStatus{Allocatable: v1.ResourceList{
+				v1.ResourceCPU:  resource.MustParse(cpu),
+				v1.ResourcePods: resource.MustParse("110"),
+			}},
+		}
+		for key, value := range labels {
+			n.Labels[key] = value
+		}
+		return n
+	}
+	green := map[string]string{"rdei.io/sec-zone-green": "true"}
+	nodeA := node("node-a", "a", green, "4")
+	nodeA.Spec.Taints = []v1.Taint{{Key: "dedicated", Value: "gpu", Effect: v1.TaintEffectNoSchedule}}
+	nodeB := node("node-b", "b", green, "1")
+	nodeC := node("node-c", "c", nil, "4")
+	nodeC.Spec.Unschedulable = true
+	nodeD := nodeC.DeepCopy()
+	nodeD.Name = "node-d"
+
+	requests := func(cpu string) v1.ResourceRequirements {
+		return v1.ResourceRequirements{Requests: v1.ResourceList{v1.ResourceCPU: resource.MustParse(cpu)}}
+	}
+	running := &v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web-0", Namespace: "test", Labels: map[string]string{"app": "web"}},
+		Spec: v1.PodSpec{
+			NodeName:   "node-b",
+			Containers: []v1.Container{{Name: "web", Resources: requests("500m")}},
+		},
+		Status: v1.PodStatus{Phase: v1.PodRunning},
+	}
+	pending := v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "test"},
+		Spec: v1.PodSpec{
+			NodeSelector: green,
+			Containers:   []v1.Container{{Name: "api", Resources: requests("1")}},
+			Affinity: &v1.Affinity{PodAntiAffinity: &v1.PodAntiAffinity{
+				RequiredDuringSchedulingIgnoredDuringExecution: []v1.PodAffinityTerm{{
+					LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+					TopologyKey:   "topology.kubernetes.io/zone",
+				}},
+			}},
+			Volumes: []v1.Volume{{Name: "data", VolumeSource: v1.VolumeSource{
+				PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: "data"},
+			}}},
+		},
+		Status: v1.PodStatus{Phase: v1.PodPending},
+	}
+	pvc := &v1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: "test"},
+		Spec:       v1.PersistentVolumeClaimSpec{VolumeName: "pv-data"},
+	}
+	pv := &v1.PersistentVolume{
+		ObjectMeta: metav1.ObjectMeta{Name: "pv-data"},
+		Spec: v1.PersistentVolumeSpec{NodeAffinity: &v1.VolumeNodeAffinity{Required: &v1.NodeSelector{
+			NodeSelectorTerms: []v1.NodeSelectorTerm{{MatchExpressions: []v1.NodeSelectorRequirement{{
+				Key: "topology.kubernetes.io/zone", Operator: v1.NodeSelectorOpIn, Values: []string{"b"},
+			}}}},
+		}}},
+	}
+
+	clientset := fake.NewSimpleClientset(nodeA, nodeB, nodeC, nodeD, running, &pending, pvc, pv)
+	cluster, err := loadSchedulingCluster(common.Analyzer{
+		Client:  &kubernetes.Client{Client: clientset},
+		Context: context.Background(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	explanation, names := cluster.explain(pending)
+	// the nodes and the other pods are masked with the pod when the explanation is anonymized
+	assert.Equal(t, names, []string{"node-a", "pv-data", "node-b", "test", "web-0", "node-c", "node-d"})
+	assert.Equal(t, strings.Split(explanation, "\n"), []string{
+		"Pod api was evaluated against the 4 nodes:",
+		"NODES           PREDICATE          REASON",
+		"node-a          TaintToleration    the taint dedicated=gpu:NoSchedule is not tolerated",
+		"node-a          VolumeZone         the PersistentVolume pv-data of the volume data is only reachable from the nodes matching [topology.kubernetes.io/zone In b]",
+		"node-b          NodeResourcesFit   insufficient cpu, the pod requests 1 and 500m of 1 are free",
+		"node-b          InterPodAffinity   the pod test/web-0 matching the anti-affinity app=web runs in its topology.kubernetes.io/zone b",
+		"node-c, node-d  NodeUnschedulable  the node is cordoned",
+		"node-c, node-d  NodeSelector       the node is not in the security zone green",
+		"node-c, node-d  VolumeZone         the PersistentVolume pv-data of the volume data is only reachable from the nodes matching [topology.kubernetes.io/zone In b]",
+		"Suggested change to run on node-a, the closest node: add the toleration {key: dedicated, operator: Equal, value: gpu, effect: NoSchedule}; none, the pod has to run where the PersistentVolume pv-data is.",
+	})
+
+	// the security zone is kept, the zone needs capacity
+	assert.Equal(t, cluster.fitNodeSelector(pending, *nodeC)[0].Suggestion, "none, add capacity to the security zone green where the pod has to run")
+
+	// the first pod of a group matching its own affinity is not held back
+	pending.Labels = map[string]string{"app": "api"}
+	pending.Spec.Affinity = &v1.Affinity{PodAffinity: &v1.PodAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: []v1.PodAffinityTerm{{
+			LabelSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "api"}},
+			TopologyKey:   "topology.kubernetes.io/zone",
+		}},
+	}}
+	pending.Spec.Containers[0].Resources = requests("500m")
+	assert.Equal(t, len(cluster.fit(pending, *nodeB)), 0)
+}
+
+func TestSchedulingExplainGroups(t *testing.T) {
+	var nodes []v1.Node
+	for i := 0; i < maxExplainGroups+6; i++ {
+		// the first nodes fail alike, the others each differently
+		size := "large"
+		if i >= 5 {
+			size = fmt.Sprintf("size-%02d", i)
+		}
+		nodes = append(nodes, v1.Node{ObjectMeta: metav1.ObjectMeta{
+			Name:   fmt.Sprintf("node-%02d", i),
+			Labels: map[string]string{"size": size},
+		}})
+	}
+	pod := v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "test"},
+		Spec:       v1.PodSpec{NodeSelector: map[string]string{"size": "small"}},
+	}
+
+	explanation, _ := newSchedulingCluster(nodes, nil).explain(pod)
+	lines := strings.Split(explanation, "\n")
+	assert.Equal(t, len(lines), maxExplainGroups+4)
+	assert.Equal(t, lines[2], "5 nodes (node-00, node-01, ...)  NodeSelector  the node has size=large instead of small")
+	assert.Equal(t, lines[maxExplainGroups+2], "2 more nodes                     -             fail in 2 other ways")
+}
@@ -14,18 +14,61 @@ limitations under the License.
 package analyzer
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
 
-	"github.com/fatih/color"
 	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
 	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
 	"github.com/k8sgpt-ai/k8sgpt/pkg/util"
+	"github.com/spf13/viper"
+	appsv1 "k8s.io/api/apps/v1"
+	v1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 type ServiceAnalyzer struct{}
 
+// ServiceConfig is read from the analyzers.service key of the config file
+type ServiceConfig struct {
+	// LoadBalancerTimeout is how long a Service of type LoadBalancer can wait for its ingress IP
+	LoadBalancerTimeout string `mapstructure:"loadbalancertimeout"`
+	// ResolveExternalNames looks up the names of the ExternalName Services with the resolver of the host k8sgpt
+	// runs on, not the cluster DNS: the names only served inside the cluster are reported from a workstation
+	ResolveExternalNames bool `mapstructure:"resolveexternalnames"`
+
+	loadBalancerTimeout time.Duration
+}
+
+func getServiceConfig() ServiceConfig {
+	config := ServiceConfig{
+		LoadBalancerTimeout: "5m",
+	}
+	_ = viper.UnmarshalKey("analyzers.service", &config)
+	config.loadBalancerTimeout = configDuration("analyzers.service.loadbalancertimeout", config.LoadBalancerTimeout)
+	return config
+}
+
+// lookupHost resolves the names of the ExternalName Services, it is replaced by the tests
+var lookupHost = net.DefaultResolver.LookupHost
+
+// serviceEndpoints are the addresses of a Service, read from its EndpointSlices or from its Endpoints
+type serviceEndpoints struct {
+	// Found is set when an EndpointSlice or an Endpoints exists for the Service
+	Found    bool
+	Ready    int
+	NotReady []string
+}
+
 func (ServiceAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
 
 	kind := "Service"
@@ -42,89 +85,296 @@ func (ServiceAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
 		"analyzer_name": kind,
 	})
 
-	// search all namespaces for pods that are not running
-	list, err := a.Client.GetClient().CoreV1().Endpoints(a.Namespace).List(a.Context, metav1.ListOptions{})
+	config := getServiceConfig()
+
+	services, err := a.Client.GetClient().CoreV1().Services(a.Namespace).List(a.Context, metav1.ListOptions{})
 	if err != nil {
 		return nil, err
 	}
+	endpoints, err := listServiceEndpoints(a)
+	if err != nil {
+		return nil, err
+	}
+	pods, err := a.Client.GetClient().CoreV1().Pods(a.Namespace).List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	statefulSets, err := a.Client.GetClient().AppsV1().StatefulSets(a.Namespace).List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	headless := map[string]bool{}
+	for _, svc := range services.Items {
+		headless[svc.Namespace+"/"+svc.Name] = svc.Spec.ClusterIP == v1.ClusterIPNone
+	}
 
-	var preAnalysis = map[string]common.PreAnalysis{}
-
-	for _, ep := range list.Items {
-		if SkipNamespace(ep.Namespace) {
+	for _, svc := range services.Items {
+		if SkipNamespace(svc.Namespace) {
 			continue
 		}
 		var failures []common.Failure
-
-		// Check for empty service
-		if len(ep.Subsets) == 0 {
-			svc, err := a.Client.GetClient().CoreV1().Services(ep.Namespace).Get(a.Context, ep.Name, metav1.GetOptions{})
-			if err != nil {
-				color.Yellow("Service %s does not exist", ep.Name)
-				continue
-			}
-			labels := ""
-			for k, v := range svc.Spec.Selector {
-				if labels != "" {
-					labels += ", "
-				}
-				labels += fmt.Sprintf("%s=%s", k, v)
-			}
-			doc := apiDoc.GetApiDocV2("spec.selector")
+		report := func(path string, format string, args ...interface{}) {
 			failures = append(failures, common.Failure{
-				Text:          fmt.Sprintf("Service %s has no endpoints, expected labels [%s]", ep.Name, labels),
-				KubernetesDoc: doc,
-				Sensitive:     []common.Sensitive{},
+				Text:          fmt.Sprintf(format, args...),
+				KubernetesDoc: apiDoc.GetApiDocV2(path),
+				Sensitive:     sensitiveValues(svc.Name),
 			})
+		}
 
+		if svc.Spec.Type == v1.ServiceTypeExternalName {
+			if problem := externalNameProblem(a, config, svc); problem != "" {
+				report("spec.externalName", "Service %s of type ExternalName points at %s %s.", svc.Name, svc.Spec.ExternalName, problem)
+			}
 		} else {
-			count := 0
-			pods := []string{}
-
-			// Check through container status to check for crashes
-			for _, epSubset := range ep.Subsets {
+			ep := endpoints[svc.Namespace+"/"+svc.Name]
+			// the Services without selector are given their endpoints by hand, they are only checked once they have some
+			if ep.Ready == 0 && len(ep.NotReady) == 0 && (len(svc.Spec.Selector) > 0 || ep.Found) {
+				selector := ""
+				for k, v := range svc.Spec.Selector {
+					if selector != "" {
+						selector += ", "
+					}
+					selector += fmt.Sprintf("%s=%s", k, v)
+				}
+				doc := apiDoc.GetApiDocV2("spec.selector")
+				failures = append(failures, common.Failure{
+					Text:          fmt.Sprintf("Service %s has no endpoints, expected labels [%s]", svc.Name, selector),
+					KubernetesDoc: doc,
+					Sensitive:     []common.Sensitive{},
+				})
+			}
+			if len(ep.NotReady) > 0 {
 				apiDoc.Kind = "Endpoints"
+				doc := apiDoc.GetApiDocV2("subsets.notReadyAddresses")
+				apiDoc.Kind = kind
+				failures = append(failures, common.Failure{
+					Text:          fmt.Sprintf("Service has not ready endpoints, pods: %s, expected %d", ep.NotReady, len(ep.NotReady)),
+					KubernetesDoc: doc,
+					Sensitive:     []common.Sensitive{},
+				})
+			}
 
-				if len(epSubset.NotReadyAddresses) > 0 {
-					for _, addresses := range epSubset.NotReadyAddresses {
-						count++
-						pods = append(pods, addresses.TargetRef.Kind+"/"+addresses.TargetRef.Name)
+			if len(svc.Spec.Selector) > 0 {
+				selected := selectedPods(pods.Items, svc)
+				for _, port := range svc.Spec.Ports {
+					target := port.TargetPort
+					if target.Type == intstr.Int && target.IntVal == 0 {
+						target = intstr.FromInt32(port.Port)
+					}
+					var missing []string
+					for _, pod := range selected {
+						if !podHasPort(pod, target, port.Protocol) {
+							missing = append(missing, pod.Name)
+						}
+					}
+					if len(missing) == 0 {
+						continue
+					}
+					portName := port.Name
+					if portName == "" {
+						portName = fmt.Sprint(port.Port)
 					}
+					if target.Type == intstr.String {
+						report("spec.ports.targetPort", "Service %s port %s targets the port named %s, which no container of the pods %s declares, they are left out of its endpoints.",
+							svc.Name, portName, target.StrVal, strings.Join(missing, ", "))
+					} else {
+						report("spec.ports.targetPort", "Service %s port %s targets the port %d, which is not among the container ports of the pods %s.",
+							svc.Name, portName, target.IntVal, strings.Join(missing, ", "))
+					}
+				}
+			}
+		}
+
+		if svc.Spec.Type == v1.ServiceTypeLoadBalancer && len(svc.Status.LoadBalancer.Ingress) == 0 && config.loadBalancerTimeout > 0 {
+			if pending := time.Since(svc.CreationTimestamp.Time); pending > config.loadBalancerTimeout {
+				text := fmt.Sprintf("Service %s of type LoadBalancer has no ingress IP after %s", svc.Name, pending.Round(time.Second))
+				// the cloud controller tells why the load balancer could not be created
+				if event, err := FetchLatestEvent(a, kind, svc.ObjectMeta); err == nil && event != nil && event.Type == v1.EventTypeWarning {
+					text += fmt.Sprintf(", the latest event is %s: %s", event.Reason, event.Message)
+				}
+				report("status.loadBalancer.ingress", "%s.", text)
+			}
+		}
+
+		for _, sts := range statefulSets.Items {
+			if sts.Namespace != svc.Namespace {
+				continue
+			}
+			for _, text := range statefulSetServiceProblems(svc, sts, headless) {
+				report("spec.clusterIP", "%s", text)
+			}
+		}
 
-					doc := apiDoc.GetApiDocV2("subsets.notReadyAddresses")
+		if len(failures) == 0 {
+			continue
+		}
+		AnalyzerErrorsMetric.WithLabelValues(kind, svc.Name, svc.Namespace).Set(float64(len(failures)))
 
-					failures = append(failures, common.Failure{
-						Text:          fmt.Sprintf("Service has not ready endpoints, pods: %s, expected %d", pods, count),
-						KubernetesDoc: doc,
-						Sensitive:     []common.Sensitive{},
-					})
+		parent, _ := util.GetParent(a.Client, svc.ObjectMeta)
+		a.Results = append(a.Results, common.Result{
+			Namespace:    svc.Namespace,
+			ResourceName: svc.Name,
+			Kind:         kind,
+			Name:         fmt.Sprintf("%s/%s", svc.Namespace, svc.Name),
+			Error:        failures,
+			ParentObject: parent,
+		})
+	}
+
+	return a.Results, nil
+}
+
+// listServiceEndpoints returns the endpoints by namespace/name of their Service. The EndpointSlices are preferred,
+// they hold every address where the Endpoints are truncated at 1000, and they are mirrored from the Endpoints
+// of the Services without selector
+func listServiceEndpoints(a common.Analyzer) (map[string]serviceEndpoints, error) {
+	result := map[string]serviceEndpoints{}
+
+	// the clusters older than 1.21 do not serve discovery/v1, only the Endpoints are read there
+	slices, err := a.Client.GetClient().DiscoveryV1().EndpointSlices(a.Namespace).List(a.Context, metav1.ListOptions{})
+	if err != nil && !k8serrors.IsNotFound(err) {
+		return nil, err
+	}
+	if err == nil {
+		for _, slice := range slices.Items {
+			service := slice.Labels[discoveryv1.LabelServiceName]
+			if service == "" {
+				continue
+			}
+			key := slice.Namespace + "/" + service
+			ep := result[key]
+			ep.Found = true
+			for _, endpoint := range slice.Endpoints {
+				switch {
+				case endpoint.Conditions.Ready == nil || *endpoint.Conditions.Ready:
+					ep.Ready++
+				// the terminating endpoints are expected during a rollout
+				case endpoint.Conditions.Terminating != nil && *endpoint.Conditions.Terminating:
+				case endpoint.TargetRef != nil:
+					ep.NotReady = append(ep.NotReady, endpoint.TargetRef.Kind+"/"+endpoint.TargetRef.Name)
+				case len(endpoint.Addresses) > 0:
+					ep.NotReady = append(ep.NotReady, endpoint.Addresses[0])
 				}
 			}
+			result[key] = ep
 		}
+	}
 
-		if len(failures) > 0 {
-			preAnalysis[fmt.Sprintf("%s/%s", ep.Namespace, ep.Name)] = common.PreAnalysis{
-				Namespace:      ep.Namespace,
-				ResourceName:   ep.Name,
-				Endpoint:       ep,
-				FailureDetails: failures,
+	list, err := a.Client.GetClient().CoreV1().Endpoints(a.Namespace).List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, endpoints := range list.Items {
+		key := endpoints.Namespace + "/" + endpoints.Name
+		if result[key].Found {
+			continue
+		}
+		ep := serviceEndpoints{Found: true}
+		for _, subset := range endpoints.Subsets {
+			ep.Ready += len(subset.Addresses)
+			for _, address := range subset.NotReadyAddresses {
+				if address.TargetRef != nil {
+					ep.NotReady = append(ep.NotReady, address.TargetRef.Kind+"/"+address.TargetRef.Name)
+				} else {
+					ep.NotReady = append(ep.NotReady, address.IP)
+				}
 			}
-			AnalyzerErrorsMetric.WithLabelValues(kind, ep.Name, ep.Namespace).Set(float64(len(failures)))
 		}
+		result[key] = ep
 	}
+	return result, nil
+}
 
-	for key, value := range preAnalysis {
-		var currentAnalysis = common.Result{
-			Namespace:    value.Namespace,
-			ResourceName: value.ResourceName,
-			Kind:         kind,
-			Name:         key,
-			Error:        value.FailureDetails,
+// selectedPods returns the pods a Service sends traffic to, the completed ones are left out
+func selectedPods(pods []v1.Pod, svc v1.Service) []v1.Pod {
+	selector := labels.SelectorFromSet(svc.Spec.Selector)
+	var selected []v1.Pod
+	for _, pod := range pods {
+		if pod.Namespace != svc.Namespace || pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+			continue
 		}
+		if selector.Matches(labels.Set(pod.Labels)) {
+			selected = append(selected, pod)
+		}
+	}
+	sort.Slice(selected, func(i, j int) bool { return selected[i].Name < selected[j].Name })
+	return selected
+}
 
-		parent, _ := util.GetParent(a.Client, value.Endpoint.ObjectMeta)
-		currentAnalysis.ParentObject = parent
-		a.Results = append(a.Results, currentAnalysis)
+// podHasPort reports whether a pod can receive the traffic of a targetPort. A named port must be declared,
+// a numbered one only has to be among the declared ports when the pod declares some
+func podHasPort(pod v1.Pod, target intstr.IntOrString, protocol v1.Protocol) bool {
+	if protocol == "" {
+		protocol = v1.ProtocolTCP
 	}
-	return a.Results, nil
+	declared := false
+	for _, container := range allContainers(pod.Spec) {
+		for _, port := range container.Ports {
+			declared = true
+			portProtocol := port.Protocol
+			if portProtocol == "" {
+				portProtocol = v1.ProtocolTCP
+			}
+			if portProtocol != protocol {
+				continue
+			}
+			if target.Type == intstr.String && port.Name == target.StrVal {
+				return true
+			}
+			if target.Type == intstr.Int && port.ContainerPort == target.IntVal {
+				return true
+			}
+		}
+	}
+	return target.Type == intstr.Int && !declared
+}
+
+// statefulSetServiceProblems describes how a Service and a StatefulSet disagree on the governing Service,
+// the one giving stable DNS names to the pods
+func statefulSetServiceProblems(svc v1.Service, sts appsv1.StatefulSet, headless map[string]bool) []string {
+	var problems []string
+	selects := len(svc.Spec.Selector) > 0 && labels.SelectorFromSet(svc.Spec.Selector).Matches(labels.Set(sts.Spec.Template.Labels))
+	if sts.Spec.ServiceName == svc.Name {
+		if svc.Spec.ClusterIP != v1.ClusterIPNone {
+			problems = append(problems, fmt.Sprintf("Service %s is the serviceName of StatefulSet %s but is not headless, the pods get no stable DNS names.", svc.Name, sts.Name))
+		}
+		if len(svc.Spec.Selector) > 0 && !selects {
+			problems = append(problems, fmt.Sprintf("Service %s is the serviceName of StatefulSet %s but its selector does not match the labels of its pods.", svc.Name, sts.Name))
+		}
+		return problems
+	}
+	// a StatefulSet also has regular Services, only a headless one stands in for a missing governing Service
+	if svc.Spec.ClusterIP == v1.ClusterIPNone && selects && !headless[sts.Namespace+"/"+sts.Spec.ServiceName] {
+		problems = append(problems, fmt.Sprintf("Headless Service %s selects the pods of StatefulSet %s, whose serviceName is %q, the pods get no DNS names under this Service.",
+			svc.Name, sts.Name, sts.Spec.ServiceName))
+	}
+	return problems
+}
+
+// externalNameProblem describes why the name of an ExternalName Service cannot be reached, the in-cluster names
+// are checked against the Services and the others are resolved when the config allows it
+func externalNameProblem(a common.Analyzer, config ServiceConfig, svc v1.Service) string {
+	name := strings.TrimSuffix(svc.Spec.ExternalName, ".")
+	if name == "" {
+		return ""
+	}
+	if parts := strings.Split(name, "."); len(parts) >= 3 && parts[2] == "svc" {
+		_, err := a.Client.GetClient().CoreV1().Services(parts[1]).Get(a.Context, parts[0], metav1.GetOptions{})
+		if k8serrors.IsNotFound(err) {
+			return fmt.Sprintf("but the Service %s/%s does not exist", parts[1], parts[0])
+		}
+		return ""
+	}
+	if !config.ResolveExternalNames {
+		return ""
+	}
+	ctx, cancel := context.WithTimeout(a.Context, 5*time.Second)
+	defer cancel()
+	_, err := lookupHost(ctx, name)
+	// a resolver that cannot be reached says nothing about the name
+	var dnsError *net.DNSError
+	if errors.As(err, &dnsError) && dnsError.IsNotFound {
+		return "which cannot be resolved"
+	}
+	return ""
 }
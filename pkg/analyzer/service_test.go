@@ -15,13 +15,19 @@ package analyzer
 
 import (
 	"context"
+	"net"
 	"testing"
+	"time"
 
 	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
 	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
 	"github.com/magiconair/properties/assert"
+	"github.com/spf13/viper"
+	appsv1 "k8s.io/api/apps/v1"
 	v1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/client-go/kubernetes/fake"
 )
 
@@ -120,3 +126,157 @@ func TestServiceAnalyzerNamespaceFiltering(t *testing.T) {
 	}
 	assert.Equal(t, len(analysisResults), 1)
 }
+
+func TestServiceAnalyzerConnectivity(t *testing.T) {
+	notReady := false
+	clientset := fake.NewSimpleClientset(
+		// the pods are left out of the endpoints as they do not declare the http port
+		&v1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "test"},
+			Spec: v1.ServiceSpec{
+				Selector: map[string]string{"app": "web"},
+				Ports:    []v1.ServicePort{{Name: "http", Port: 80, TargetPort: intstr.FromString("http")}},
+			},
+		},
+		&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "web-1", Namespace: "test", Labels: map[string]string{"app": "web"}},
+			Spec: v1.PodSpec{
+				Containers: []v1.Container{{Name: "web", Ports: []v1.ContainerPort{{Name: "metrics", ContainerPort: 9090}}}},
+			},
+		},
+		// the truncated Endpoints are ignored in favor of the EndpointSlices
+		&v1.Endpoints{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "web",
+				Namespace:   "test",
+				Annotations: map[string]string{v1.EndpointsOverCapacity: "truncated"},
+			},
+		},
+		&discoveryv1.EndpointSlice{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "web-abc",
+				Namespace: "test",
+				Labels:    map[string]string{discoveryv1.LabelServiceName: "web"},
+			},
+			Endpoints: []discoveryv1.Endpoint{
+				{
+					Addresses:  []string{"10.0.0.1"},
+					Conditions: discoveryv1.EndpointConditions{Ready: &notReady},
+					TargetRef:  &v1.ObjectReference{Kind: "Pod", Name: "web-1"},
+				},
+			},
+		},
+		// a healthy Service whose pods do not declare their ports
+		&v1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "test"},
+			Spec: v1.ServiceSpec{
+				Selector: map[string]string{"app": "api"},
+				Ports:    []v1.ServicePort{{Port: 8080}},
+			},
+		},
+		&v1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "api-1", Namespace: "test", Labels: map[string]string{"app": "api"}},
+			Spec:       v1.PodSpec{Containers: []v1.Container{{Name: "api"}}},
+		},
+		&v1.Endpoints{
+			ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "test"},
+			Subsets:    []v1.EndpointSubset{{Addresses: []v1.EndpointAddress{{IP: "10.0.0.2"}}}},
+		},
+		&v1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "lb",
+				Namespace:         "test",
+				CreationTimestamp: metav1.NewTime(time.Now().Add(-time.Hour)),
+			},
+			Spec: v1.ServiceSpec{Type: v1.ServiceTypeLoadBalancer},
+		},
+		&v1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "lb.1", Namespace: "test"},
+			InvolvedObject: v1.ObjectReference{Kind: "Service", Name: "lb", Namespace: "test"},
+			Type:           v1.EventTypeWarning,
+			Reason:         "SyncLoadBalancerFailed",
+			Message:        "no available subnet",
+			LastTimestamp:  metav1.Now(),
+		},
+		&v1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "ext", Namespace: "test"},
+			Spec:       v1.ServiceSpec{Type: v1.ServiceTypeExternalName, ExternalName: "db.example.invalid"},
+		},
+		&v1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "alias", Namespace: "test"},
+			Spec:       v1.ServiceSpec{Type: v1.ServiceTypeExternalName, ExternalName: "missing.other.svc.cluster.local"},
+		},
+		// the governing Service of the StatefulSet is not headless, another headless one selects its pods
+		&appsv1.StatefulSet{
+			ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "test"},
+			Spec: appsv1.StatefulSetSpec{
+				ServiceName: "db",
+				Template:    v1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "db"}}},
+			},
+		},
+		&v1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "test"},
+			Spec:       v1.ServiceSpec{ClusterIP: "10.96.0.10", Selector: map[string]string{"app": "db"}},
+		},
+		&v1.Service{
+			ObjectMeta: metav1.ObjectMeta{Name: "db-headless", Namespace: "test"},
+			Spec:       v1.ServiceSpec{ClusterIP: v1.ClusterIPNone, Selector: map[string]string{"app": "db"}},
+		},
+		&v1.Endpoints{
+			ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "test"},
+			Subsets:    []v1.EndpointSubset{{Addresses: []v1.EndpointAddress{{IP: "10.0.0.3"}}}},
+		},
+		&v1.Endpoints{
+			ObjectMeta: metav1.ObjectMeta{Name: "db-headless", Namespace: "test"},
+			Subsets:    []v1.EndpointSubset{{Addresses: []v1.EndpointAddress{{IP: "10.0.0.3"}}}},
+		},
+	)
+
+	lookupHost = func(ctx context.Context, host string) ([]string, error) {
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	}
+	defer func() { lookupHost = net.DefaultResolver.LookupHost }()
+	// the names are only resolved when asked, k8sgpt may not run with the cluster DNS
+	viper.Set("analyzers.service.resolveexternalnames", true)
+	defer viper.Set("analyzers.service", nil)
+
+	config := common.Analyzer{
+		Client: &kubernetes.Client{
+			Client: clientset,
+		},
+		Context:   context.Background(),
+		Namespace: "test",
+	}
+	analysisResults, err := ServiceAnalyzer{}.Analyze(config)
+	if err != nil {
+		t.Error(err)
+	}
+
+	texts := map[string][]string{}
+	for _, result := range analysisResults {
+		for _, failure := range result.Error {
+			texts[result.Name] = append(texts[result.Name], failure.Text)
+		}
+	}
+	assert.Equal(t, texts, map[string][]string{
+		"test/web": {
+			"Service has not ready endpoints, pods: [Pod/web-1], expected 1",
+			"Service web port http targets the port named http, which no container of the pods web-1 declares, they are left out of its endpoints.",
+		},
+		"test/lb": {
+			"Service lb of type LoadBalancer has no ingress IP after 1h0m0s, the latest event is SyncLoadBalancerFailed: no available subnet.",
+		},
+		"test/ext": {
+			"Service ext of type ExternalName points at db.example.invalid which cannot be resolved.",
+		},
+		"test/alias": {
+			"Service alias of type ExternalName points at missing.other.svc.cluster.local but the Service other/missing does not exist.",
+		},
+		"test/db": {
+			"Service db is the serviceName of StatefulSet db but is not headless, the pods get no stable DNS names.",
+		},
+		"test/db-headless": {
+			`Headless Service db-headless selects the pods of StatefulSet db, whose serviceName is "db", the pods get no DNS names under this Service.`,
+		},
+	})
+}
@@ -0,0 +1,353 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyzer
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
+	"github.com/spf13/viper"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// StorageAnalyzer reports the PersistentVolumes, claims, StorageClasses and VolumeAttachments that keep
+// volumes from being provisioned, bound, attached or reclaimed
+type StorageAnalyzer struct{}
+
+// StorageConfig is read from the analyzers.storage key of the config file
+type StorageConfig struct {
+	// ReleasedTimeout is how long a PersistentVolume can stay Released before it is reported
+	ReleasedTimeout string `mapstructure:"releasedtimeout"`
+	// DetachTimeout is how long a VolumeAttachment can take to detach
+	DetachTimeout string `mapstructure:"detachtimeout"`
+	// Schedulers are the schedulers the pods must run with to use the volumes of a provisioner
+	Schedulers []SchedulerRule `mapstructure:"schedulers"`
+
+	releasedTimeout time.Duration
+	detachTimeout   time.Duration
+}
+
+// SchedulerRule requires the pods using the volumes of Provisioner to be scheduled by Scheduler
+type SchedulerRule struct {
+	Provisioner string `mapstructure:"provisioner"`
+	Scheduler   string `mapstructure:"scheduler"`
+}
+
+func getStorageConfig() StorageConfig {
+	config := StorageConfig{
+		ReleasedTimeout: "1h",
+		DetachTimeout:   "5m",
+		// Portworx volumes are placed by stork, the in-tree and the CSI drivers
+		Schedulers: []SchedulerRule{
+			{Provisioner: "kubernetes.io/portworx-volume", Scheduler: "stork"},
+			{Provisioner: "pxd.portworx.com", Scheduler: "stork"},
+		},
+	}
+	_ = viper.UnmarshalKey("analyzers.storage", &config)
+	config.releasedTimeout = configDuration("analyzers.storage.releasedtimeout", config.ReleasedTimeout)
+	config.detachTimeout = configDuration("analyzers.storage.detachtimeout", config.DetachTimeout)
+	return config
+}
+
+var (
+	defaultStorageClassAnnotations = []string{
+		"storageclass.kubernetes.io/is-default-class",
+		"storageclass.beta.kubernetes.io/is-default-class",
+	}
+	// leaseName turns a provisioner into the name of the leader election lease of its controller
+	leaseName = regexp.MustCompile(`[^a-zA-Z0-9-]`)
+)
+
+func (StorageAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
+
+	kind := "Storage"
+	apiDoc := kubernetes.K8sApiReference{
+		Kind: "PersistentVolume",
+		ApiVersion: schema.GroupVersion{
+			Group:   "",
+			Version: "v1",
+		},
+		OpenapiSchema: a.OpenapiSchema,
+	}
+
+	AnalyzerErrorsMetric.DeletePartialMatch(map[string]string{
+		"analyzer_name": kind,
+	})
+
+	config := getStorageConfig()
+
+	report := func(resourceKind string, namespace string, name string, failures []common.Failure) {
+		if len(failures) == 0 {
+			return
+		}
+		AnalyzerErrorsMetric.WithLabelValues(kind, name, namespace).Set(float64(len(failures)))
+		key := name
+		if namespace != "" {
+			key = fmt.Sprintf("%s/%s", namespace, name)
+		}
+		a.Results = append(a.Results, common.Result{
+			Namespace:    namespace,
+			ResourceName: name,
+			Kind:         resourceKind,
+			Name:         key,
+			Error:        failures,
+			ParentObject: fmt.Sprintf("%s/%s", resourceKind, name),
+		})
+	}
+
+	pvs, err := a.Client.GetClient().CoreV1().PersistentVolumes().List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	volumes := map[string]v1.PersistentVolume{}
+	for _, pv := range pvs.Items {
+		volumes[pv.Name] = pv
+
+		// the volumes are cluster wide, they belong to the namespace of their claim
+		if pv.Spec.ClaimRef == nil || SkipNamespace(pv.Spec.ClaimRef.Namespace) ||
+			(a.Namespace != "" && pv.Spec.ClaimRef.Namespace != a.Namespace) {
+			continue
+		}
+		claim := fmt.Sprintf("%s/%s", pv.Spec.ClaimRef.Namespace, pv.Spec.ClaimRef.Name)
+		var failures []common.Failure
+		switch pv.Status.Phase {
+		case v1.VolumeFailed:
+			failures = append(failures, common.Failure{
+				Text:          fmt.Sprintf("PersistentVolume %s released by %s failed to be reclaimed: %s", pv.Name, claim, pv.Status.Message),
+				KubernetesDoc: apiDoc.GetApiDocV2("spec.persistentVolumeReclaimPolicy"),
+				Sensitive:     sensitiveValues(pv.Name, pv.Spec.ClaimRef.Namespace, pv.Spec.ClaimRef.Name),
+			})
+		case v1.VolumeReleased:
+			// without the transition time the volume is reported as soon as it is released
+			if transition := pv.Status.LastPhaseTransitionTime; config.releasedTimeout <= 0 ||
+				transition != nil && time.Since(transition.Time) < config.releasedTimeout {
+				continue
+			}
+			text := fmt.Sprintf("PersistentVolume %s is still Released by the deleted claim %s, the provisioner did not delete it.", pv.Name, claim)
+			if pv.Spec.PersistentVolumeReclaimPolicy == v1.PersistentVolumeReclaimRetain {
+				text = fmt.Sprintf("PersistentVolume %s is Released by the deleted claim %s and retained, it cannot be bound again until its claimRef is removed or it is deleted.", pv.Name, claim)
+			}
+			failures = append(failures, common.Failure{
+				Text:          text,
+				KubernetesDoc: apiDoc.GetApiDocV2("spec.claimRef"),
+				Sensitive:     sensitiveValues(pv.Name, pv.Spec.ClaimRef.Namespace, pv.Spec.ClaimRef.Name),
+			})
+		}
+		report("PersistentVolume", "", pv.Name, failures)
+	}
+
+	pvcs, err := a.Client.GetClient().CoreV1().PersistentVolumeClaims(a.Namespace).List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, pvc := range pvcs.Items {
+		if SkipNamespace(pvc.Namespace) || pvc.Status.Phase != v1.ClaimBound {
+			continue
+		}
+		pv, ok := volumes[pvc.Spec.VolumeName]
+		if !ok {
+			continue
+		}
+		var failures []common.Failure
+		for _, mode := range pvc.Spec.AccessModes {
+			if !hasAccessMode(pv.Spec.AccessModes, mode) {
+				failures = append(failures, common.Failure{
+					Text: fmt.Sprintf("PersistentVolumeClaim %s/%s requests the %s access mode but is bound to the PersistentVolume %s which only offers %s.",
+						pvc.Namespace, pvc.Name, mode, pv.Name, accessModes(pv.Spec.AccessModes)),
+					KubernetesDoc: apiDoc.GetApiDocV2("spec.accessModes"),
+					Sensitive:     sensitiveValues(pvc.Namespace, pvc.Name, pv.Name),
+				})
+			}
+		}
+		requested, hasRequest := pvc.Spec.Resources.Requests[v1.ResourceStorage]
+		capacity, hasCapacity := pv.Spec.Capacity[v1.ResourceStorage]
+		if hasRequest && hasCapacity && capacity.Cmp(requested) < 0 {
+			failures = append(failures, common.Failure{
+				Text: fmt.Sprintf("PersistentVolumeClaim %s/%s requests %s but is bound to the PersistentVolume %s of %s.",
+					pvc.Namespace, pvc.Name, requested.String(), pv.Name, capacity.String()),
+				KubernetesDoc: apiDoc.GetApiDocV2("spec.capacity"),
+				Sensitive:     sensitiveValues(pvc.Namespace, pvc.Name, pv.Name),
+			})
+		}
+		report("PersistentVolumeClaim", pvc.Namespace, pvc.Name, failures)
+	}
+
+	storageClasses, err := a.Client.GetClient().StorageV1().StorageClasses().List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	running := runningProvisioners(a)
+	var defaults []string
+	for _, sc := range storageClasses.Items {
+		if isDefaultStorageClass(sc.ObjectMeta) {
+			defaults = append(defaults, sc.Name)
+		}
+		// the in-tree provisioners run in the controller manager
+		if running == nil || strings.HasPrefix(sc.Provisioner, "kubernetes.io/") || running[sc.Provisioner] ||
+			running[leaseName.ReplaceAllString(sc.Provisioner, "-")] || running[strings.ReplaceAll(sc.Provisioner, "/", "-")] {
+			continue
+		}
+		report("StorageClass", "", sc.Name, []common.Failure{
+			{
+				Text:      fmt.Sprintf("StorageClass %s uses the provisioner %s which is not running, its claims will stay Pending.", sc.Name, sc.Provisioner),
+				Sensitive: sensitiveValues(sc.Name),
+			},
+		})
+	}
+	switch {
+	case len(storageClasses.Items) > 0 && len(defaults) == 0:
+		report("StorageClass", "", "default", []common.Failure{
+			{
+				Text:      "There is no default StorageClass, the claims without a storageClassName will stay Pending.",
+				Sensitive: []common.Sensitive{},
+			},
+		})
+	case len(defaults) > 1:
+		sort.Strings(defaults)
+		report("StorageClass", "", "default", []common.Failure{
+			{
+				Text: fmt.Sprintf("The StorageClasses %s are all marked as default, the claims without a storageClassName use the most recent one.",
+					strings.Join(defaults, ", ")),
+				Sensitive: sensitiveValues(defaults...),
+			},
+		})
+	}
+
+	attachments, err := a.Client.GetClient().StorageV1().VolumeAttachments().List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, attachment := range attachments.Items {
+		if attachment.DeletionTimestamp == nil || config.detachTimeout <= 0 ||
+			time.Since(attachment.DeletionTimestamp.Time) < config.detachTimeout {
+			continue
+		}
+		volume := ""
+		if attachment.Spec.Source.PersistentVolumeName != nil {
+			volume = *attachment.Spec.Source.PersistentVolumeName
+		}
+		text := fmt.Sprintf("VolumeAttachment %s of the PersistentVolume %s has been detaching from the node %s for %s, pods using the volume cannot start on another node.",
+			attachment.Name, volume, attachment.Spec.NodeName, time.Since(attachment.DeletionTimestamp.Time).Round(time.Second))
+		if attachment.Status.DetachError != nil {
+			text += fmt.Sprintf(" The %s attacher reports: %s", attachment.Spec.Attacher, attachment.Status.DetachError.Message)
+		}
+		report("VolumeAttachment", "", attachment.Name, []common.Failure{
+			{
+				Text:      text,
+				Sensitive: sensitiveValues(attachment.Name, volume, attachment.Spec.NodeName),
+			},
+		})
+	}
+
+	return a.Results, nil
+}
+
+// runningProvisioners returns the drivers registered on the nodes and the leader election leases,
+// the provisioners of the StorageClasses are expected among them. nil is returned when they cannot be read
+func runningProvisioners(a common.Analyzer) map[string]bool {
+	csiNodes, err := a.Client.GetClient().StorageV1().CSINodes().List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil
+	}
+	leases, err := a.Client.GetClient().CoordinationV1().Leases("").List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil
+	}
+	running := map[string]bool{}
+	for _, node := range csiNodes.Items {
+		for _, driver := range node.Spec.Drivers {
+			running[driver.Name] = true
+		}
+	}
+	for _, lease := range leases.Items {
+		running[lease.Name] = true
+	}
+	return running
+}
+
+func isDefaultStorageClass(meta metav1.ObjectMeta) bool {
+	for _, annotation := range defaultStorageClassAnnotations {
+		if meta.Annotations[annotation] == "true" {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAccessMode(modes []v1.PersistentVolumeAccessMode, mode v1.PersistentVolumeAccessMode) bool {
+	for _, m := range modes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+func accessModes(modes []v1.PersistentVolumeAccessMode) string {
+	if len(modes) == 0 {
+		return "no access mode"
+	}
+	names := make([]string, 0, len(modes))
+	for _, mode := range modes {
+		names = append(names, string(mode))
+	}
+	return strings.Join(names, ", ")
+}
+
+// volumeProvisioner returns the provisioner, or the CSI driver, a PersistentVolume comes from
+func volumeProvisioner(pv v1.PersistentVolume) string {
+	if pv.Spec.CSI != nil {
+		return pv.Spec.CSI.Driver
+	}
+	if provisioner := pv.Annotations["pv.kubernetes.io/provisioned-by"]; provisioner != "" {
+		return provisioner
+	}
+	if pv.Spec.PortworxVolume != nil {
+		return "kubernetes.io/portworx-volume"
+	}
+	return ""
+}
+
+// requiredScheduler returns the first volume of a pod whose provisioner needs another scheduler than the
+// one of the pod, with the scheduler it needs
+func requiredScheduler(a common.Analyzer, pod v1.Pod, pvcList *v1.PersistentVolumeClaimList, rules []SchedulerRule) (string, string) {
+	for _, volume := range pod.Spec.Volumes {
+		if volume.PersistentVolumeClaim == nil {
+			continue
+		}
+		for _, pvc := range pvcList.Items {
+			if pvc.Namespace != pod.Namespace || pvc.Name != volume.PersistentVolumeClaim.ClaimName || pvc.Spec.VolumeName == "" {
+				continue
+			}
+			pv, err := a.Client.GetClient().CoreV1().PersistentVolumes().Get(a.Context, pvc.Spec.VolumeName, metav1.GetOptions{})
+			if err != nil {
+				continue
+			}
+			provisioner := volumeProvisioner(*pv)
+			for _, rule := range rules {
+				if rule.Provisioner == provisioner && pod.Spec.SchedulerName != rule.Scheduler {
+					return pv.Name, rule.Scheduler
+				}
+			}
+		}
+	}
+	return "", ""
+}
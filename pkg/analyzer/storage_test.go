@@ -0,0 +1,185 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyzer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
+	"github.com/magiconair/properties/assert"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	v1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestStorageAnalyzer(t *testing.T) {
+	volumeName := "pv-data"
+	deleted := metav1.NewTime(time.Now().Add(-10 * time.Minute))
+	clientset := fake.NewSimpleClientset(
+		&v1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: "pv-old"},
+			Spec: v1.PersistentVolumeSpec{
+				ClaimRef:                      &v1.ObjectReference{Namespace: "test", Name: "old"},
+				PersistentVolumeReclaimPolicy: v1.PersistentVolumeReclaimRetain,
+			},
+			Status: v1.PersistentVolumeStatus{Phase: v1.VolumeReleased},
+		},
+		&v1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: "pv-recent"},
+			Spec: v1.PersistentVolumeSpec{
+				ClaimRef:                      &v1.ObjectReference{Namespace: "test", Name: "recent"},
+				PersistentVolumeReclaimPolicy: v1.PersistentVolumeReclaimDelete,
+			},
+			Status: v1.PersistentVolumeStatus{Phase: v1.VolumeReleased, LastPhaseTransitionTime: &metav1.Time{Time: time.Now()}},
+		},
+		&v1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: volumeName},
+			Spec: v1.PersistentVolumeSpec{
+				ClaimRef:    &v1.ObjectReference{Namespace: "test", Name: "data"},
+				AccessModes: []v1.PersistentVolumeAccessMode{v1.ReadWriteOnce},
+				Capacity:    v1.ResourceList{v1.ResourceStorage: resource.MustParse("5Gi")},
+			},
+			Status: v1.PersistentVolumeStatus{Phase: v1.VolumeBound},
+		},
+		&v1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: "test"},
+			Spec: v1.PersistentVolumeClaimSpec{
+				VolumeName:  volumeName,
+				AccessModes: []v1.PersistentVolumeAccessMode{v1.ReadWriteMany},
+				Resources: v1.ResourceRequirements{
+					Requests: v1.ResourceList{v1.ResourceStorage: resource.MustParse("10Gi")},
+				},
+			},
+			Status: v1.PersistentVolumeClaimStatus{Phase: v1.ClaimBound},
+		},
+		&storagev1.StorageClass{
+			ObjectMeta:  metav1.ObjectMeta{Name: "fast", Annotations: map[string]string{"storageclass.kubernetes.io/is-default-class": "true"}},
+			Provisioner: "ebs.csi.aws.com",
+		},
+		&storagev1.StorageClass{
+			ObjectMeta:  metav1.ObjectMeta{Name: "nfs", Annotations: map[string]string{"storageclass.kubernetes.io/is-default-class": "true"}},
+			Provisioner: "example.com/nfs",
+		},
+		&storagev1.StorageClass{
+			ObjectMeta:  metav1.ObjectMeta{Name: "local"},
+			Provisioner: "kubernetes.io/no-provisioner",
+		},
+		&storagev1.CSINode{
+			ObjectMeta: metav1.ObjectMeta{Name: "node1"},
+			Spec:       storagev1.CSINodeSpec{Drivers: []storagev1.CSINodeDriver{{Name: "ebs.csi.aws.com"}}},
+		},
+		&coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{Name: "kube-controller-manager", Namespace: "kube-system"},
+		},
+		&storagev1.VolumeAttachment{
+			ObjectMeta: metav1.ObjectMeta{Name: "csi-123", DeletionTimestamp: &deleted, Finalizers: []string{"external-attacher/ebs-csi-aws-com"}},
+			Spec: storagev1.VolumeAttachmentSpec{
+				Attacher: "ebs.csi.aws.com",
+				NodeName: "node1",
+				Source:   storagev1.VolumeAttachmentSource{PersistentVolumeName: &volumeName},
+			},
+			Status: storagev1.VolumeAttachmentStatus{
+				Attached:    true,
+				DetachError: &storagev1.VolumeError{Message: "volume is still mounted"},
+			},
+		},
+	)
+
+	config := common.Analyzer{
+		Client: &kubernetes.Client{
+			Client: clientset,
+		},
+		Context:   context.Background(),
+		Namespace: "test",
+	}
+	analysisResults, err := StorageAnalyzer{}.Analyze(config)
+	if err != nil {
+		t.Error(err)
+	}
+
+	texts := map[string][]string{}
+	for _, result := range analysisResults {
+		for _, failure := range result.Error {
+			texts[result.Kind+" "+result.Name] = append(texts[result.Kind+" "+result.Name], failure.Text)
+		}
+	}
+	assert.Equal(t, texts, map[string][]string{
+		"PersistentVolume pv-old": {
+			"PersistentVolume pv-old is Released by the deleted claim test/old and retained, it cannot be bound again until its claimRef is removed or it is deleted.",
+		},
+		"PersistentVolumeClaim test/data": {
+			"PersistentVolumeClaim test/data requests the ReadWriteMany access mode but is bound to the PersistentVolume pv-data which only offers ReadWriteOnce.",
+			"PersistentVolumeClaim test/data requests 10Gi but is bound to the PersistentVolume pv-data of 5Gi.",
+		},
+		"StorageClass nfs": {
+			"StorageClass nfs uses the provisioner example.com/nfs which is not running, its claims will stay Pending.",
+		},
+		"StorageClass default": {
+			"The StorageClasses fast, nfs are all marked as default, the claims without a storageClassName use the most recent one.",
+		},
+		"VolumeAttachment csi-123": {
+			"VolumeAttachment csi-123 of the PersistentVolume pv-data has been detaching from the node node1 for 10m0s, pods using the volume cannot start on another node. The ebs.csi.aws.com attacher reports: volume is still mounted",
+		},
+	})
+}
+
+func TestRequiredScheduler(t *testing.T) {
+	clientset := fake.NewSimpleClientset(
+		&v1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{Name: "pv-px"},
+			Spec: v1.PersistentVolumeSpec{
+				PersistentVolumeSource: v1.PersistentVolumeSource{
+					CSI: &v1.CSIPersistentVolumeSource{Driver: "pxd.portworx.com"},
+				},
+			},
+		},
+	)
+	pvcList := &v1.PersistentVolumeClaimList{
+		Items: []v1.PersistentVolumeClaim{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "data", Namespace: "test"},
+				Spec:       v1.PersistentVolumeClaimSpec{VolumeName: "pv-px"},
+			},
+		},
+	}
+	pod := v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "test"},
+		Spec: v1.PodSpec{
+			Volumes: []v1.Volume{
+				{Name: "data", VolumeSource: v1.VolumeSource{PersistentVolumeClaim: &v1.PersistentVolumeClaimVolumeSource{ClaimName: "data"}}},
+			},
+		},
+	}
+	config := common.Analyzer{
+		Client: &kubernetes.Client{
+			Client: clientset,
+		},
+		Context: context.Background(),
+	}
+	rules := getStorageConfig().Schedulers
+
+	volume, scheduler := requiredScheduler(config, pod, pvcList, rules)
+	assert.Equal(t, volume, "pv-px")
+	assert.Equal(t, scheduler, "stork")
+
+	pod.Spec.SchedulerName = "stork"
+	volume, _ = requiredScheduler(config, pod, pvcList, rules)
+	assert.Equal(t, volume, "")
+}
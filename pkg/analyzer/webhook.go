@@ -0,0 +1,247 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyzer
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
+	"github.com/spf13/viper"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// WebhookAnalyzer reports the admission webhooks that are unreachable or can block the cluster
+type WebhookAnalyzer struct{}
+
+// WebhookConfig is read from the analyzers.webhook key of the config file
+type WebhookConfig struct {
+	// MaxTimeout is the timeout in seconds above which a webhook is reported, every API request it
+	// intercepts can be slowed down by as much
+	MaxTimeout int32 `mapstructure:"maxtimeout"`
+}
+
+func getWebhookConfig() WebhookConfig {
+	config := WebhookConfig{
+		MaxTimeout: 10,
+	}
+	_ = viper.UnmarshalKey("analyzers.webhook", &config)
+	return config
+}
+
+// admissionWebhook holds the fields shared by the mutating and the validating webhooks
+type admissionWebhook struct {
+	Name              string
+	ClientConfig      admissionregistrationv1.WebhookClientConfig
+	FailurePolicy     *admissionregistrationv1.FailurePolicyType
+	NamespaceSelector *metav1.LabelSelector
+	TimeoutSeconds    *int32
+}
+
+func (WebhookAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
+
+	kind := "Webhook"
+	apiDoc := kubernetes.K8sApiReference{
+		Kind: "ValidatingWebhookConfiguration",
+		ApiVersion: schema.GroupVersion{
+			Group:   "admissionregistration.k8s.io",
+			Version: "v1",
+		},
+		OpenapiSchema: a.OpenapiSchema,
+	}
+
+	AnalyzerErrorsMetric.DeletePartialMatch(map[string]string{
+		"analyzer_name": kind,
+	})
+	// the events are listed once for all the lookups of the analysis
+	a.Events = eventIndex(a)
+
+	config := getWebhookConfig()
+
+	mutating, err := a.Client.GetClient().AdmissionregistrationV1().MutatingWebhookConfigurations().List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	validating, err := a.Client.GetClient().AdmissionregistrationV1().ValidatingWebhookConfigurations().List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	// the selectors are matched against the labels of kube-system, the name label is set by the API server
+	systemLabels := labels.Set{"kubernetes.io/metadata.name": "kube-system"}
+	if ns, err := a.Client.GetClient().CoreV1().Namespaces().Get(a.Context, "kube-system", metav1.GetOptions{}); err == nil {
+		systemLabels = labels.Set(ns.Labels)
+	}
+	// the controllers report the pods they failed to create, the webhook rejecting them is named in the message
+	events, err := a.Events.All(a.Context)
+	if err != nil {
+		return nil, err
+	}
+
+	analyze := func(configKind string, meta metav1.ObjectMeta, webhooks []admissionWebhook) {
+		var failures []common.Failure
+		for _, webhook := range webhooks {
+			var webhookFailures []common.Failure
+			report := func(path string, format string, args ...interface{}) {
+				webhookFailures = append(webhookFailures, common.Failure{
+					Text:          fmt.Sprintf("Webhook %s of the %s %s ", webhook.Name, configKind, meta.Name) + fmt.Sprintf(format, args...),
+					KubernetesDoc: apiDoc.GetApiDocV2(path),
+					Sensitive:     sensitiveValues(webhook.Name, meta.Name),
+				})
+			}
+
+			if service := webhook.ClientConfig.Service; service != nil {
+				ready, err := serviceHasReadyEndpoints(a, service.Namespace, service.Name)
+				if errors.IsNotFound(err) {
+					report("webhooks.clientConfig.service", "calls the Service %s/%s which does not exist.", service.Namespace, service.Name)
+				} else if err == nil && !ready {
+					report("webhooks.clientConfig.service", "calls the Service %s/%s which has no ready endpoints.", service.Namespace, service.Name)
+				}
+				// the URL webhooks can be signed by the system roots, the in-cluster ones cannot
+				if len(webhook.ClientConfig.CABundle) == 0 {
+					report("webhooks.clientConfig.caBundle", "has no caBundle, the API server cannot verify the certificate of the Service.")
+				}
+			}
+			if problem := caBundleProblem(webhook.ClientConfig.CABundle); problem != "" {
+				report("webhooks.clientConfig.caBundle", "%s.", problem)
+			}
+
+			failurePolicy := admissionregistrationv1.Fail
+			if webhook.FailurePolicy != nil {
+				failurePolicy = *webhook.FailurePolicy
+			}
+			if failurePolicy == admissionregistrationv1.Fail {
+				selector := labels.Everything()
+				var err error
+				if webhook.NamespaceSelector != nil {
+					selector, err = metav1.LabelSelectorAsSelector(webhook.NamespaceSelector)
+				}
+				if err == nil && selector.Matches(systemLabels) {
+					report("webhooks.namespaceSelector", "fails closed and its namespaceSelector includes kube-system, the system components cannot be updated while it is down.")
+				}
+			}
+			if webhook.TimeoutSeconds != nil && config.MaxTimeout > 0 && *webhook.TimeoutSeconds > config.MaxTimeout {
+				report("webhooks.timeoutSeconds", "has a timeout of %ds, every request it intercepts can be delayed as long.", *webhook.TimeoutSeconds)
+			}
+
+			// the workloads that failed to create pods because the webhook could not be called or denied them
+			for _, event := range events {
+				if event.Reason != "FailedCreate" || !strings.Contains(event.Message, fmt.Sprintf("webhook %q", webhook.Name)) {
+					continue
+				}
+				involved := event.InvolvedObject
+				text := fmt.Sprintf("%s %s/%s had its pods denied by the webhook %s: %s", involved.Kind, involved.Namespace, involved.Name, webhook.Name, event.Message)
+				if strings.Contains(event.Message, "failed calling webhook") {
+					text = fmt.Sprintf("%s %s/%s failed to create pods because of the webhook %s: %s", involved.Kind, involved.Namespace, involved.Name, webhook.Name, event.Message)
+				}
+				webhookFailures = append(webhookFailures, common.Failure{
+					Text:      text,
+					Sensitive: sensitiveValues(involved.Namespace, involved.Name, webhook.Name),
+				})
+			}
+			failures = append(failures, webhookFailures...)
+		}
+
+		if len(failures) == 0 {
+			return
+		}
+		AnalyzerErrorsMetric.WithLabelValues(kind, meta.Name, "").Set(float64(len(failures)))
+		a.Results = append(a.Results, common.Result{
+			ResourceName: meta.Name,
+			Kind:         configKind,
+			Name:         meta.Name,
+			Error:        failures,
+			ParentObject: fmt.Sprintf("%s/%s", configKind, meta.Name),
+		})
+	}
+
+	for _, configuration := range mutating.Items {
+		var webhooks []admissionWebhook
+		for _, webhook := range configuration.Webhooks {
+			webhooks = append(webhooks, admissionWebhook{webhook.Name, webhook.ClientConfig, webhook.FailurePolicy, webhook.NamespaceSelector, webhook.TimeoutSeconds})
+		}
+		analyze("MutatingWebhookConfiguration", configuration.ObjectMeta, webhooks)
+	}
+	for _, configuration := range validating.Items {
+		var webhooks []admissionWebhook
+		for _, webhook := range configuration.Webhooks {
+			webhooks = append(webhooks, admissionWebhook{webhook.Name, webhook.ClientConfig, webhook.FailurePolicy, webhook.NamespaceSelector, webhook.TimeoutSeconds})
+		}
+		analyze("ValidatingWebhookConfiguration", configuration.ObjectMeta, webhooks)
+	}
+
+	return a.Results, nil
+}
+
+// serviceHasReadyEndpoints reports whether a Service has at least one ready address
+func serviceHasReadyEndpoints(a common.Analyzer, namespace string, name string) (bool, error) {
+	if _, err := a.Client.GetClient().CoreV1().Services(namespace).Get(a.Context, name, metav1.GetOptions{}); err != nil {
+		return false, err
+	}
+	endpoints, err := a.Client.GetClient().CoreV1().Endpoints(namespace).Get(a.Context, name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	for _, subset := range endpoints.Subsets {
+		if len(subset.Addresses) > 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// caBundleProblem describes why the certificates of a caBundle cannot be trusted, an empty bundle is not a problem here
+func caBundleProblem(bundle []byte) string {
+	if len(bundle) == 0 {
+		return ""
+	}
+	now := time.Now()
+	found := false
+	for rest := bundle; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return fmt.Sprintf("has a caBundle that cannot be parsed: %s", err)
+		}
+		found = true
+		if now.After(cert.NotAfter) {
+			return fmt.Sprintf("has a caBundle with the certificate %s which expired on %s", cert.Subject.CommonName, cert.NotAfter.Format(time.RFC3339))
+		}
+		if now.Before(cert.NotBefore) {
+			return fmt.Sprintf("has a caBundle with the certificate %s which is not valid before %s", cert.Subject.CommonName, cert.NotBefore.Format(time.RFC3339))
+		}
+	}
+	if !found {
+		return "has a caBundle without any PEM certificate"
+	}
+	return ""
+}
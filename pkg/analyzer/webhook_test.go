@@ -0,0 +1,142 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyzer
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
+	"github.com/magiconair/properties/assert"
+	admissionregistrationv1 "k8s.io/api/admissionregistration/v1"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func testCertificate(t *testing.T, commonName string, notAfter time.Time, dnsNames ...string) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:     notAfter,
+		DNSNames:     dnsNames,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestWebhookAnalyzer(t *testing.T) {
+	expiry := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	ignore := admissionregistrationv1.Ignore
+	timeout := int32(30)
+	clientset := fake.NewSimpleClientset(
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system", Labels: map[string]string{"kubernetes.io/metadata.name": "kube-system"}}},
+		&v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "policy", Namespace: "test"}},
+		&v1.Endpoints{ObjectMeta: metav1.ObjectMeta{Name: "policy", Namespace: "test"}},
+		&v1.Service{ObjectMeta: metav1.ObjectMeta{Name: "injector", Namespace: "test"}},
+		&v1.Endpoints{
+			ObjectMeta: metav1.ObjectMeta{Name: "injector", Namespace: "test"},
+			Subsets:    []v1.EndpointSubset{{Addresses: []v1.EndpointAddress{{IP: "10.0.0.1"}}}},
+		},
+		&admissionregistrationv1.ValidatingWebhookConfiguration{
+			ObjectMeta: metav1.ObjectMeta{Name: "policy"},
+			Webhooks: []admissionregistrationv1.ValidatingWebhook{
+				{
+					Name: "validate.policy.example.com",
+					ClientConfig: admissionregistrationv1.WebhookClientConfig{
+						Service:  &admissionregistrationv1.ServiceReference{Namespace: "test", Name: "policy"},
+						CABundle: testCertificate(t, "policy-ca", expiry),
+					},
+					TimeoutSeconds: &timeout,
+				},
+			},
+		},
+		&admissionregistrationv1.MutatingWebhookConfiguration{
+			ObjectMeta: metav1.ObjectMeta{Name: "injector"},
+			Webhooks: []admissionregistrationv1.MutatingWebhook{
+				{
+					Name: "inject.example.com",
+					ClientConfig: admissionregistrationv1.WebhookClientConfig{
+						Service:  &admissionregistrationv1.ServiceReference{Namespace: "test", Name: "injector"},
+						CABundle: testCertificate(t, "injector-ca", time.Now().Add(24*time.Hour)),
+					},
+					FailurePolicy: &ignore,
+				},
+			},
+		},
+		&v1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "web-5d4f8.1", Namespace: "test"},
+			InvolvedObject: v1.ObjectReference{Kind: "ReplicaSet", Namespace: "test", Name: "web-5d4f8"},
+			Reason:         "FailedCreate",
+			Type:           v1.EventTypeWarning,
+			Message:        `Error creating: Internal error occurred: failed calling webhook "validate.policy.example.com": no endpoints available for service "policy"`,
+		},
+		&v1.Event{
+			ObjectMeta:     metav1.ObjectMeta{Name: "api-7c9b2.1", Namespace: "test"},
+			InvolvedObject: v1.ObjectReference{Kind: "ReplicaSet", Namespace: "test", Name: "api-7c9b2"},
+			Reason:         "FailedCreate",
+			Type:           v1.EventTypeWarning,
+			Message:        `Error creating: admission webhook "inject.example.com" denied the request: sidecar image is not allowed`,
+		},
+	)
+
+	config := common.Analyzer{
+		Client: &kubernetes.Client{
+			Client: clientset,
+		},
+		Context:   context.Background(),
+		Namespace: "test",
+	}
+	analysisResults, err := WebhookAnalyzer{}.Analyze(config)
+	if err != nil {
+		t.Error(err)
+	}
+	assert.Equal(t, len(analysisResults), 2)
+	sort.Slice(analysisResults, func(i, j int) bool { return analysisResults[i].Kind > analysisResults[j].Kind })
+	assert.Equal(t, analysisResults[0].Kind, "ValidatingWebhookConfiguration")
+	var texts []string
+	for _, failure := range analysisResults[0].Error {
+		texts = append(texts, failure.Text)
+	}
+	assert.Equal(t, texts, []string{
+		"Webhook validate.policy.example.com of the ValidatingWebhookConfiguration policy calls the Service test/policy which has no ready endpoints.",
+		"Webhook validate.policy.example.com of the ValidatingWebhookConfiguration policy has a caBundle with the certificate policy-ca which expired on 2020-01-01T00:00:00Z.",
+		"Webhook validate.policy.example.com of the ValidatingWebhookConfiguration policy fails closed and its namespaceSelector includes kube-system, the system components cannot be updated while it is down.",
+		"Webhook validate.policy.example.com of the ValidatingWebhookConfiguration policy has a timeout of 30s, every request it intercepts can be delayed as long.",
+		`ReplicaSet test/web-5d4f8 failed to create pods because of the webhook validate.policy.example.com: Error creating: Internal error occurred: failed calling webhook "validate.policy.example.com": no endpoints available for service "policy"`,
+	})
+	// the injector is healthy, the denial is reported by itself
+	assert.Equal(t, analysisResults[1].Kind, "MutatingWebhookConfiguration")
+	assert.Equal(t, len(analysisResults[1].Error), 1)
+	assert.Equal(t, analysisResults[1].Error[0].Text,
+		`ReplicaSet test/api-7c9b2 had its pods denied by the webhook inject.example.com: Error creating: admission webhook "inject.example.com" denied the request: sidecar image is not allowed`)
+}
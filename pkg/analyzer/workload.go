@@ -0,0 +1,110 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package analyzer
+
+import (
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/util"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// workload is a controller owning a pod template, the analyzers of the templates attribute their findings to it
+type workload struct {
+	Kind       string
+	ObjectMeta metav1.ObjectMeta
+	Template   v1.PodTemplateSpec
+	// Replicas is nil for the kinds that do not have a replica count
+	Replicas *int32
+	// CurrentReplicas is the number of pods created for the workload, for the kinds with a replica count
+	CurrentReplicas int32
+	// ClaimTemplates are the names of the volume claim templates of a StatefulSet
+	ClaimTemplates []string
+}
+
+// listWorkloads returns the Deployments, StatefulSets, DaemonSets, Jobs and CronJobs of the analyzed namespace,
+// the Jobs created by a CronJob are left out as the CronJob holds their template
+func listWorkloads(a common.Analyzer) ([]workload, error) {
+	var workloads []workload
+
+	deployments, err := a.Client.GetClient().AppsV1().Deployments(a.Namespace).List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, deployment := range deployments.Items {
+		workloads = append(workloads, workload{Kind: "Deployment", ObjectMeta: deployment.ObjectMeta, Template: deployment.Spec.Template, Replicas: deployment.Spec.Replicas,
+			CurrentReplicas: deployment.Status.Replicas})
+	}
+
+	statefulSets, err := a.Client.GetClient().AppsV1().StatefulSets(a.Namespace).List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, sts := range statefulSets.Items {
+		var claims []string
+		for _, claim := range sts.Spec.VolumeClaimTemplates {
+			claims = append(claims, claim.Name)
+		}
+		workloads = append(workloads, workload{Kind: "StatefulSet", ObjectMeta: sts.ObjectMeta, Template: sts.Spec.Template, Replicas: sts.Spec.Replicas,
+			CurrentReplicas: sts.Status.Replicas, ClaimTemplates: claims})
+	}
+
+	daemonSets, err := a.Client.GetClient().AppsV1().DaemonSets(a.Namespace).List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, ds := range daemonSets.Items {
+		workloads = append(workloads, workload{Kind: "DaemonSet", ObjectMeta: ds.ObjectMeta, Template: ds.Spec.Template})
+	}
+
+	jobs, err := a.Client.GetClient().BatchV1().Jobs(a.Namespace).List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, job := range jobs.Items {
+		if cronJobOwner(job) != "" {
+			continue
+		}
+		workloads = append(workloads, workload{Kind: "Job", ObjectMeta: job.ObjectMeta, Template: job.Spec.Template})
+	}
+
+	cronJobs, err := a.Client.GetClient().BatchV1().CronJobs(a.Namespace).List(a.Context, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, cronJob := range cronJobs.Items {
+		workloads = append(workloads, workload{Kind: "CronJob", ObjectMeta: cronJob.ObjectMeta, Template: cronJob.Spec.JobTemplate.Spec.Template})
+	}
+
+	return workloads, nil
+}
+
+// allContainers returns the init containers followed by the containers of a pod spec
+func allContainers(spec v1.PodSpec) []v1.Container {
+	containers := make([]v1.Container, 0, len(spec.InitContainers)+len(spec.Containers))
+	containers = append(containers, spec.InitContainers...)
+	return append(containers, spec.Containers...)
+}
+
+// sensitiveValues masks every value of a failure text
+func sensitiveValues(values ...string) []common.Sensitive {
+	result := []common.Sensitive{}
+	for _, value := range values {
+		result = append(result, common.Sensitive{
+			Unmasked: value,
+			Masked:   util.MaskString(value),
+		})
+	}
+	return result
+}
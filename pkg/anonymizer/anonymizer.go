@@ -0,0 +1,208 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package anonymizer
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+type Category string
+
+const (
+	CategoryName      Category = "name"
+	CategoryNamespace Category = "namespace"
+	CategoryIP        Category = "ip"
+	CategoryHost      Category = "host"
+	CategoryRegistry  Category = "registry"
+	CategoryEmail     Category = "email"
+	CategoryToken     Category = "token"
+)
+
+type detector struct {
+	category Category
+	re       *regexp.Regexp
+	// group is the submatch that holds the sensitive value, 0 for the whole match
+	group int
+}
+
+// detectors are evaluated against the original text, the first one listed wins on overlapping matches
+// of the same length
+var detectors = []detector{
+	{CategoryToken, regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`), 0},
+	{CategoryToken, regexp.MustCompile(`(?i)\bbearer\s+([A-Za-z0-9._~+/-]{8,}=*)`), 1},
+	{CategoryToken, regexp.MustCompile(`(?i)\b(?:token|password|passwd|secret|api[_-]?key)["']?\s*[:=]\s*["']?([^\s"',]{6,})`), 1},
+	{CategoryEmail, regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`), 0},
+	{CategoryRegistry, regexp.MustCompile(`\b((?:[a-z0-9](?:[a-z0-9-]*[a-z0-9])?\.)+[a-z]{2,}(?::[0-9]{2,5})?)/[a-z0-9._-]+(?:/[a-z0-9._-]+)*(?::[A-Za-z0-9][A-Za-z0-9._-]*|@sha256:[a-f0-9]{64})`), 1},
+	{CategoryIP, regexp.MustCompile(`\b(?:(?:25[0-5]|2[0-4][0-9]|1[0-9]{2}|[1-9]?[0-9])\.){3}(?:25[0-5]|2[0-4][0-9]|1[0-9]{2}|[1-9]?[0-9])\b`), 0},
+	{CategoryIP, regexp.MustCompile(`(?i)\b(?:[0-9a-f]{1,4}:){7}[0-9a-f]{1,4}\b|\b(?:[0-9a-f]{1,4}:){1,7}:[0-9a-f]{1,4}\b`), 0},
+	{CategoryNamespace, regexp.MustCompile(`\bsystem:serviceaccount:([a-z0-9][a-z0-9-]*):`), 1},
+	{CategoryNamespace, regexp.MustCompile(`\bnamespaces?(?:[:=/]\s*"?|\s+")([a-z0-9][a-z0-9-]*[a-z0-9])`), 1},
+	{CategoryHost, regexp.MustCompile(`\b(?:[a-z0-9](?:[a-z0-9-]*[a-z0-9])?\.)+(?:com|net|org|io|ai|co|local|internal|corp|lan|cloud|dev|svc)\b`), 0},
+}
+
+// publicDomains are well known and do not identify a cluster, masking them only makes the prompt harder to answer
+var publicDomains = []string{
+	"kubernetes.io", "k8s.io", "x-k8s.io", "docker.io", "registry.k8s.io", "cluster.local",
+}
+
+// Anonymizer replaces sensitive values with deterministic pseudonyms. The same value always gets the
+// same pseudonym during a run, so the answer of the AI backend can be mapped back to the real values.
+type Anonymizer struct {
+	mu       sync.Mutex
+	known    map[string]Category
+	forward  map[string]string
+	reverse  map[string]string
+	counters map[Category]int
+}
+
+func New() *Anonymizer {
+	return &Anonymizer{
+		known:    map[string]Category{},
+		forward:  map[string]string{},
+		reverse:  map[string]string{},
+		counters: map[Category]int{},
+	}
+}
+
+// AddKnown registers values that must always be masked, such as object names reported by analyzers
+func (an *Anonymizer) AddKnown(category Category, values ...string) {
+	an.mu.Lock()
+	defer an.mu.Unlock()
+	for _, value := range values {
+		if len(value) < 2 {
+			continue
+		}
+		if _, ok := an.known[value]; !ok {
+			an.known[value] = category
+		}
+	}
+}
+
+type span struct {
+	start, end int
+	category   Category
+}
+
+// Anonymize returns the text with every detected sensitive value replaced by its pseudonym
+func (an *Anonymizer) Anonymize(text string) string {
+	an.mu.Lock()
+	defer an.mu.Unlock()
+
+	var spans []span
+	for _, d := range detectors {
+		for _, m := range d.re.FindAllStringSubmatchIndex(text, -1) {
+			start, end := m[2*d.group], m[2*d.group+1]
+			if start < 0 || isPublic(text[start:end]) {
+				continue
+			}
+			spans = append(spans, span{start, end, d.category})
+		}
+	}
+	for value, category := range an.known {
+		for offset := 0; ; {
+			i := strings.Index(text[offset:], value)
+			if i < 0 {
+				break
+			}
+			start, end := offset+i, offset+i+len(value)
+			if !isNameChar(text, start-1) && !isNameChar(text, end) {
+				spans = append(spans, span{start, end, category})
+			}
+			offset = start + 1
+		}
+	}
+
+	// resolve overlaps: earliest match first, the longest one when two start at the same offset
+	sort.SliceStable(spans, func(i, j int) bool {
+		if spans[i].start != spans[j].start {
+			return spans[i].start < spans[j].start
+		}
+		return spans[i].end-spans[i].start > spans[j].end-spans[j].start
+	})
+
+	var out strings.Builder
+	last := 0
+	for _, s := range spans {
+		if s.start < last {
+			continue
+		}
+		out.WriteString(text[last:s.start])
+		out.WriteString(an.pseudonym(s.category, text[s.start:s.end]))
+		last = s.end
+	}
+	out.WriteString(text[last:])
+	return out.String()
+}
+
+// Deanonymize maps the pseudonyms found in a text back to the original values
+func (an *Anonymizer) Deanonymize(text string) string {
+	an.mu.Lock()
+	defer an.mu.Unlock()
+
+	if len(an.reverse) == 0 {
+		return text
+	}
+	// the delimiters cannot be part of the original text, so a pseudonym is never read inside a longer word
+	pairs := make([]string, 0, 2*len(an.reverse))
+	for p, value := range an.reverse {
+		pairs = append(pairs, p, value)
+	}
+	return strings.NewReplacer(pairs...).Replace(text)
+}
+
+// Mapping returns the pseudonyms assigned so far, keyed by pseudonym
+func (an *Anonymizer) Mapping() map[string]string {
+	an.mu.Lock()
+	defer an.mu.Unlock()
+
+	mapping := make(map[string]string, len(an.reverse))
+	for k, v := range an.reverse {
+		mapping[k] = v
+	}
+	return mapping
+}
+
+func (an *Anonymizer) pseudonym(category Category, value string) string {
+	if p, ok := an.forward[value]; ok {
+		return p
+	}
+	an.counters[category]++
+	p := fmt.Sprintf("<<%s-%d>>", category, an.counters[category])
+	an.forward[value] = p
+	an.reverse[p] = value
+	return p
+}
+
+// isNameChar reports whether the byte at i can be part of a Kubernetes object name
+func isNameChar(text string, i int) bool {
+	if i < 0 || i >= len(text) {
+		return false
+	}
+	c := text[i]
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_'
+}
+
+func isPublic(value string) bool {
+	host := strings.ToLower(value)
+	for _, domain := range publicDomains {
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
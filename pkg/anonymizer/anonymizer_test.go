@@ -0,0 +1,63 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package anonymizer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnonymizeDetectors(t *testing.T) {
+	an := New()
+	text := `Failed to pull image "registry.corp.example.com:5000/team/app:1.2.3": dial tcp 10.1.2.3:443: connect: connection refused`
+	got := an.Anonymize(text)
+
+	require.Equal(t, `Failed to pull image "<<registry-1>>/team/app:1.2.3": dial tcp <<ip-1>>:443: connect: connection refused`, got)
+	require.Equal(t, text, an.Deanonymize(got))
+}
+
+func TestAnonymizeFreeText(t *testing.T) {
+	an := New()
+	got := an.Anonymize(`user jane.doe@example.com sent Authorization: Bearer abcdefghijklmnop to api.example.com in namespace "payments"`)
+
+	require.Equal(t, `user <<email-1>> sent Authorization: Bearer <<token-1>> to <<host-1>> in namespace "<<namespace-1>>"`, got)
+}
+
+func TestAnonymizeKnownNamesAreDeterministic(t *testing.T) {
+	an := New()
+	an.AddKnown(CategoryName, "my.app+v2", "web")
+
+	first := an.Anonymize("Pod my.app+v2 and web-1 and web are failing")
+	second := an.Anonymize("web restarted")
+
+	require.Equal(t, "Pod <<name-1>> and web-1 and <<name-2>> are failing", first)
+	require.Equal(t, "<<name-2>> restarted", second)
+	require.Equal(t, "Pod my.app+v2 and web-1 and web are failing", an.Deanonymize(first))
+}
+
+func TestAnonymizeKeepsPublicDomains(t *testing.T) {
+	an := New()
+	text := "see https://kubernetes.io/docs for node.kubernetes.io/not-ready"
+
+	require.Equal(t, text, an.Anonymize(text))
+}
+
+func TestDeanonymizeOnlyDelimitedPseudonyms(t *testing.T) {
+	an := New()
+	an.AddKnown(CategoryName, "checkout")
+
+	require.Equal(t, "Pod <<name-1>> is failing", an.Anonymize("Pod checkout is failing"))
+	require.Equal(t, "checkout, name-10 and name-1-foo", an.Deanonymize("<<name-1>>, name-10 and name-1-foo"))
+}
@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+)
+
+var _ (ICache) = (*AzureCache)(nil)
+
+// AzureCache stores the answers as blobs of a container, credentials come from the environment
+type AzureCache struct {
+	noCache       bool
+	containerName string
+	session       *azblob.Client
+}
+
+func init() {
+	cacheRegistry[TypeAzure] = NewAzureCache
+}
+
+func NewAzureCache(cache CacheProvider, noCache bool) (ICache, error) {
+	credential, err := azidentity.NewDefaultAzureCredential(nil)
+	if err != nil {
+		return nil, err
+	}
+	serviceURL := fmt.Sprintf("https://%s.blob.core.windows.net/", cache.StorageAccount)
+	client, err := azblob.NewClient(serviceURL, credential, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create the container if it does not exist yet, an existing container returns an error we can ignore
+	_, _ = client.CreateContainer(context.Background(), cache.ContainerName, nil)
+
+	return &AzureCache{
+		noCache:       noCache,
+		containerName: cache.ContainerName,
+		session:       client,
+	}, nil
+}
+
+func (s *AzureCache) Store(key string, data string) error {
+	_, err := s.session.UploadBuffer(context.Background(), s.containerName, key, []byte(data), nil)
+	return err
+}
+
+func (s *AzureCache) Load(key string) (string, error) {
+	response, err := s.session.DownloadStream(context.Background(), s.containerName, key, nil)
+	if err != nil {
+		return "", err
+	}
+	defer response.Body.Close()
+
+	buf := new(bytes.Buffer)
+	if _, err := buf.ReadFrom(response.Body); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func (s *AzureCache) List() ([]CacheObjectDetails, error) {
+	var result []CacheObjectDetails
+	pager := s.session.NewListBlobsFlatPager(s.containerName, nil)
+	for pager.More() {
+		page, err := pager.NextPage(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		for _, blob := range page.Segment.BlobItems {
+			if blob.Name == nil {
+				continue
+			}
+			details := CacheObjectDetails{
+				Name: *blob.Name,
+			}
+			if blob.Properties != nil {
+				if blob.Properties.ContentLength != nil {
+					details.Size = *blob.Properties.ContentLength
+				}
+				if blob.Properties.LastModified != nil {
+					details.UpdatedAt = *blob.Properties.LastModified
+				}
+			}
+			result = append(result, details)
+		}
+	}
+	return result, nil
+}
+
+func (s *AzureCache) Delete(key string) error {
+	_, err := s.session.DeleteBlob(context.Background(), s.containerName, key, nil)
+	return err
+}
+
+func (s *AzureCache) Exists(key string) bool {
+	_, err := s.session.ServiceClient().NewContainerClient(s.containerName).NewBlobClient(key).GetProperties(context.Background(), nil)
+	return err == nil
+}
+
+func (s *AzureCache) IsCacheDisabled() bool {
+	return s.noCache
+}
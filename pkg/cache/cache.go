@@ -2,56 +2,216 @@ package cache
 
 import (
 	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/spf13/viper"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 type ICache interface {
 	Store(key string, data string) error
 	Load(key string) (string, error)
-	List() ([]string, error)
+	List() ([]CacheObjectDetails, error)
+	Delete(key string) error
 	Exists(key string) bool
 	IsCacheDisabled() bool
 }
 
-func New(noCache bool, remoteCache bool) ICache {
-	if remoteCache {
-		return NewS3Cache(noCache)
+// CacheObjectDetails describes a stored object without loading it
+type CacheObjectDetails struct {
+	Name      string
+	Size      int64
+	UpdatedAt time.Time
+}
+
+const (
+	TypeFile  = "file"
+	TypeS3    = "s3"
+	TypeRedis = "redis"
+	TypeAzure = "azure"
+	TypeGCS   = "gcs"
+)
+
+type cacheFactory func(config CacheProvider, noCache bool) (ICache, error)
+
+// cacheRegistry maps a cache type to its constructor, remote caches register themselves in their own file
+var cacheRegistry = map[string]cacheFactory{
+	TypeFile: func(config CacheProvider, noCache bool) (ICache, error) {
+		return &FileBasedCache{
+			noCache: noCache,
+		}, nil
+	},
+}
+
+func RegisteredTypes() []string {
+	types := make([]string, 0, len(cacheRegistry))
+	for t := range cacheRegistry {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// New returns the configured cache. Remote caches are fronted by an in-memory LRU tier when
+// memoryentries is set, so that repeated answers do not round-trip to the remote store.
+func New(noCache bool) (ICache, error) {
+	config, err := GetCacheConfig()
+	if err != nil {
+		return nil, err
+	}
+	cacheType := config.GetType()
+	factory, ok := cacheRegistry[cacheType]
+	if !ok {
+		return nil, fmt.Errorf("unknown cache type %q, supported types are %s", cacheType, strings.Join(RegisteredTypes(), ", "))
+	}
+	c, err := factory(config, noCache)
+	if err != nil {
+		return nil, err
 	}
-	return &FileBasedCache{
-		noCache: noCache,
+	if cacheType != TypeFile && config.MemoryEntries > 0 {
+		return NewTieredCache(sharedMemoryCache(config.MemoryEntries), c), nil
 	}
+	return c, nil
 }
 
 // CacheProvider is the configuration for the cache provider when using a remote cache
 type CacheProvider struct {
+	// Type is one of file, s3, redis, azure or gcs. Configurations written before the type existed
+	// only have a bucket and a region, they are read as s3.
+	Type       string `mapstructure:"type" yaml:"type,omitempty"`
 	BucketName string `mapstructure:"bucketname"`
 	Region     string `mapstructure:"region"`
+	// Endpoint points the s3 cache at an S3-compatible store such as MinIO
+	Endpoint  string `mapstructure:"endpoint" yaml:"endpoint,omitempty"`
+	PathStyle bool   `mapstructure:"pathstyle" yaml:"pathstyle,omitempty"`
+	// ProjectId is the GCP project of the gcs bucket
+	ProjectId      string `mapstructure:"projectid" yaml:"projectid,omitempty"`
+	StorageAccount string `mapstructure:"storageaccount" yaml:"storageaccount,omitempty"`
+	ContainerName  string `mapstructure:"containername" yaml:"containername,omitempty"`
+	RedisAddress   string `mapstructure:"redisaddress" yaml:"redisaddress,omitempty"`
+	RedisPassword  string `mapstructure:"redispassword" yaml:"redispassword,omitempty"`
+	RedisDB        int    `mapstructure:"redisdb" yaml:"redisdb,omitempty"`
+	// MemoryEntries is the size of the in-memory LRU tier in front of a remote cache, 0 disables it
+	MemoryEntries int `mapstructure:"memoryentries" yaml:"memoryentries,omitempty"`
+	// TTL is how long an answer is reused, e.g. 72h. Empty never expires.
+	TTL string `mapstructure:"ttl" yaml:"ttl,omitempty"`
+	// MaxSize bounds the total size of the cache, e.g. 100Mi. Oldest entries are evicted first.
+	MaxSize string `mapstructure:"maxsize" yaml:"maxsize,omitempty"`
 }
 
-func RemoteCacheEnabled() (bool, error) {
-	// load remote cache if it is configured
+func (c CacheProvider) GetType() string {
+	if c.Type != "" {
+		return c.Type
+	}
+	if c.BucketName != "" && c.Region != "" {
+		return TypeS3
+	}
+	return TypeFile
+}
+
+// Validate checks that the fields required by the cache type are set
+func (c CacheProvider) Validate() error {
+	switch c.GetType() {
+	case TypeFile:
+	case TypeS3:
+		if c.BucketName == "" || (c.Region == "" && c.Endpoint == "") {
+			return errors.New("s3 cache requires a bucket and a region or an endpoint")
+		}
+	case TypeGCS:
+		if c.BucketName == "" || c.ProjectId == "" {
+			return errors.New("gcs cache requires a bucket and a project id")
+		}
+	case TypeAzure:
+		if c.StorageAccount == "" || c.ContainerName == "" {
+			return errors.New("azure cache requires a storage account and a container")
+		}
+	case TypeRedis:
+		if c.RedisAddress == "" {
+			return errors.New("redis cache requires an address")
+		}
+	default:
+		return fmt.Errorf("unknown cache type %q, supported types are %s", c.Type, strings.Join(RegisteredTypes(), ", "))
+	}
+	if c.MemoryEntries < 0 {
+		return errors.New("memory entries must not be negative")
+	}
+	return nil
+}
+
+func GetCacheConfig() (CacheProvider, error) {
 	var cache CacheProvider
 	err := viper.UnmarshalKey("cache", &cache)
+	return cache, err
+}
+
+func (c CacheProvider) TTLDuration() time.Duration {
+	ttl, err := time.ParseDuration(c.TTL)
 	if err != nil {
-		return false, err
+		return 0
 	}
-	if cache.BucketName != "" && cache.Region != "" {
-		return true, nil
+	return ttl
+}
+
+func (c CacheProvider) MaxSizeBytes() int64 {
+	size, err := resource.ParseQuantity(c.MaxSize)
+	if err != nil {
+		return 0
 	}
-	return false, nil
+	return size.Value()
 }
 
-func AddRemoteCache(bucketName string, region string) error {
-	var cacheInfo CacheProvider
-	err := viper.UnmarshalKey("cache", &cacheInfo)
+// SetCacheLimits stores the expiry and size limits used by every cache type. A nil limit is left
+// unchanged, an empty one is removed.
+func SetCacheLimits(ttl *string, maxSize *string) error {
+	if ttl != nil && *ttl != "" {
+		if _, err := time.ParseDuration(*ttl); err != nil {
+			return fmt.Errorf("invalid ttl %q: %v", *ttl, err)
+		}
+	}
+	if maxSize != nil && *maxSize != "" {
+		if _, err := resource.ParseQuantity(*maxSize); err != nil {
+			return fmt.Errorf("invalid max size %q: %v", *maxSize, err)
+		}
+	}
+	cacheInfo, err := GetCacheConfig()
 	if err != nil {
 		return err
 	}
-
-	cacheInfo.BucketName = bucketName
-	cacheInfo.Region = region
+	if ttl != nil {
+		cacheInfo.TTL = *ttl
+	}
+	if maxSize != nil {
+		cacheInfo.MaxSize = *maxSize
+	}
 	viper.Set("cache", cacheInfo)
+	return viper.WriteConfig()
+}
+
+func RemoteCacheEnabled() (bool, error) {
+	// load remote cache if it is configured
+	cache, err := GetCacheConfig()
+	if err != nil {
+		return false, err
+	}
+	return cache.GetType() != TypeFile, nil
+}
+
+// AddRemoteCache replaces the remote cache configuration, the limits of the cache are kept
+func AddRemoteCache(remote CacheProvider) error {
+	if err := remote.Validate(); err != nil {
+		return err
+	}
+	cacheInfo, err := GetCacheConfig()
+	if err != nil {
+		return err
+	}
+
+	remote.TTL = cacheInfo.TTL
+	remote.MaxSize = cacheInfo.MaxSize
+	viper.Set("cache", remote)
 	err = viper.WriteConfig()
 	if err != nil {
 		return err
@@ -59,17 +219,19 @@ func AddRemoteCache(bucketName string, region string) error {
 	return nil
 }
 
-func RemoveRemoteCache(bucketName string) error {
-	var cacheInfo CacheProvider
-	err := viper.UnmarshalKey("cache", &cacheInfo)
+func RemoveRemoteCache() error {
+	cacheInfo, err := GetCacheConfig()
 	if err != nil {
 		return err
 	}
-	if cacheInfo.BucketName == "" {
+	if cacheInfo.GetType() == TypeFile {
 		return errors.New("Error: no cache is configured")
 	}
 
-	cacheInfo = CacheProvider{}
+	cacheInfo = CacheProvider{
+		TTL:     cacheInfo.TTL,
+		MaxSize: cacheInfo.MaxSize,
+	}
 	viper.Set("cache", cacheInfo)
 	err = viper.WriteConfig()
 	if err != nil {
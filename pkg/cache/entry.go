@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/util"
+)
+
+// Entry is what is stored in the cache for an AI answer, the metadata allows expiring entries
+// and telling which configuration produced them
+type Entry struct {
+	Provider   string    `json:"provider"`
+	Model      string    `json:"model"`
+	PromptHash string    `json:"promptHash"`
+	CreatedAt  time.Time `json:"createdAt"`
+	Data       string    `json:"data"`
+}
+
+func NewEntry(provider string, model string, promptTmpl string, data string) Entry {
+	return Entry{
+		Provider:   provider,
+		Model:      model,
+		PromptHash: util.GetPromptHash(promptTmpl),
+		CreatedAt:  time.Now().UTC(),
+		Data:       data,
+	}
+}
+
+// Expired reports whether the entry is older than ttl, a zero ttl never expires
+func (e Entry) Expired(ttl time.Duration) bool {
+	if ttl <= 0 || e.CreatedAt.IsZero() {
+		return false
+	}
+	return time.Since(e.CreatedAt) > ttl
+}
+
+func EncodeEntry(e Entry) (string, error) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// DecodeEntry reads an entry, values stored before entries had metadata are returned as the data
+// of an entry without metadata
+func DecodeEntry(raw string) Entry {
+	var e Entry
+	if strings.HasPrefix(raw, "{") && json.Unmarshal([]byte(raw), &e) == nil {
+		return e
+	}
+	return Entry{Data: raw}
+}
+
+// LoadEntry returns the entry stored under key, or nil when there is none or it has expired.
+// Expired entries are deleted.
+func LoadEntry(c ICache, key string) (*Entry, error) {
+	if !c.Exists(key) {
+		return nil, nil
+	}
+	raw, err := c.Load(key)
+	if err != nil {
+		return nil, err
+	}
+	e := DecodeEntry(raw)
+	config, err := GetCacheConfig()
+	if err != nil {
+		return nil, err
+	}
+	if e.Expired(config.TTLDuration()) {
+		return nil, c.Delete(key)
+	}
+	return &e, nil
+}
+
+func StoreEntry(c ICache, key string, e Entry) error {
+	data, err := EncodeEntry(e)
+	if err != nil {
+		return err
+	}
+	return c.Store(key, data)
+}
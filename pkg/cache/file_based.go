@@ -19,7 +19,7 @@ func (f *FileBasedCache) IsCacheDisabled() bool {
 	return f.noCache
 }
 
-func (*FileBasedCache) List() ([]string, error) {
+func (*FileBasedCache) List() ([]CacheObjectDetails, error) {
 	path, err := xdg.CacheFile("k8sgpt")
 	if err != nil {
 		return nil, err
@@ -30,9 +30,17 @@ func (*FileBasedCache) List() ([]string, error) {
 		return nil, err
 	}
 
-	var result []string
+	var result []CacheObjectDetails
 	for _, file := range files {
-		result = append(result, file.Name())
+		info, err := file.Info()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, CacheObjectDetails{
+			Name:      file.Name(),
+			Size:      info.Size(),
+			UpdatedAt: info.ModTime(),
+		})
 	}
 
 	return result, nil
@@ -81,3 +89,13 @@ func (*FileBasedCache) Store(key string, data string) error {
 
 	return os.WriteFile(path, []byte(data), 0600)
 }
+
+func (*FileBasedCache) Delete(key string) error {
+	path, err := xdg.CacheFile(filepath.Join("k8sgpt", key))
+
+	if err != nil {
+		return err
+	}
+
+	return os.Remove(path)
+}
@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"context"
+	"io"
+
+	"cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
+)
+
+var _ (ICache) = (*GCSCache)(nil)
+
+// GCSCache stores the answers as objects of a bucket, credentials come from the environment
+type GCSCache struct {
+	noCache    bool
+	bucketName string
+	projectId  string
+	session    *storage.Client
+}
+
+func init() {
+	cacheRegistry[TypeGCS] = NewGCSCache
+}
+
+func NewGCSCache(cache CacheProvider, noCache bool) (ICache, error) {
+	ctx := context.Background()
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check if the bucket exists, if not create it
+	bucket := client.Bucket(cache.BucketName)
+	if _, err := bucket.Attrs(ctx); err == storage.ErrBucketNotExist {
+		if err := bucket.Create(ctx, cache.ProjectId, &storage.BucketAttrs{
+			Location: cache.Region,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	return &GCSCache{
+		noCache:    noCache,
+		bucketName: cache.BucketName,
+		projectId:  cache.ProjectId,
+		session:    client,
+	}, nil
+}
+
+func (s *GCSCache) Store(key string, data string) error {
+	writer := s.session.Bucket(s.bucketName).Object(key).NewWriter(context.Background())
+	if _, err := writer.Write([]byte(data)); err != nil {
+		_ = writer.Close()
+		return err
+	}
+	return writer.Close()
+}
+
+func (s *GCSCache) Load(key string) (string, error) {
+	reader, err := s.session.Bucket(s.bucketName).Object(key).NewReader(context.Background())
+	if err != nil {
+		return "", err
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func (s *GCSCache) List() ([]CacheObjectDetails, error) {
+	var result []CacheObjectDetails
+	objects := s.session.Bucket(s.bucketName).Objects(context.Background(), nil)
+	for {
+		attrs, err := objects.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, CacheObjectDetails{
+			Name:      attrs.Name,
+			Size:      attrs.Size,
+			UpdatedAt: attrs.Updated,
+		})
+	}
+	return result, nil
+}
+
+func (s *GCSCache) Delete(key string) error {
+	return s.session.Bucket(s.bucketName).Object(key).Delete(context.Background())
+}
+
+func (s *GCSCache) Exists(key string) bool {
+	_, err := s.session.Bucket(s.bucketName).Object(key).Attrs(context.Background())
+	return err == nil
+}
+
+func (s *GCSCache) IsCacheDisabled() bool {
+	return s.noCache
+}
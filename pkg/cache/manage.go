@@ -0,0 +1,130 @@
+package cache
+
+import (
+	"sort"
+	"time"
+)
+
+type Stats struct {
+	Entries   int
+	TotalSize int64
+	Expired   int
+	Oldest    time.Time
+	Newest    time.Time
+}
+
+// GetStats summarizes the content of the cache
+func GetStats(c ICache) (Stats, error) {
+	var stats Stats
+	objects, err := c.List()
+	if err != nil {
+		return stats, err
+	}
+	config, err := GetCacheConfig()
+	if err != nil {
+		return stats, err
+	}
+	ttl := config.TTLDuration()
+
+	for _, object := range objects {
+		created, err := createdAt(c, object)
+		if err != nil {
+			return stats, err
+		}
+		stats.Entries++
+		stats.TotalSize += object.Size
+		if stats.Oldest.IsZero() || created.Before(stats.Oldest) {
+			stats.Oldest = created
+		}
+		if created.After(stats.Newest) {
+			stats.Newest = created
+		}
+		if (Entry{CreatedAt: created}).Expired(ttl) {
+			stats.Expired++
+		}
+	}
+	return stats, nil
+}
+
+// createdAt returns when an entry was stored, from its metadata or, for the entries stored before
+// the metadata existed, from the write time reported by the cache
+func createdAt(c ICache, object CacheObjectDetails) (time.Time, error) {
+	raw, err := c.Load(object.Name)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if e := DecodeEntry(raw); !e.CreatedAt.IsZero() {
+		return e.CreatedAt, nil
+	}
+	return object.UpdatedAt, nil
+}
+
+// Purge deletes the expired entries, or every entry when all is set, and returns how many were deleted
+func Purge(c ICache, all bool) (int, error) {
+	objects, err := c.List()
+	if err != nil {
+		return 0, err
+	}
+	config, err := GetCacheConfig()
+	if err != nil {
+		return 0, err
+	}
+	ttl := config.TTLDuration()
+
+	deleted := 0
+	for _, object := range objects {
+		if !all {
+			created, err := createdAt(c, object)
+			if err != nil {
+				return deleted, err
+			}
+			if !(Entry{CreatedAt: created}).Expired(ttl) {
+				continue
+			}
+		}
+		if err := c.Delete(object.Name); err != nil {
+			return deleted, err
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+// Evict deletes the entries written first until the cache fits in its maximum size. It runs after every
+// analysis, so it only lists the cache: the entries are aged by their write time and none is read. The expired
+// entries are deleted when they are read or purged.
+func Evict(c ICache) error {
+	config, err := GetCacheConfig()
+	if err != nil {
+		return err
+	}
+	maxSize := config.MaxSizeBytes()
+	if maxSize <= 0 {
+		return nil
+	}
+	objects, err := c.List()
+	if err != nil {
+		return err
+	}
+	var total int64
+	for _, object := range objects {
+		total += object.Size
+	}
+	if total <= maxSize {
+		return nil
+	}
+
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].UpdatedAt.Before(objects[j].UpdatedAt)
+	})
+	for _, object := range objects {
+		if total <= maxSize {
+			break
+		}
+		if err := c.Delete(object.Name); err != nil {
+			return err
+		}
+		total -= object.Size
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+)
+
+// storeAged stores an entry created age ago
+func storeAged(t *testing.T, c ICache, key string, age time.Duration) {
+	e := NewEntry("openai", "gpt-4", "template", key)
+	e.CreatedAt = time.Now().Add(-age)
+	require.NoError(t, StoreEntry(c, key, e))
+}
+
+func TestDecodeEntryLegacy(t *testing.T) {
+	e := DecodeEntry("a plain answer stored before entries had metadata")
+
+	require.Equal(t, "a plain answer stored before entries had metadata", e.Data)
+	require.True(t, e.CreatedAt.IsZero())
+	require.False(t, e.Expired(time.Hour))
+}
+
+func TestLoadEntryExpires(t *testing.T) {
+	viper.Set("cache", map[string]interface{}{"ttl": "1h"})
+	defer viper.Set("cache", nil)
+
+	c := NewMemoryCache(0)
+	storeAged(t, c, "fresh", time.Minute)
+	storeAged(t, c, "old", 2*time.Hour)
+	require.NoError(t, c.Store("legacy", "answer"))
+
+	e, err := LoadEntry(c, "fresh")
+	require.NoError(t, err)
+	require.Equal(t, "fresh", e.Data)
+
+	e, err = LoadEntry(c, "old")
+	require.NoError(t, err)
+	require.Nil(t, e)
+	require.False(t, c.Exists("old"))
+
+	e, err = LoadEntry(c, "legacy")
+	require.NoError(t, err)
+	require.Equal(t, "answer", e.Data)
+}
+
+func TestPurge(t *testing.T) {
+	viper.Set("cache", map[string]interface{}{"ttl": "1h"})
+	defer viper.Set("cache", nil)
+
+	c := NewMemoryCache(0)
+	storeAged(t, c, "fresh", time.Minute)
+	// written recently but created long ago, the metadata wins over the write time
+	storeAged(t, c, "old", 2*time.Hour)
+	require.NoError(t, c.Store("legacy", "answer"))
+
+	deleted, err := Purge(c, false)
+	require.NoError(t, err)
+	require.Equal(t, 1, deleted)
+	require.True(t, c.Exists("fresh"))
+	require.False(t, c.Exists("old"))
+	require.True(t, c.Exists("legacy"))
+
+	deleted, err = Purge(c, true)
+	require.NoError(t, err)
+	require.Equal(t, 2, deleted)
+}
+
+func TestEvict(t *testing.T) {
+	viper.Set("cache", map[string]interface{}{"ttl": "24h", "maxsize": "300"})
+	defer viper.Set("cache", nil)
+
+	c := NewMemoryCache(0)
+	storeAged(t, c, "oldest", 2*time.Hour)
+	time.Sleep(time.Millisecond)
+	storeAged(t, c, "middle", time.Hour)
+	time.Sleep(time.Millisecond)
+	storeAged(t, c, "newest", time.Minute)
+
+	require.NoError(t, Evict(c))
+	require.False(t, c.Exists("oldest"))
+	require.True(t, c.Exists("middle"))
+	require.True(t, c.Exists("newest"))
+}
+
+func TestEvictUnderMaxSize(t *testing.T) {
+	viper.Set("cache", map[string]interface{}{"ttl": "1h", "maxsize": "1Mi"})
+	defer viper.Set("cache", nil)
+
+	c := &loadCounter{ICache: NewMemoryCache(0)}
+	storeAged(t, c, "expired", 2*time.Hour)
+	storeAged(t, c, "fresh", time.Minute)
+
+	// under the maximum size nothing is read nor deleted, the expired entries are left to reads and purges
+	require.NoError(t, Evict(c))
+	require.Equal(t, 0, c.loads)
+	require.True(t, c.Exists("expired"))
+	require.True(t, c.Exists("fresh"))
+}
+
+// loadCounter counts the entries read from a cache
+type loadCounter struct {
+	ICache
+	loads int
+}
+
+func (c *loadCounter) Load(key string) (string, error) {
+	c.loads++
+	return c.ICache.Load(key)
+}
+
+func TestSetCacheLimitsKeepsUnsetLimits(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "k8sgpt.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("cache:\n  ttl: 72h\n  maxsize: 100Mi\n"), 0600))
+	viper.SetConfigFile(path)
+	require.NoError(t, viper.ReadInConfig())
+	defer viper.Set("cache", nil)
+
+	ttl := "24h"
+	require.NoError(t, SetCacheLimits(&ttl, nil))
+	config, err := GetCacheConfig()
+	require.NoError(t, err)
+	require.Equal(t, "24h", config.TTL)
+	require.Equal(t, "100Mi", config.MaxSize)
+
+	empty := ""
+	require.NoError(t, SetCacheLimits(nil, &empty))
+	config, err = GetCacheConfig()
+	require.NoError(t, err)
+	require.Equal(t, "24h", config.TTL)
+	require.Equal(t, "", config.MaxSize)
+
+}
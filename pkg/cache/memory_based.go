@@ -0,0 +1,168 @@
+package cache
+
+import (
+	"container/list"
+	"errors"
+	"sync"
+	"time"
+)
+
+var _ (ICache) = (*MemoryCache)(nil)
+
+// MemoryCache is a bounded in-memory LRU cache, it is used as a tier in front of remote caches
+type MemoryCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	order      *list.List
+	items      map[string]*list.Element
+}
+
+type memoryItem struct {
+	key       string
+	data      string
+	updatedAt time.Time
+}
+
+func NewMemoryCache(maxEntries int) *MemoryCache {
+	return &MemoryCache{
+		maxEntries: maxEntries,
+		order:      list.New(),
+		items:      map[string]*list.Element{},
+	}
+}
+
+var (
+	memoryCacheOnce sync.Once
+	memoryCache     *MemoryCache
+)
+
+// sharedMemoryCache returns the process wide memory tier, so that it survives across analyses
+// run by the same server
+func sharedMemoryCache(maxEntries int) *MemoryCache {
+	memoryCacheOnce.Do(func() {
+		memoryCache = NewMemoryCache(maxEntries)
+	})
+	return memoryCache
+}
+
+func (m *MemoryCache) Store(key string, data string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if element, ok := m.items[key]; ok {
+		item := element.Value.(*memoryItem)
+		item.data = data
+		item.updatedAt = time.Now()
+		m.order.MoveToFront(element)
+		return nil
+	}
+	m.items[key] = m.order.PushFront(&memoryItem{key: key, data: data, updatedAt: time.Now()})
+	for m.maxEntries > 0 && m.order.Len() > m.maxEntries {
+		oldest := m.order.Back()
+		m.order.Remove(oldest)
+		delete(m.items, oldest.Value.(*memoryItem).key)
+	}
+	return nil
+}
+
+func (m *MemoryCache) Load(key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	element, ok := m.items[key]
+	if !ok {
+		return "", errors.New("key not found in memory cache")
+	}
+	m.order.MoveToFront(element)
+	return element.Value.(*memoryItem).data, nil
+}
+
+func (m *MemoryCache) List() ([]CacheObjectDetails, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var result []CacheObjectDetails
+	for element := m.order.Front(); element != nil; element = element.Next() {
+		item := element.Value.(*memoryItem)
+		result = append(result, CacheObjectDetails{
+			Name:      item.key,
+			Size:      int64(len(item.data)),
+			UpdatedAt: item.updatedAt,
+		})
+	}
+	return result, nil
+}
+
+func (m *MemoryCache) Delete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if element, ok := m.items[key]; ok {
+		m.order.Remove(element)
+		delete(m.items, key)
+	}
+	return nil
+}
+
+func (m *MemoryCache) Exists(key string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, ok := m.items[key]
+	return ok
+}
+
+func (m *MemoryCache) IsCacheDisabled() bool {
+	return false
+}
+
+var _ (ICache) = (*TieredCache)(nil)
+
+// TieredCache reads from the memory tier first and falls back to the remote cache, writes go to both
+type TieredCache struct {
+	memory *MemoryCache
+	remote ICache
+}
+
+func NewTieredCache(memory *MemoryCache, remote ICache) *TieredCache {
+	return &TieredCache{
+		memory: memory,
+		remote: remote,
+	}
+}
+
+func (t *TieredCache) Store(key string, data string) error {
+	if err := t.remote.Store(key, data); err != nil {
+		return err
+	}
+	return t.memory.Store(key, data)
+}
+
+func (t *TieredCache) Load(key string) (string, error) {
+	if data, err := t.memory.Load(key); err == nil {
+		return data, nil
+	}
+	data, err := t.remote.Load(key)
+	if err != nil {
+		return "", err
+	}
+	_ = t.memory.Store(key, data)
+	return data, nil
+}
+
+func (t *TieredCache) List() ([]CacheObjectDetails, error) {
+	return t.remote.List()
+}
+
+func (t *TieredCache) Delete(key string) error {
+	_ = t.memory.Delete(key)
+	return t.remote.Delete(key)
+}
+
+func (t *TieredCache) Exists(key string) bool {
+	return t.memory.Exists(key) || t.remote.Exists(key)
+}
+
+func (t *TieredCache) IsCacheDisabled() bool {
+	return t.remote.IsCacheDisabled()
+}
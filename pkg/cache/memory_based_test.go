@@ -0,0 +1,36 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := NewMemoryCache(2)
+	require.NoError(t, c.Store("a", "1"))
+	require.NoError(t, c.Store("b", "2"))
+
+	// reading a makes b the least recently used entry
+	_, err := c.Load("a")
+	require.NoError(t, err)
+	require.NoError(t, c.Store("c", "3"))
+
+	require.True(t, c.Exists("a"))
+	require.False(t, c.Exists("b"))
+	require.True(t, c.Exists("c"))
+}
+
+func TestTieredCacheFillsMemoryFromRemote(t *testing.T) {
+	remote := NewMemoryCache(0)
+	require.NoError(t, remote.Store("key", "value"))
+	tiered := NewTieredCache(NewMemoryCache(10), remote)
+
+	data, err := tiered.Load("key")
+	require.NoError(t, err)
+	require.Equal(t, "value", data)
+	require.True(t, tiered.memory.Exists("key"))
+
+	require.NoError(t, tiered.Delete("key"))
+	require.False(t, tiered.Exists("key"))
+}
@@ -0,0 +1,88 @@
+package cache
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const redisKeyPrefix = "k8sgpt:"
+
+var _ (ICache) = (*RedisCache)(nil)
+
+// RedisCache shares the answers between the replicas of the server
+type RedisCache struct {
+	noCache bool
+	client  *redis.Client
+	ttl     time.Duration
+}
+
+func init() {
+	cacheRegistry[TypeRedis] = NewRedisCache
+}
+
+func NewRedisCache(cache CacheProvider, noCache bool) (ICache, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     cache.RedisAddress,
+		Password: cache.RedisPassword,
+		DB:       cache.RedisDB,
+	})
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		return nil, err
+	}
+	return &RedisCache{
+		noCache: noCache,
+		client:  client,
+		// redis expires the keys itself, so entries do not wait for a purge
+		ttl: cache.TTLDuration(),
+	}, nil
+}
+
+func (r *RedisCache) Store(key string, data string) error {
+	return r.client.Set(context.Background(), redisKeyPrefix+key, data, r.ttl).Err()
+}
+
+func (r *RedisCache) Load(key string) (string, error) {
+	return r.client.Get(context.Background(), redisKeyPrefix+key).Result()
+}
+
+func (r *RedisCache) List() ([]CacheObjectDetails, error) {
+	ctx := context.Background()
+	var result []CacheObjectDetails
+	iter := r.client.Scan(ctx, 0, redisKeyPrefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		data, err := r.client.Get(ctx, key).Result()
+		if err == redis.Nil {
+			// expired between the scan and the read
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, CacheObjectDetails{
+			Name: key[len(redisKeyPrefix):],
+			Size: int64(len(data)),
+			// redis does not keep the write time, the entry metadata does
+			UpdatedAt: DecodeEntry(data).CreatedAt,
+		})
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (r *RedisCache) Delete(key string) error {
+	return r.client.Del(context.Background(), redisKeyPrefix+key).Err()
+}
+
+func (r *RedisCache) Exists(key string) bool {
+	count, err := r.client.Exists(context.Background(), redisKeyPrefix+key).Result()
+	return err == nil && count > 0
+}
+
+func (r *RedisCache) IsCacheDisabled() bool {
+	return r.noCache
+}
@@ -2,11 +2,11 @@ package cache
 
 import (
 	"bytes"
+	"errors"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/s3"
-	"github.com/spf13/viper"
 )
 
 // Generate ICache implementation
@@ -44,22 +44,36 @@ func (s *S3Cache) Load(key string) (string, error) {
 	return buf.String(), nil
 }
 
-func (s *S3Cache) List() ([]string, error) {
+func (s *S3Cache) List() ([]CacheObjectDetails, error) {
 
 	// List the files in the bucket
-	result, err := s.session.ListObjectsV2(&s3.ListObjectsV2Input{Bucket: aws.String(s.bucketName)})
+	var keys []CacheObjectDetails
+	err := s.session.ListObjectsV2Pages(&s3.ListObjectsV2Input{Bucket: aws.String(s.bucketName)},
+		func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+			for _, item := range page.Contents {
+				keys = append(keys, CacheObjectDetails{
+					Name:      aws.StringValue(item.Key),
+					Size:      aws.Int64Value(item.Size),
+					UpdatedAt: aws.TimeValue(item.LastModified),
+				})
+			}
+			return true
+		})
 	if err != nil {
 		return nil, err
 	}
 
-	var keys []string
-	for _, item := range result.Contents {
-		keys = append(keys, *item.Key)
-	}
-
 	return keys, nil
 }
 
+func (s *S3Cache) Delete(key string) error {
+	_, err := s.session.DeleteObject(&s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucketName),
+		Key:    aws.String(key),
+	})
+	return err
+}
+
 func (s *S3Cache) Exists(key string) bool {
 	// Check if the object exists in the bucket
 	_, err := s.session.HeadObject(&s3.HeadObjectInput{
@@ -74,26 +88,37 @@ func (s *S3Cache) IsCacheDisabled() bool {
 	return s.noCache
 }
 
-func NewS3Cache(nocache bool) ICache {
+func init() {
+	cacheRegistry[TypeS3] = NewS3Cache
+}
 
-	var cache CacheProvider
-	err := viper.UnmarshalKey("cache", &cache)
-	if err != nil {
-		panic(err)
-	}
+// NewS3Cache connects to an AWS bucket, or to any S3-compatible store when an endpoint is configured
+func NewS3Cache(cache CacheProvider, nocache bool) (ICache, error) {
 	if cache.BucketName == "" {
-		panic("Bucket name not configured")
+		return nil, errors.New("bucket name not configured")
 	}
-	if cache.Region == "" {
-		panic("Region not configured")
+
+	config := aws.Config{
+		Region: aws.String(cache.Region),
+	}
+	if cache.Endpoint != "" {
+		config.Endpoint = aws.String(cache.Endpoint)
+		// MinIO and most S3-compatible stores do not serve virtual-hosted buckets
+		config.S3ForcePathStyle = aws.Bool(cache.PathStyle)
+		if cache.Region == "" {
+			config.Region = aws.String("us-east-1")
+		}
+	} else if cache.Region == "" {
+		return nil, errors.New("region not configured")
 	}
 
-	sess := session.Must(session.NewSessionWithOptions(session.Options{
+	sess, err := session.NewSessionWithOptions(session.Options{
 		SharedConfigState: session.SharedConfigEnable,
-		Config: aws.Config{
-			Region: aws.String(cache.Region),
-		},
-	}))
+		Config:            config,
+	})
+	if err != nil {
+		return nil, err
+	}
 
 	s := s3.New(sess)
 
@@ -111,5 +136,5 @@ func NewS3Cache(nocache bool) ICache {
 		noCache:    nocache,
 		session:    s,
 		bucketName: cache.BucketName,
-	}
+	}, nil
 }
@@ -22,7 +22,8 @@ import (
 	"github.com/k8sgpt-ai/k8sgpt/pkg/ai"
 	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
 	appsv1 "k8s.io/api/apps/v1"
-	autov1 "k8s.io/api/autoscaling/v1"
+	autov2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
 	networkv1 "k8s.io/api/networking/v1"
 	policyv1 "k8s.io/api/policy/v1"
@@ -40,6 +41,8 @@ type Analyzer struct {
 	PreAnalysis   map[string]PreAnalysis
 	Results       []Result
 	OpenapiSchema *openapi_v2.Document
+	// Events is shared by the analyzers of a run, so the events are listed only once
+	Events *kubernetes.EventIndex
 }
 
 type PreAnalysis struct {
@@ -52,9 +55,11 @@ type PreAnalysis struct {
 	PersistentVolumeClaim    v1.PersistentVolumeClaim
 	Endpoint                 v1.Endpoints
 	Ingress                  networkv1.Ingress
-	HorizontalPodAutoscalers autov1.HorizontalPodAutoscaler
+	HorizontalPodAutoscalers autov2.HorizontalPodAutoscaler
 	PodDisruptionBudget      policyv1.PodDisruptionBudget
 	StatefulSet              appsv1.StatefulSet
+	DaemonSet                appsv1.DaemonSet
+	Job                      batchv1.Job
 	NetworkPolicy            networkv1.NetworkPolicy
 	Node                     v1.Node
 	GatewayClass             gtwapi.GatewayClass
@@ -65,14 +70,15 @@ type PreAnalysis struct {
 }
 
 type Result struct {
-	Kind         string    `json:"kind"`
-	Name         string    `json:"name"`
-	Namespace    string    `json:"namespace"`
-	ResourceName string    `json:"resourceName"`
-	Error        []Failure `json:"error"`
-	Details      string    `json:"details"`
-	Ref          string    `json:"ref"`
-	ParentObject string    `json:"parentObject"`
+	Kind         string       `json:"kind"`
+	Name         string       `json:"name"`
+	Namespace    string       `json:"namespace"`
+	ResourceName string       `json:"resourceName"`
+	Error        []Failure    `json:"error"`
+	Details      string       `json:"details"`
+	Ref          string       `json:"ref"`
+	ParentObject string       `json:"parentObject"`
+	Enrichments  []Enrichment `json:"enrichments,omitempty"`
 }
 
 type Failure struct {
@@ -81,6 +87,13 @@ type Failure struct {
 	Sensitive     []Sensitive
 }
 
+// Enrichment is related context attached to a result before it is sent to the AI backend
+type Enrichment struct {
+	Source    string      `json:"source"`
+	Text      string      `json:"text"`
+	Sensitive []Sensitive `json:"-"`
+}
+
 type Sensitive struct {
 	Unmasked string
 	Masked   string
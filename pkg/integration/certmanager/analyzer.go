@@ -0,0 +1,174 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certmanager
+
+import (
+	"fmt"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/util"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// GroupVersion is the API of the cert-manager resources, read as unstructured objects to avoid depending on cert-manager
+var GroupVersion = schema.GroupVersion{Group: "cert-manager.io", Version: "v1"}
+
+type CertManagerAnalyzer struct {
+}
+
+// condition is a condition of a cert-manager resource
+type condition struct {
+	Type    string
+	Status  string
+	Reason  string
+	Message string
+}
+
+func (CertManagerAnalyzer) Analyze(a common.Analyzer) ([]common.Result, error) {
+
+	certificates, err := listResources(a, "CertificateList")
+	if err != nil || certificates == nil {
+		return nil, err
+	}
+	requests, err := listResources(a, "CertificateRequestList")
+	if err != nil || requests == nil {
+		return nil, err
+	}
+
+	// the most recent request of every Certificate, the older ones were superseded
+	latestRequests := map[string]unstructured.Unstructured{}
+	for _, request := range requests.Items {
+		certificate := request.GetAnnotations()["cert-manager.io/certificate-name"]
+		if certificate == "" {
+			continue
+		}
+		key := request.GetNamespace() + "/" + certificate
+		if latest, ok := latestRequests[key]; !ok || latest.GetCreationTimestamp().Time.Before(request.GetCreationTimestamp().Time) {
+			latestRequests[key] = request
+		}
+	}
+
+	for _, certificate := range certificates.Items {
+		var failures []common.Failure
+		sensitive := []common.Sensitive{
+			{
+				Unmasked: certificate.GetNamespace(),
+				Masked:   util.MaskString(certificate.GetNamespace()),
+			},
+			{
+				Unmasked: certificate.GetName(),
+				Masked:   util.MaskString(certificate.GetName()),
+			},
+		}
+
+		conditions := resourceConditions(certificate)
+		if ready, ok := conditions["Ready"]; ok && ready.Status == "False" && ready.Reason == "Expired" {
+			failures = append(failures, common.Failure{
+				Text:      fmt.Sprintf("Certificate %s/%s has expired: %s", certificate.GetNamespace(), certificate.GetName(), ready.Message),
+				Sensitive: sensitive,
+			})
+		}
+		if issuing, ok := conditions["Issuing"]; ok && issuing.Status == "False" && issuing.Reason == "Failed" {
+			failures = append(failures, common.Failure{
+				Text:      fmt.Sprintf("Certificate %s/%s failed to be issued: %s", certificate.GetNamespace(), certificate.GetName(), issuing.Message),
+				Sensitive: sensitive,
+			})
+		}
+
+		// the issuer explains the failure on the request it did not sign
+		if request, ok := latestRequests[certificate.GetNamespace()+"/"+certificate.GetName()]; ok {
+			issuerName, _, _ := unstructured.NestedString(request.Object, "spec", "issuerRef", "name")
+			issuerKind, _, _ := unstructured.NestedString(request.Object, "spec", "issuerRef", "kind")
+			if issuerKind == "" {
+				issuerKind = "Issuer"
+			}
+			requestConditions := resourceConditions(request)
+			for _, conditionType := range []string{"Denied", "InvalidRequest", "Ready"} {
+				c, ok := requestConditions[conditionType]
+				failed := ok && ((conditionType == "Ready" && c.Status == "False" && (c.Reason == "Failed" || c.Reason == "Denied")) ||
+					(conditionType != "Ready" && c.Status == "True"))
+				if !failed {
+					continue
+				}
+				failures = append(failures, common.Failure{
+					Text: fmt.Sprintf("CertificateRequest %s/%s of the Certificate %s was not signed by the %s %s: %s: %s",
+						request.GetNamespace(), request.GetName(), certificate.GetName(), issuerKind, issuerName, c.Reason, c.Message),
+					Sensitive: append(sensitive, common.Sensitive{
+						Unmasked: issuerName,
+						Masked:   util.MaskString(issuerName),
+					}),
+				})
+				break
+			}
+		}
+
+		if len(failures) == 0 {
+			continue
+		}
+		var currentAnalysis = common.Result{
+			Kind:  "Certificate",
+			Name:  fmt.Sprintf("%s/%s", certificate.GetNamespace(), certificate.GetName()),
+			Error: failures,
+		}
+		// the certificates of the ingress-shim are owned by their Ingress
+		parent, _ := util.GetParent(a.Client, metav1.ObjectMeta{
+			Namespace:       certificate.GetNamespace(),
+			Name:            certificate.GetName(),
+			OwnerReferences: certificate.GetOwnerReferences(),
+		})
+		currentAnalysis.ParentObject = parent
+		a.Results = append(a.Results, currentAnalysis)
+	}
+
+	return a.Results, nil
+}
+
+// listResources lists a kind of cert-manager resource, nil is returned when cert-manager is not installed
+func listResources(a common.Analyzer, kind string) (*unstructured.UnstructuredList, error) {
+	if a.Client.GetCtrlClient() == nil {
+		return nil, nil
+	}
+	list := &unstructured.UnstructuredList{}
+	list.SetGroupVersionKind(GroupVersion.WithKind(kind))
+	if err := a.Client.GetCtrlClient().List(a.Context, list, ctrl.InNamespace(a.Namespace)); err != nil {
+		if meta.IsNoMatchError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return list, nil
+}
+
+// resourceConditions returns the status conditions of a resource by type
+func resourceConditions(object unstructured.Unstructured) map[string]condition {
+	conditions := map[string]condition{}
+	items, _, _ := unstructured.NestedSlice(object.Object, "status", "conditions")
+	for _, item := range items {
+		fields, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		c := condition{}
+		c.Type, _, _ = unstructured.NestedString(fields, "type")
+		c.Status, _, _ = unstructured.NestedString(fields, "status")
+		c.Reason, _, _ = unstructured.NestedString(fields, "reason")
+		c.Message, _, _ = unstructured.NestedString(fields, "message")
+		conditions[c.Type] = c
+	}
+	return conditions
+}
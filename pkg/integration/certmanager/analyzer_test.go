@@ -0,0 +1,128 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certmanager
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
+	"github.com/magiconair/properties/assert"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/kubernetes/fake"
+	ctrl "sigs.k8s.io/controller-runtime/pkg/client"
+	fakectrl "sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// resource builds a cert-manager resource with its status conditions, each condition is type, status, reason and message
+func resource(kind string, name string, conditions ...[4]string) *unstructured.Unstructured {
+	var items []interface{}
+	for _, c := range conditions {
+		items = append(items, map[string]interface{}{"type": c[0], "status": c[1], "reason": c[2], "message": c[3]})
+	}
+	object := &unstructured.Unstructured{Object: map[string]interface{}{
+		"status": map[string]interface{}{"conditions": items},
+	}}
+	object.SetGroupVersionKind(GroupVersion.WithKind(kind))
+	object.SetNamespace("test")
+	object.SetName(name)
+	return object
+}
+
+// request builds a CertificateRequest of a Certificate created age ago
+func request(name string, certificate string, age time.Duration, conditions ...[4]string) *unstructured.Unstructured {
+	object := resource("CertificateRequest", name, conditions...)
+	object.SetAnnotations(map[string]string{"cert-manager.io/certificate-name": certificate})
+	object.SetCreationTimestamp(metav1.NewTime(time.Now().Add(-age)))
+	_ = unstructured.SetNestedField(object.Object, map[string]interface{}{"name": "letsencrypt", "kind": "ClusterIssuer"}, "spec", "issuerRef")
+	return object
+}
+
+func TestCertManagerAnalyzer(t *testing.T) {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(GroupVersion.WithKind("Certificate"), meta.RESTScopeNamespace)
+	mapper.Add(GroupVersion.WithKind("CertificateRequest"), meta.RESTScopeNamespace)
+	scheme, err := kubernetes.NewScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+	objects := []ctrl.Object{
+		resource("Certificate", "healthy", [4]string{"Ready", "True", "Ready", "Certificate is up to date and has not expired"}),
+		resource("Certificate", "expired", [4]string{"Ready", "False", "Expired", "Certificate expired on Sun, 01 Oct 2023"}),
+		resource("Certificate", "failing",
+			[4]string{"Ready", "False", "DoesNotExist", "Issuing certificate as Secret does not exist"},
+			[4]string{"Issuing", "False", "Failed", "The certificate request has failed to complete and will be retried"}),
+		resource("Certificate", "denied", [4]string{"Issuing", "True", "Issuing", "Issuing certificate as Secret does not exist"}),
+		resource("Certificate", "invalid", [4]string{"Issuing", "True", "Issuing", "Issuing certificate as Secret does not exist"}),
+		// the older request of the failing Certificate was denied, only the latest one is reported
+		request("failing-1", "failing", 2*time.Hour, [4]string{"Denied", "True", "Denied", "denied by policy"}),
+		request("failing-2", "failing", time.Hour, [4]string{"Ready", "False", "Failed", "rate limited by the ACME server"}),
+		request("denied-1", "denied", time.Hour, [4]string{"Denied", "True", "PolicyDenied", "dnsName example.org is not allowed"}),
+		request("invalid-1", "invalid", time.Hour, [4]string{"InvalidRequest", "True", "BadConfig", "the CSR cannot be decoded"}),
+	}
+	analyzer := common.Analyzer{
+		Client: &kubernetes.Client{
+			Client:     fake.NewSimpleClientset(),
+			CtrlClient: fakectrl.NewClientBuilder().WithScheme(scheme).WithRESTMapper(mapper).WithObjects(objects...).Build(),
+		},
+		Context: context.Background(),
+	}
+	results, err := CertManagerAnalyzer{}.Analyze(analyzer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Name < results[j].Name })
+
+	texts := map[string][]string{}
+	for _, result := range results {
+		for _, failure := range result.Error {
+			texts[result.Name] = append(texts[result.Name], failure.Text)
+		}
+	}
+	assert.Equal(t, texts, map[string][]string{
+		"test/denied": {
+			"CertificateRequest test/denied-1 of the Certificate denied was not signed by the ClusterIssuer letsencrypt: PolicyDenied: dnsName example.org is not allowed",
+		},
+		"test/expired": {
+			"Certificate test/expired has expired: Certificate expired on Sun, 01 Oct 2023",
+		},
+		"test/failing": {
+			"Certificate test/failing failed to be issued: The certificate request has failed to complete and will be retried",
+			"CertificateRequest test/failing-2 of the Certificate failing was not signed by the ClusterIssuer letsencrypt: Failed: rate limited by the ACME server",
+		},
+		"test/invalid": {
+			"CertificateRequest test/invalid-1 of the Certificate invalid was not signed by the ClusterIssuer letsencrypt: BadConfig: the CSR cannot be decoded",
+		},
+	})
+}
+
+func TestCertManagerAnalyzerNotInstalled(t *testing.T) {
+	scheme, err := kubernetes.NewScheme()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, client := range []*kubernetes.Client{
+		{Client: fake.NewSimpleClientset()},
+		{Client: fake.NewSimpleClientset(), CtrlClient: fakectrl.NewClientBuilder().WithScheme(scheme).Build()},
+	} {
+		results, err := CertManagerAnalyzer{}.Analyze(common.Analyzer{Client: client, Context: context.Background()})
+		assert.Equal(t, err, nil)
+		assert.Equal(t, len(results), 0)
+	}
+}
@@ -0,0 +1,119 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package certmanager
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/k8sgpt-ai/k8sgpt/pkg/common"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/kubernetes"
+	"github.com/k8sgpt-ai/k8sgpt/pkg/util"
+	helmclient "github.com/mittwald/go-helm-client"
+	"github.com/spf13/viper"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+const (
+	Repo          = "https://charts.jetstack.io"
+	Version       = "v1.13.2"
+	ChartName     = "cert-manager"
+	RepoShortName = "jetstack"
+	ReleaseName   = "cert-manager-k8sgpt"
+	AnalyzerName  = "CertManager"
+)
+
+type CertManager struct {
+	helm helmclient.Client
+}
+
+func NewCertManager() *CertManager {
+	helmClient, err := helmclient.New(&helmclient.Options{})
+	if err != nil {
+		panic(err)
+	}
+	return &CertManager{
+		helm: helmClient,
+	}
+}
+
+func (c *CertManager) GetAnalyzerName() string {
+	return AnalyzerName
+}
+
+// Deploy installs cert-manager, unless the cluster already serves its API
+func (c *CertManager) Deploy(namespace string) error {
+	client, err := kubernetes.NewClient(viper.GetString("kubecontext"), viper.GetString("kubeconfig"))
+	if err != nil {
+		return err
+	}
+	if _, err := client.GetClient().Discovery().ServerResourcesForGroupVersion(GroupVersion.String()); err == nil {
+		return nil
+	}
+
+	// Add the repository
+	chartRepo := repo.Entry{
+		Name: RepoShortName,
+		URL:  Repo,
+	}
+	if err := c.helm.AddOrUpdateChartRepo(chartRepo); err != nil {
+		return err
+	}
+
+	chartSpec := helmclient.ChartSpec{
+		ReleaseName: ReleaseName,
+		ChartName:   fmt.Sprintf("%s/%s", RepoShortName, ChartName),
+		Version:     Version,
+		Namespace:   namespace,
+		ValuesYaml:  "installCRDs: true",
+		UpgradeCRDs: true,
+		Wait:        false,
+		Timeout:     300,
+	}
+	if _, err := c.helm.InstallOrUpgradeChart(context.Background(), &chartSpec, nil); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// UnDeploy removes the release installed by Deploy, a cert-manager installed otherwise is left alone
+func (c *CertManager) UnDeploy(namespace string) error {
+	if _, err := c.helm.GetRelease(ReleaseName); err != nil {
+		return nil
+	}
+	chartSpec := helmclient.ChartSpec{
+		ReleaseName: ReleaseName,
+		ChartName:   fmt.Sprintf("%s/%s", RepoShortName, ChartName),
+		Namespace:   namespace,
+		Wait:        false,
+		Timeout:     300,
+	}
+	return c.helm.UninstallRelease(&chartSpec)
+}
+
+// IsActivate relies on the active filters, cert-manager is usually installed before the integration is activated
+func (c *CertManager) IsActivate() bool {
+	return util.SliceContainsString(viper.GetStringSlice("active_filters"), AnalyzerName)
+}
+
+func (c *CertManager) AddAnalyzer(mergedMap *map[string]common.IAnalyzer) {
+
+	(*mergedMap)[AnalyzerName] = &CertManagerAnalyzer{}
+
+}
+
+func (c *CertManager) RemoveAnalyzer() error {
+	return nil
+}
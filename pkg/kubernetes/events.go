@@ -0,0 +1,179 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubernetes
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+	eventsv1 "k8s.io/api/events/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Event is an event of the events.k8s.io/v1 or of the core API
+type Event struct {
+	Type           string
+	Reason         string
+	Message        string
+	Count          int32
+	FirstTimestamp time.Time
+	LastTimestamp  time.Time
+	InvolvedObject v1.ObjectReference
+}
+
+// EventIndex lists the events of a namespace once and serves the lookups of every analyzer of a run
+type EventIndex struct {
+	client    *Client
+	namespace string
+
+	once   sync.Once
+	err    error
+	events []Event
+	byUID  map[types.UID][]Event
+	byName map[string][]Event
+}
+
+func NewEventIndex(client *Client, namespace string) *EventIndex {
+	return &EventIndex{
+		client:    client,
+		namespace: namespace,
+	}
+}
+
+func (i *EventIndex) load(ctx context.Context) error {
+	i.once.Do(func() {
+		i.events, i.err = i.list(ctx)
+		i.byUID = map[types.UID][]Event{}
+		i.byName = map[string][]Event{}
+		for _, event := range i.events {
+			if event.InvolvedObject.UID != "" {
+				i.byUID[event.InvolvedObject.UID] = append(i.byUID[event.InvolvedObject.UID], event)
+			}
+			key := nameKey(event.InvolvedObject.Kind, event.InvolvedObject.Namespace, event.InvolvedObject.Name)
+			i.byName[key] = append(i.byName[key], event)
+		}
+	})
+	return i.err
+}
+
+// list prefers events.k8s.io/v1 and falls back to the core events when the API is not served
+// or returns nothing, both APIs expose the same events when they are available
+func (i *EventIndex) list(ctx context.Context) ([]Event, error) {
+	var events []Event
+	list, err := i.client.GetClient().EventsV1().Events(i.namespace).List(ctx, metav1.ListOptions{})
+	if err == nil {
+		for _, event := range list.Items {
+			events = append(events, fromEventsV1(event))
+		}
+	}
+	if len(events) > 0 {
+		return events, nil
+	}
+
+	coreList, err := i.client.GetClient().CoreV1().Events(i.namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	for _, event := range coreList.Items {
+		events = append(events, fromCoreEvent(event))
+	}
+	return events, nil
+}
+
+// ForObject returns the events about an object. The UID is used when it is known, so that the
+// events of a deleted object are not mixed up with the object that replaced it.
+func (i *EventIndex) ForObject(ctx context.Context, kind string, object metav1.ObjectMeta) ([]Event, error) {
+	if err := i.load(ctx); err != nil {
+		return nil, err
+	}
+	if object.UID != "" {
+		if events, ok := i.byUID[object.UID]; ok {
+			return events, nil
+		}
+	}
+	var events []Event
+	for _, event := range i.byName[nameKey(kind, object.Namespace, object.Name)] {
+		if object.UID == "" || event.InvolvedObject.UID == "" {
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+// All returns every event of the namespace
+func (i *EventIndex) All(ctx context.Context) ([]Event, error) {
+	if err := i.load(ctx); err != nil {
+		return nil, err
+	}
+	return i.events, nil
+}
+
+func nameKey(kind string, namespace string, name string) string {
+	return kind + "/" + namespace + "/" + name
+}
+
+func fromCoreEvent(event v1.Event) Event {
+	e := Event{
+		Type:           event.Type,
+		Reason:         event.Reason,
+		Message:        event.Message,
+		Count:          event.Count,
+		FirstTimestamp: event.FirstTimestamp.Time,
+		LastTimestamp:  event.LastTimestamp.Time,
+		InvolvedObject: event.InvolvedObject,
+	}
+	if event.Series != nil {
+		e.Count = event.Series.Count
+		e.LastTimestamp = event.Series.LastObservedTime.Time
+	}
+	if e.FirstTimestamp.IsZero() {
+		e.FirstTimestamp = event.EventTime.Time
+	}
+	if e.LastTimestamp.IsZero() {
+		e.LastTimestamp = e.FirstTimestamp
+	}
+	if e.Count == 0 {
+		e.Count = 1
+	}
+	return e
+}
+
+func fromEventsV1(event eventsv1.Event) Event {
+	e := Event{
+		Type:           event.Type,
+		Reason:         event.Reason,
+		Message:        event.Note,
+		Count:          event.DeprecatedCount,
+		FirstTimestamp: event.DeprecatedFirstTimestamp.Time,
+		LastTimestamp:  event.DeprecatedLastTimestamp.Time,
+		InvolvedObject: event.Regarding,
+	}
+	if event.Series != nil {
+		e.Count = event.Series.Count
+		e.LastTimestamp = event.Series.LastObservedTime.Time
+	}
+	if e.FirstTimestamp.IsZero() {
+		e.FirstTimestamp = event.EventTime.Time
+	}
+	if e.LastTimestamp.IsZero() {
+		e.LastTimestamp = e.FirstTimestamp
+	}
+	if e.Count == 0 {
+		e.Count = 1
+	}
+	return e
+}
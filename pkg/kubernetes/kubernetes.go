@@ -14,16 +14,32 @@ limitations under the License.
 package kubernetes
 
 import (
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	"k8s.io/client-go/kubernetes"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth/oidc"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/kubectl/pkg/scheme"
 	"os"
 	ctrl "sigs.k8s.io/controller-runtime/pkg/client"
+	gtwapi "sigs.k8s.io/gateway-api/apis/v1"
 )
 
+// NewScheme returns the scheme of the controller-runtime client, it knows the
+// built-in types and the CRDs read by the analyzers
+func NewScheme() (*runtime.Scheme, error) {
+	s := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(s); err != nil {
+		return nil, err
+	}
+	if err := gtwapi.AddToScheme(s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
 func (c *Client) GetConfig() *rest.Config {
 	return c.Config
 }
@@ -65,6 +81,16 @@ func NewClient(kubecontext string, kubeconfig string) (*Client, error) {
 	if err != nil {
 		return nil, err
 	}
+
+	ctrlScheme, err := NewScheme()
+	if err != nil {
+		return nil, err
+	}
+	// the controller-runtime client copies the config, so it is created before the config is specialised for the core group
+	ctrlClient, err := ctrl.New(config, ctrl.Options{Scheme: ctrlScheme})
+	if err != nil {
+		return nil, err
+	}
 	config.APIPath = "/api"
 	config.GroupVersion = &scheme.Scheme.PrioritizedVersionsForGroup("")[0]
 	config.NegotiatedSerializer = serializer.WithoutConversionCodecFactory{CodecFactory: scheme.Codecs}
@@ -84,5 +110,6 @@ func NewClient(kubecontext string, kubeconfig string) (*Client, error) {
 		RestClient:    restClient,
 		Config:        config,
 		ServerVersion: serverVersion,
+		CtrlClient:    ctrlClient,
 	}, nil
 }
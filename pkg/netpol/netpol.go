@@ -0,0 +1,279 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package netpol
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Engine evaluates the NetworkPolicies of a cluster to tell whether a pod can reach another one on a port
+type Engine struct {
+	policies   []networkingv1.NetworkPolicy
+	namespaces map[string]labels.Set
+}
+
+// Verdict is the answer of the Engine for a connection
+type Verdict struct {
+	Allowed bool
+	// Reason explains the verdict, it names the policies denying the connection
+	Reason string
+}
+
+// NewEngine reads the NetworkPolicies and the Namespaces of the cluster
+func NewEngine(ctx context.Context, client kubernetes.Interface) (*Engine, error) {
+	policies, err := client.NetworkingV1().NetworkPolicies("").List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	namespaces, err := client.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	engine := &Engine{
+		policies:   policies.Items,
+		namespaces: map[string]labels.Set{},
+	}
+	for _, ns := range namespaces.Items {
+		engine.namespaces[ns.Name] = labels.Set(ns.Labels)
+	}
+	return engine, nil
+}
+
+// Empty reports whether the cluster has no NetworkPolicy, all the traffic is then allowed
+func (e *Engine) Empty() bool {
+	return len(e.policies) == 0
+}
+
+// Check tells whether the pod from can open a connection to the pod to on a port. The egress policies of the
+// source and the ingress policies of the destination must both allow it
+func (e *Engine) Check(from v1.Pod, to v1.Pod, port int32, protocol v1.Protocol) Verdict {
+	if protocol == "" {
+		protocol = v1.ProtocolTCP
+	}
+	connection := fmt.Sprintf("%s/%s to %s/%s on %s/%d", from.Namespace, from.Name, to.Namespace, to.Name, protocol, port)
+
+	egress := e.isolating(from, networkingv1.PolicyTypeEgress)
+	var egressAllowed []string
+	for _, policy := range egress {
+		for _, rule := range policy.Spec.Egress {
+			if portsMatch(rule.Ports, to, port, protocol) && e.peersMatch(rule.To, policy.Namespace, to) {
+				egressAllowed = append(egressAllowed, policyName(policy))
+				break
+			}
+		}
+	}
+	if len(egress) > 0 && len(egressAllowed) == 0 {
+		return Verdict{Reason: fmt.Sprintf("the traffic from %s is denied by the egress of the NetworkPolicies %s, none of their rules allow it",
+			connection, policyNames(egress))}
+	}
+
+	ingress := e.isolating(to, networkingv1.PolicyTypeIngress)
+	var ingressAllowed []string
+	for _, policy := range ingress {
+		for _, rule := range policy.Spec.Ingress {
+			if portsMatch(rule.Ports, to, port, protocol) && e.peersMatch(rule.From, policy.Namespace, from) {
+				ingressAllowed = append(ingressAllowed, policyName(policy))
+				break
+			}
+		}
+	}
+	if len(ingress) > 0 && len(ingressAllowed) == 0 {
+		return Verdict{Reason: fmt.Sprintf("the traffic from %s is denied by the ingress of the NetworkPolicies %s, none of their rules allow it",
+			connection, policyNames(ingress))}
+	}
+
+	reason := fmt.Sprintf("the traffic from %s is allowed", connection)
+	var by []string
+	if len(egressAllowed) > 0 {
+		by = append(by, "the egress of "+strings.Join(egressAllowed, ", "))
+	}
+	if len(ingressAllowed) > 0 {
+		by = append(by, "the ingress of "+strings.Join(ingressAllowed, ", "))
+	}
+	if len(by) > 0 {
+		reason += " by " + strings.Join(by, " and ")
+	} else {
+		reason += ", no NetworkPolicy isolates the pods"
+	}
+	return Verdict{Allowed: true, Reason: reason}
+}
+
+// isolating returns the policies selecting a pod for a direction of the traffic
+func (e *Engine) isolating(pod v1.Pod, policyType networkingv1.PolicyType) []networkingv1.NetworkPolicy {
+	var policies []networkingv1.NetworkPolicy
+	for _, policy := range e.policies {
+		if policy.Namespace != pod.Namespace || !hasPolicyType(policy, policyType) {
+			continue
+		}
+		if selectorMatches(&policy.Spec.PodSelector, labels.Set(pod.Labels)) {
+			policies = append(policies, policy)
+		}
+	}
+	return policies
+}
+
+func policyName(policy networkingv1.NetworkPolicy) string {
+	return policy.Namespace + "/" + policy.Name
+}
+
+func policyNames(policies []networkingv1.NetworkPolicy) string {
+	names := make([]string, 0, len(policies))
+	for _, policy := range policies {
+		names = append(names, policyName(policy))
+	}
+	return strings.Join(names, ", ")
+}
+
+// peersMatch reports whether a pod is one of the peers of a rule, a rule without peers matches every pod
+func (e *Engine) peersMatch(peers []networkingv1.NetworkPolicyPeer, policyNamespace string, pod v1.Pod) bool {
+	if len(peers) == 0 {
+		return true
+	}
+	for _, peer := range peers {
+		if peer.IPBlock != nil {
+			if ipBlockMatches(peer.IPBlock, pod.Status.PodIP) {
+				return true
+			}
+			continue
+		}
+		namespaceMatches := pod.Namespace == policyNamespace
+		if peer.NamespaceSelector != nil {
+			namespaceMatches = selectorMatches(peer.NamespaceSelector, e.namespaceLabels(pod.Namespace))
+		}
+		podMatches := peer.PodSelector == nil || selectorMatches(peer.PodSelector, labels.Set(pod.Labels))
+		if namespaceMatches && podMatches {
+			return true
+		}
+	}
+	return false
+}
+
+// namespaceLabels returns the labels of a namespace, the name label is set by the API server on every namespace
+func (e *Engine) namespaceLabels(namespace string) labels.Set {
+	if set, ok := e.namespaces[namespace]; ok {
+		return set
+	}
+	return labels.Set{"kubernetes.io/metadata.name": namespace}
+}
+
+// TargetPort resolves the port of a pod receiving the traffic of a Service port, a named targetPort must be
+// declared by a container of the pod
+func TargetPort(pod v1.Pod, port v1.ServicePort) (int32, bool) {
+	target := port.TargetPort
+	if target.Type == intstr.String {
+		return containerPort(pod, target.StrVal, port.Protocol)
+	}
+	if target.IntVal == 0 {
+		return port.Port, true
+	}
+	return target.IntVal, true
+}
+
+// containerPort returns the number of a named port of a pod
+func containerPort(pod v1.Pod, name string, protocol v1.Protocol) (int32, bool) {
+	if protocol == "" {
+		protocol = v1.ProtocolTCP
+	}
+	for _, container := range append(append([]v1.Container{}, pod.Spec.InitContainers...), pod.Spec.Containers...) {
+		for _, port := range container.Ports {
+			portProtocol := port.Protocol
+			if portProtocol == "" {
+				portProtocol = v1.ProtocolTCP
+			}
+			if port.Name == name && portProtocol == protocol {
+				return port.ContainerPort, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// portsMatch reports whether a port of the destination pod is one of the ports of a rule, a rule without ports
+// matches every port
+func portsMatch(ports []networkingv1.NetworkPolicyPort, to v1.Pod, port int32, protocol v1.Protocol) bool {
+	if len(ports) == 0 {
+		return true
+	}
+	for _, p := range ports {
+		portProtocol := v1.ProtocolTCP
+		if p.Protocol != nil {
+			portProtocol = *p.Protocol
+		}
+		if portProtocol != protocol {
+			continue
+		}
+		switch {
+		case p.Port == nil:
+			return true
+		case p.Port.Type == intstr.String:
+			if number, ok := containerPort(to, p.Port.StrVal, protocol); ok && number == port {
+				return true
+			}
+		case p.Port.IntVal == port:
+			return true
+		case p.EndPort != nil && port >= p.Port.IntVal && port <= *p.EndPort:
+			return true
+		}
+	}
+	return false
+}
+
+// hasPolicyType tells whether a policy restricts a direction of the traffic, without policyTypes the policies
+// restrict the ingress, and the egress when they have egress rules
+func hasPolicyType(policy networkingv1.NetworkPolicy, policyType networkingv1.PolicyType) bool {
+	if len(policy.Spec.PolicyTypes) == 0 {
+		return policyType == networkingv1.PolicyTypeIngress || len(policy.Spec.Egress) > 0
+	}
+	for _, t := range policy.Spec.PolicyTypes {
+		if t == policyType {
+			return true
+		}
+	}
+	return false
+}
+
+func selectorMatches(selector *metav1.LabelSelector, set labels.Set) bool {
+	s, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return false
+	}
+	return s.Matches(set)
+}
+
+func ipBlockMatches(block *networkingv1.IPBlock, ip string) bool {
+	address := net.ParseIP(ip)
+	if address == nil {
+		return false
+	}
+	_, cidr, err := net.ParseCIDR(block.CIDR)
+	if err != nil || !cidr.Contains(address) {
+		return false
+	}
+	for _, except := range block.Except {
+		if _, excluded, err := net.ParseCIDR(except); err == nil && excluded.Contains(address) {
+			return false
+		}
+	}
+	return true
+}
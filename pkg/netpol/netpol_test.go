@@ -0,0 +1,131 @@
+/*
+Copyright 2023 The K8sGPT Authors.
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+    http://www.apache.org/licenses/LICENSE-2.0
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package netpol
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/magiconair/properties/assert"
+	v1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func testPod(namespace string, name string, ip string, labels map[string]string) v1.Pod {
+	return v1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace, Labels: labels},
+		Spec: v1.PodSpec{
+			Containers: []v1.Container{{Name: name, Ports: []v1.ContainerPort{{Name: "http", ContainerPort: 8080}}}},
+		},
+		Status: v1.PodStatus{PodIP: ip},
+	}
+}
+
+func TestEngineCheck(t *testing.T) {
+	http := intstr.FromString("http")
+	clientset := fake.NewSimpleClientset(
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "shop"}},
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ops", Labels: map[string]string{"team": "ops"}}},
+		&v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "batch"}},
+		&networkingv1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "deny-all", Namespace: "shop"},
+		},
+		&networkingv1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "allow-frontend", Namespace: "shop"},
+			Spec: networkingv1.NetworkPolicySpec{
+				PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "api"}},
+				Ingress: []networkingv1.NetworkPolicyIngressRule{
+					{
+						From:  []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "frontend"}}}},
+						Ports: []networkingv1.NetworkPolicyPort{{Port: &http}},
+					},
+					{
+						From: []networkingv1.NetworkPolicyPeer{{NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "ops"}}}},
+					},
+				},
+			},
+		},
+		&networkingv1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "internal-only", Namespace: "batch"},
+			Spec: networkingv1.NetworkPolicySpec{
+				PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeEgress},
+				Egress: []networkingv1.NetworkPolicyEgressRule{
+					{To: []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: "10.0.0.0/8", Except: []string{"10.1.0.0/16"}}}}},
+				},
+			},
+		},
+	)
+	engine, err := NewEngine(context.Background(), clientset)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	api := testPod("shop", "api", "10.1.0.1", map[string]string{"app": "api"})
+	frontend := testPod("shop", "frontend", "10.1.0.2", map[string]string{"app": "frontend"})
+	db := testPod("shop", "db", "10.1.0.3", map[string]string{"app": "db"})
+	monitor := testPod("ops", "monitor", "10.2.0.1", nil)
+	job := testPod("batch", "job", "10.3.0.1", nil)
+	cache := testPod("batch", "cache", "10.3.0.2", nil)
+
+	tests := []struct {
+		name    string
+		from    v1.Pod
+		to      v1.Pod
+		port    int32
+		allowed bool
+		reason  string
+	}{
+		{"named port", frontend, api, 8080, true, "by the ingress of shop/allow-frontend"},
+		{"other port", frontend, api, 9090, false, "denied by the ingress of the NetworkPolicies shop/allow-frontend, shop/deny-all"},
+		{"other pod", db, api, 8080, false, "denied by the ingress"},
+		{"namespace selector", monitor, api, 9090, true, "by the ingress of shop/allow-frontend"},
+		{"default deny", frontend, db, 8080, false, "shop/deny-all, none of their rules allow it"},
+		{"ip block", job, cache, 8080, true, "by the egress of batch/internal-only"},
+		{"ip block except", job, api, 8080, false, "denied by the egress of the NetworkPolicies batch/internal-only"},
+		{"not isolated", api, cache, 8080, true, "no NetworkPolicy isolates the pods"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verdict := engine.Check(tt.from, tt.to, tt.port, "")
+			assert.Equal(t, verdict.Allowed, tt.allowed)
+			if !strings.Contains(verdict.Reason, tt.reason) {
+				t.Errorf("reason %q does not contain %q", verdict.Reason, tt.reason)
+			}
+		})
+	}
+}
+
+func TestTargetPort(t *testing.T) {
+	pod := testPod("shop", "api", "10.1.0.1", nil)
+	tests := []struct {
+		port   v1.ServicePort
+		number int32
+		found  bool
+	}{
+		{v1.ServicePort{Port: 80}, 80, true},
+		{v1.ServicePort{Port: 80, TargetPort: intstr.FromInt32(8081)}, 8081, true},
+		{v1.ServicePort{Port: 80, TargetPort: intstr.FromString("http")}, 8080, true},
+		{v1.ServicePort{Port: 80, TargetPort: intstr.FromString("grpc")}, 0, false},
+		{v1.ServicePort{Port: 80, TargetPort: intstr.FromString("http"), Protocol: v1.ProtocolUDP}, 0, false},
+	}
+	for _, tt := range tests {
+		number, found := TargetPort(pod, tt.port)
+		assert.Equal(t, number, tt.number)
+		assert.Equal(t, found, tt.found)
+	}
+}
@@ -34,6 +34,8 @@ func (h *handler) Analyze(ctx context.Context, i *schemav1.AnalyzeRequest) (
 		int(i.MaxConcurrency),
 		false, // Kubernetes Doc disabled in server mode
 		"",
+		false,
+		false,
 	)
 	if err != nil {
 		return &schemav1.AnalyzeResponse{}, err
@@ -10,11 +10,36 @@ import (
 
 func (h *handler) AddConfig(ctx context.Context, i *schemav1.AddConfigRequest) (*schemav1.AddConfigResponse, error,
 ) {
-	if i.Cache.BucketName == "" || i.Cache.Region == "" {
-		return nil, errors.New("BucketName & Region are required")
+	if i.Cache == nil {
+		return nil, errors.New("cache configuration is required")
 	}
 
-	err := cache.AddRemoteCache(i.Cache.BucketName, i.Cache.Region)
+	var remote cache.CacheProvider
+	switch c := i.Cache.GetCacheType().(type) {
+	case *schemav1.Cache_S3Cache:
+		remote = cache.CacheProvider{
+			Type:       cache.TypeS3,
+			BucketName: c.S3Cache.BucketName,
+			Region:     c.S3Cache.Region,
+		}
+	case *schemav1.Cache_AzureCache:
+		remote = cache.CacheProvider{
+			Type:           cache.TypeAzure,
+			StorageAccount: c.AzureCache.StorageAccount,
+			ContainerName:  c.AzureCache.ContainerName,
+		}
+	case *schemav1.Cache_GcsCache:
+		remote = cache.CacheProvider{
+			Type:       cache.TypeGCS,
+			BucketName: c.GcsCache.BucketName,
+			Region:     c.GcsCache.Region,
+			ProjectId:  c.GcsCache.ProjectId,
+		}
+	default:
+		return nil, errors.New("unsupported cache type")
+	}
+
+	err := cache.AddRemoteCache(remote)
 	if err != nil {
 		return &schemav1.AddConfigResponse{}, err
 	}
@@ -26,7 +51,7 @@ func (h *handler) AddConfig(ctx context.Context, i *schemav1.AddConfigRequest) (
 
 func (h *handler) RemoveConfig(ctx context.Context, i *schemav1.RemoveConfigRequest) (*schemav1.RemoveConfigResponse, error,
 ) {
-	err := cache.RemoveRemoteCache(i.Cache.BucketName)
+	err := cache.RemoveRemoteCache()
 	if err != nil {
 		return &schemav1.RemoveConfigResponse{}, err
 	}
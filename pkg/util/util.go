@@ -85,6 +85,26 @@ func GetParent(client *kubernetes.Client, meta metav1.ObjectMeta) (string, bool)
 				}
 				return "DaemonSet/" + ds.Name, false
 
+			case "Job":
+				job, err := client.GetClient().BatchV1().Jobs(meta.Namespace).Get(context.Background(), owner.Name, metav1.GetOptions{})
+				if err != nil {
+					return "", false
+				}
+				if job.OwnerReferences != nil {
+					return GetParent(client, job.ObjectMeta)
+				}
+				return "Job/" + job.Name, false
+
+			case "CronJob":
+				cj, err := client.GetClient().BatchV1().CronJobs(meta.Namespace).Get(context.Background(), owner.Name, metav1.GetOptions{})
+				if err != nil {
+					return "", false
+				}
+				if cj.OwnerReferences != nil {
+					return GetParent(client, cj.ObjectMeta)
+				}
+				return "CronJob/" + cj.Name, false
+
 			case "Ingress":
 				ds, err := client.GetClient().NetworkingV1().Ingresses(meta.Namespace).Get(context.Background(), owner.Name, metav1.GetOptions{})
 				if err != nil {
@@ -142,21 +162,30 @@ func MaskString(input string) string {
 }
 
 func ReplaceIfMatch(text string, pattern string, replacement string) string {
-	re := regexp.MustCompile(fmt.Sprintf(`%s(\b)`, pattern))
+	re := regexp.MustCompile(fmt.Sprintf(`%s(\b)`, regexp.QuoteMeta(pattern)))
 	if re.MatchString(text) {
 		text = re.ReplaceAllString(text, replacement)
 	}
 	return text
 }
 
-func GetCacheKey(provider string, language string, sEnc string) string {
-	data := fmt.Sprintf("%s-%s-%s", provider, language, sEnc)
+// GetCacheKey namespaces the cache key by provider, model and prompt template, so that switching any of
+// them does not return answers produced for another configuration
+func GetCacheKey(provider string, language string, model string, promptTmpl string, sEnc string) string {
+	data := fmt.Sprintf("%s-%s-%s-%s-%s", provider, language, model, GetPromptHash(promptTmpl), sEnc)
 
 	hash := sha256.Sum256([]byte(data))
 
 	return hex.EncodeToString(hash[:])
 }
 
+// GetPromptHash returns a short stable identifier of a prompt template
+func GetPromptHash(promptTmpl string) string {
+	hash := sha256.Sum256([]byte(promptTmpl))
+
+	return hex.EncodeToString(hash[:8])
+}
+
 func GetPodListByLabels(client k.Interface,
 	namespace string,
 	labels map[string]string) (*v1.PodList, error) {
@@ -191,3 +220,20 @@ func EnsureDirExists(dir string) error {
 
 	return err
 }
+
+// EstimateTokens approximates the number of tokens of a text, using the common ratio of 4 characters per token
+func EstimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// TruncateToTokens cuts a text so that its estimated token count fits in the given budget
+func TruncateToTokens(text string, tokens int) string {
+	limit := tokens * 4
+	if limit >= len(text) {
+		return text
+	}
+	if limit <= 0 {
+		return ""
+	}
+	return text[:limit]
+}